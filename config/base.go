@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"math"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Xrxd 除权除息数据 Ex-Right Dividend
@@ -62,6 +65,97 @@ func (x *Xrxd) CalcPos(qty, price float64, closePrice ...float64) (
 	return
 }
 
+// Factor 单次除权除息的前复权因子，除权日之前的价格乘以该因子
+// 未提供复权因子时由除权除息价或登记日收盘价计算
+func (x *Xrxd) Factor() float64 {
+	if x.ExFactor > 0 {
+		return x.ExFactor
+	}
+
+	if x.RegiClosePrice <= 0 {
+		return 1
+	}
+
+	if x.XrxdPrice > 0 {
+		return x.XrxdPrice / x.RegiClosePrice
+	}
+
+	return adjustedPrice(
+		x.RegiClosePrice, x.DivCash, x.DivShare, x.PlaceRate, x.TrabShare, x.PlacePrice,
+	) / x.RegiClosePrice
+}
+
+// adjusted 该时间的价格是否需要按本次除权除息调整: 除权日之前，没有除权日时为登记日及之前
+func (x *Xrxd) adjusted(tm time.Time) bool {
+	if x.ExDate != nil && !x.ExDate.IsZero() {
+		ex := time.Date(x.ExDate.Year(), x.ExDate.Month(), x.ExDate.Day(), 0, 0, 0, 0, tm.Location())
+		return tm.Before(ex)
+	}
+
+	if x.RegDate == nil {
+		return false
+	}
+
+	reg := time.Date(x.RegDate.Year(), x.RegDate.Month(), x.RegDate.Day(), 0, 0, 0, 0, tm.Location())
+	return tm.Before(reg.AddDate(0, 0, 1))
+}
+
+// AdjustFactorField 前复权模式下回放行情附带的复权因子列
+const AdjustFactorField = "AdjFactor"
+
+// SuspendedField 回放行情的停牌标记列，非0表示该K线为停牌时按前收盘补齐的数据
+const SuspendedField = "Suspended"
+
+// SecondaryFields 辅助频率K线写入指标的列，列名由 Frequency.Field 生成
+var SecondaryFields = []string{"Open", "High", "Low", "Close", "Volume", "Amount"}
+
+// AdjustFactor 前复权因子，由除权除息数据计算
+// 某一时间的因子为此后到截止时间之间各次除权除息因子的乘积，截止时间的价格保持不变
+type AdjustFactor struct {
+	inst2xrxd map[string][]*Xrxd
+}
+
+// NewAdjustFactor 由除权除息数据构建前复权因子，instIDs为空时保留全部标的
+func NewAdjustFactor(xrxds []Xrxd, instIDs ...string) *AdjustFactor {
+	need := make(map[string]bool, len(instIDs))
+	for _, instID := range instIDs {
+		need[instID] = true
+	}
+
+	a := &AdjustFactor{inst2xrxd: make(map[string][]*Xrxd)}
+	for i := range xrxds {
+		if len(need) > 0 && !need[xrxds[i].InstID] {
+			continue
+		}
+
+		a.inst2xrxd[xrxds[i].InstID] = append(a.inst2xrxd[xrxds[i].InstID], &xrxds[i])
+	}
+
+	return a
+}
+
+// LoadAdjustFactor 读取除权除息文件构建前复权因子
+func LoadAdjustFactor(file string, instIDs ...string) (*AdjustFactor, error) {
+	var xrxds []Xrxd
+	if err := ReadCsvFile(file, &xrxds); err != nil {
+		return nil, err
+	}
+
+	return NewAdjustFactor(xrxds, instIDs...), nil
+}
+
+// Factor 标的在tm时的前复权因子，只计算截止时间end及之前除权的事件
+func (a *AdjustFactor) Factor(instID string, tm, end time.Time) float64 {
+	factor := 1.0
+	for _, x := range a.inst2xrxd[instID] {
+		if x.adjusted(tm) && !x.adjusted(end) {
+			factor *= x.Factor()
+		}
+	}
+
+	return factor
+}
+
 type Time struct {
 	time.Time
 }
@@ -80,3 +174,15 @@ func (t *Time) UnmarshalCSV(text string) error {
 
 	return nil
 }
+
+// UnmarshalYAML yaml中的日期，格式为 2006-01-02 或 20060102，按本地时区解析
+func (t *Time) UnmarshalYAML(value *yaml.Node) error {
+	for _, layout := range []string{TimeFormatDate2, TimeFormatDate} {
+		if tm, err := time.ParseInLocation(layout, value.Value, time.Local); err == nil {
+			t.Time = tm
+			return nil
+		}
+	}
+
+	return fmt.Errorf("解析日期失败, 格式: %s, text: %v", TimeFormatDate2, value.Value)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 测试前复权因子: 除权日之前的价格乘以此后各次除权因子，截止时间之后的除权不计入
+func TestAdjustFactor(t *testing.T) {
+	date := func(d int) time.Time {
+		return time.Date(2024, 6, d, 0, 0, 0, 0, time.Local)
+	}
+
+	const instID = "000001.XSHE.CS"
+	adjust := NewAdjustFactor([]Xrxd{
+		{InstID: instID, ExDate: &Time{Time: date(10)}, ExFactor: 0.9},
+		{InstID: instID, RegDate: &Time{Time: date(19)}, RegiClosePrice: 10, DivCash: 1},
+		{InstID: instID, ExDate: &Time{Time: date(28)}, ExFactor: 0.5},
+		{InstID: "600000.XSHG.CS", ExDate: &Time{Time: date(10)}, ExFactor: 0.8},
+	}, instID)
+
+	end := date(25).Add(15 * time.Hour)
+	require.InDelta(t, 0.81, adjust.Factor(instID, date(3).Add(10*time.Hour), end), 1e-9)
+	require.InDelta(t, 0.9, adjust.Factor(instID, date(10).Add(10*time.Hour), end), 1e-9)
+	require.InDelta(t, 1.0, adjust.Factor(instID, date(20).Add(10*time.Hour), end), 1e-9)
+	require.InDelta(t, 1.0, adjust.Factor("600000.XSHG.CS", date(3), end), 1e-9)
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"go/token"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -141,6 +143,20 @@ type FutureContract struct {
 	MarginRate MarginRate `yaml:",inline"` // 保证金比例
 
 	FutureFee FutureFee `yaml:",inline"` // 期货费用
+
+	FeeSchedules []FutureFeeSchedule `yaml:"fee-schedules,omitempty"` // 按生效日期切换的期货费用
+}
+
+// FeeAt tm时生效的期货费用，早于全部生效日期时使用FutureFee
+func (s *FutureContract) FeeAt(tm time.Time) FutureFee {
+	fee, effective := s.FutureFee, time.Time{}
+	for _, v := range s.FeeSchedules {
+		if !v.EffectiveDate.After(tm) && !v.EffectiveDate.Before(effective) {
+			fee, effective = v.FutureFee, v.EffectiveDate.Time
+		}
+	}
+
+	return fee
 }
 
 func (s *FutureContract) InitializeObject() error {
@@ -148,7 +164,7 @@ func (s *FutureContract) InitializeObject() error {
 		ContractSize: 100,
 		MinOrderVol:  100,
 		TickSize:     0.01,
-		TPlus:        1,
+		TPlus:        0,
 	}
 
 	s.MarginRate = MarginRate{
@@ -184,6 +200,18 @@ type FutureFee struct {
 	ClosePreviousRate float64 `yaml:"comm-close-previous-rate,omitempty"` // 平昨佣金率
 }
 
+// FutureFeeSchedule 自生效日期起执行的一组期货费用，未配置的费用按0计算
+type FutureFeeSchedule struct {
+	EffectiveDate Time      `yaml:"effective-date"` // 生效日期
+	FutureFee     FutureFee `yaml:",inline"`
+}
+
+// StockFeeSchedule 自生效日期起执行的一组股票费用，未配置的费用按0计算
+type StockFeeSchedule struct {
+	EffectiveDate Time     `yaml:"effective-date"` // 生效日期
+	StockFee      StockFee `yaml:",inline"`
+}
+
 type StockFee struct {
 	TransferFeeRate float64 `yaml:"transfer-fee-rate,omitempty"` // 过户费率
 	TaxRate         float64 `yaml:"tax-rate,omitempty"`          // 印花税率
@@ -195,7 +223,10 @@ type ContractBasic struct {
 	ContractSize float64 `yaml:"contract-size,omitempty"`    // 一手数目，主板股票、ETF为100，科创板、创业板为1
 	MinOrderVol  float64 `yaml:"min-order-volume,omitempty"` // 最小下单单位，主板股票、ETF为100，科创板、创业板为200
 	TickSize     float64 `yaml:"tick-size,omitempty"`        // 最小变动价位 股票 0.01 etf 0.001
-	TPlus        int8    `yaml:"t-plus"`                     // t+x交易 t+1
+	TPlus        int8    `yaml:"t-plus"`                     // t+x交易 股票t+1，期货、跨境ETF、债券ETF、可转债t+0
+
+	Board          Board   `yaml:"board,omitempty"`            // 板块，未配置时按合约代码判断
+	PriceLimitRate float64 `yaml:"price-limit-rate,omitempty"` // 涨跌停幅度，0使用板块默认值，小于0表示不设涨跌停
 }
 
 type StockContract struct {
@@ -203,6 +234,22 @@ type StockContract struct {
 	Basic ContractBasic `yaml:",inline"` // 合约基本信息
 
 	StockFee StockFee `yaml:",inline"` // 股票费用
+
+	ListDate *Time `yaml:"list-date,omitempty"` // 上市日期，上市首日不设涨跌停，按标的配置
+
+	FeeSchedules []StockFeeSchedule `yaml:"fee-schedules,omitempty"` // 按生效日期切换的股票费用，例如印花税调整
+}
+
+// FeeAt tm时生效的股票费用，早于全部生效日期时使用StockFee
+func (s *StockContract) FeeAt(tm time.Time) StockFee {
+	fee, effective := s.StockFee, time.Time{}
+	for _, v := range s.FeeSchedules {
+		if !v.EffectiveDate.After(tm) && !v.EffectiveDate.Before(effective) {
+			fee, effective = v.StockFee, v.EffectiveDate.Time
+		}
+	}
+
+	return fee
 }
 
 func (s *StockContract) InitializeObject() error {
@@ -271,6 +318,18 @@ type GepModel struct {
 	NumConstants int       `yaml:"num-constants"` // 常量数量
 	LinkFunc     string    `yaml:"link-func"`     // 连接函数
 	Mode         ModelType `yaml:"mode"`          // 模式
+
+	Workers            int   `yaml:"workers,omitempty"`             // 并行评估的回放数量上限，默认为CPU核数
+	Seed               int64 `yaml:"seed,omitempty"`                // 随机种子，相同种子的训练结果可复现，0表示随机
+	CheckpointInterval int   `yaml:"checkpoint-interval,omitempty"` // 每隔多少次迭代保存一次训练断点，默认1，小于0表示不保存
+
+	Export FormulaExport `yaml:"export,omitempty"` // 训练结果导出为指标公式源码的配置
+}
+
+// FormulaExport 训练结果导出为指标公式源码的配置
+type FormulaExport struct {
+	Name    string `yaml:"name,omitempty"`    // 公式名称，同时作为生成的类型名和注册名，默认GepSignal
+	Package string `yaml:"package,omitempty"` // 生成代码的包名，默认indicator
 }
 
 type Model struct {
@@ -298,9 +357,34 @@ func NewModelConfig(configFile string) (*Model, error) {
 }
 
 type Performance struct {
-	RiskFreeRate    float64 `yaml:"risk-free-rate"`   // 无风险利率
-	PerformanceType string  `yaml:"performance-type"` // 评估指标类型
-	ExpectFitness   float64 `yaml:"expect-fitness"`   // 满意预期，达到即终止，可设定大一些，迫使算法持续搜索
+	RiskFreeRate    float64     `yaml:"risk-free-rate"`       // 无风险利率
+	PerformanceType string      `yaml:"performance-type"`     // 评估指标类型
+	ExpectFitness   float64     `yaml:"expect-fitness"`       // 满意预期，达到即终止，可设定大一些，迫使算法持续搜索
+	Benchmark       string      `yaml:"benchmark,omitempty"`  // 基准序列csv文件(date,close)，相对路径基于基础数据目录，用于alpha、beta等相对基准指标
+	Objectives      []Objective `yaml:"objectives,omitempty"` // 多目标训练的目标列表，配置后按非支配排序选择，并输出整个帕累托前沿
+}
+
+// Objective 多目标训练中的一个目标
+type Objective struct {
+	Type      string             `yaml:"type"`                // 评估指标类型
+	Direction ObjectiveDirection `yaml:"direction,omitempty"` // 优化方向，默认最大化
+}
+
+// IndicateTypes 评估需要计算的全部指标类型，评估指标类型在前
+func (p Performance) IndicateTypes() []string {
+	types := []string{p.PerformanceType}
+	for _, o := range p.Objectives {
+		types = append(types, o.Type)
+	}
+
+	return types
+}
+
+// WalkForward 滚动训练配置，按月切分训练段和验证段
+type WalkForward struct {
+	TrainMonths int  `yaml:"train-months"`       // 训练段长度(月)
+	TestMonths  int  `yaml:"test-months"`        // 样本外验证段长度(月)，同时也是每次滚动的步长
+	Anchored    bool `yaml:"anchored,omitempty"` // 锚定模式: 训练段起点固定为开始日期，训练段逐段增长
 }
 
 type TradeAcc struct {
@@ -315,11 +399,44 @@ type Account struct {
 	Count    int       `yaml:"count,omitempty"`    // 拉取
 	Slippage float64   `yaml:"slippage,omitempty"` // 滑点
 	Account  *TradeAcc `yaml:"account,omitempty"`  // 账户信息
+
+	VolumeRatio    float64 `yaml:"volume-ratio,omitempty"`     // 单根K线可成交量占该K线成交量的比例上限(limit-match), 默认1
+	PriceLimitRate float64 `yaml:"price-limit-rate,omitempty"` // 涨跌停幅度(limit-match), 默认按合约板块规则, 小于0表示不限制
+}
+
+// MarginStock 融资融券账户，在股票账户参数基础上增加信用交易参数
+type MarginStock struct {
+	Account `yaml:",inline"`
+
+	FinancingRate    float64            `yaml:"financing-rate,omitempty"`    // 融资年利率, 默认0.06
+	LendingRate      float64            `yaml:"lending-rate,omitempty"`      // 融券年费率, 默认0.08
+	DayCount         float64            `yaml:"day-count,omitempty"`         // 计息天数基准, 默认360
+	MarginRatio      float64            `yaml:"margin-ratio,omitempty"`      // 融资融券保证金比例, 默认1
+	Haircut          float64            `yaml:"haircut,omitempty"`           // 担保证券折算率, 默认0.7
+	Haircuts         map[string]float64 `yaml:"haircuts,omitempty"`          // 按标的设置的担保证券折算率
+	LiquidationRatio float64            `yaml:"liquidation-ratio,omitempty"` // 维持担保比例平仓线, 默认1.3
+	Borrowable       []string           `yaml:"borrowable,omitempty"`        // 可融券标的
+}
+
+// Risk 下单前风控参数，0表示不限制；开仓(增加敞口)的订单才检查持仓、敞口和当日亏损限制
+type Risk struct {
+	MaxOrderAmt      float64  `yaml:"max-order-amt,omitempty"`      // 单笔委托金额上限
+	MaxOrderQty      float64  `yaml:"max-order-qty,omitempty"`      // 单笔委托数量上限
+	MaxInstAmt       float64  `yaml:"max-inst-amt,omitempty"`       // 单个标的持仓市值上限(含本笔委托)
+	MaxAccountAmt    float64  `yaml:"max-account-amt,omitempty"`    // 单个账户持仓市值上限(含本笔委托)
+	MaxGrossExposure float64  `yaml:"max-gross-exposure,omitempty"` // 全部账户多空持仓市值之和上限(含本笔委托)
+	MaxOrdersPerMin  int      `yaml:"max-orders-per-min,omitempty"` // 每分钟委托笔数上限
+	MaxDailyLoss     float64  `yaml:"max-daily-loss,omitempty"`     // 当日亏损上限, 相对日初总权益的金额
+	PriceCollar      float64  `yaml:"price-collar,omitempty"`       // 委托价偏离最新价的比例上限, 例如0.05
+	Restricted       []string `yaml:"restricted,omitempty"`         // 禁止交易的标的
+	KillOnDailyLoss  bool     `yaml:"kill-on-daily-loss,omitempty"` // 触及当日亏损上限时熔断: 全部平仓并停止策略
 }
 
 type Framework struct {
-	Stock  Account `yaml:"stock,omitempty"`  // 股票
-	Future Account `yaml:"future,omitempty"` // 期货
+	Stock       Account     `yaml:"stock,omitempty"`        // 股票
+	Future      Account     `yaml:"future,omitempty"`       // 期货
+	MarginStock MarginStock `yaml:"margin-stock,omitempty"` // 融资融券
+	Risk        Risk        `yaml:"risk,omitempty"`         // 风控
 
 	GroupInstrument []string `yaml:"group,omitempty"`      // 股票组合
 	Instrument      []string `yaml:"instrument,omitempty"` // 合约标的
@@ -327,6 +444,7 @@ type Framework struct {
 
 	Realtime            bool          `yaml:"realtime,omitempty"`           // 是否实盘，对于回测无效
 	Frequency           Frequency     `yaml:"frequency,omitempty"`          // 1min, 5min, 15min, 30min, 60min, 1day, 1week, 1month
+	Secondary           []Frequency   `yaml:"secondary,omitempty"`          // 辅助频率，周期须大于 frequency，只能看到已完成的K线
 	BeginTime           string        `yaml:"begin-time,omitempty"`         // 启动时间
 	EndTime             string        `yaml:"end-time,omitempty"`           // 结束时间
 	BeginDate           string        `yaml:"begin,omitempty"`              // 启动日期
@@ -335,6 +453,7 @@ type Framework struct {
 	Begin               time.Time     `yaml:",inline"`                      // 启动
 	End                 time.Time     `yaml:",inline"`                      // 结束
 	DailyTriggerTime    time.Duration // 每日触发时间, 默认为14:50:00
+	Warmup              time.Time     `yaml:"-"` // 指标预热开始时间，早于 Begin 的行情只计算指标，不交易、不记录，为空时不预热
 }
 
 type System struct {
@@ -391,10 +510,11 @@ type Runtime struct {
 	Contract  *ContractProperty  // 合约
 	Indicator *IndicatorProperty // 指标
 
-	Tunnel      *Tunnel      `yaml:"tunnel,omitempty"`    // 隧道
-	Performance Performance  `yaml:"performance"`         // 评估指标
-	Framework   Framework    `yaml:"framework,omitempty"` // 运行参数
-	DataSource  []DataSource `yaml:"datasource"`          // 数据源
+	Tunnel      *Tunnel      `yaml:"tunnel,omitempty"`       // 隧道
+	Performance Performance  `yaml:"performance"`            // 评估指标
+	Framework   Framework    `yaml:"framework,omitempty"`    // 运行参数
+	DataSource  []DataSource `yaml:"datasource"`             // 数据源
+	WalkForward WalkForward  `yaml:"walk-forward,omitempty"` // 滚动训练配置
 }
 
 func (rt *Runtime) NewConfig(configFile string) error {
@@ -420,12 +540,20 @@ func checkFramework(framework *Framework) {
 		ErrorF("频率不能为空")
 	}
 
-	if framework.Frequency != Frequency1Min && framework.Frequency != Frequency5Min &&
-		framework.Frequency != Frequency15Min && framework.Frequency != Frequency30Min &&
-		framework.Frequency != Frequency1Day && framework.Frequency != Frequency120Min {
+	if !framework.Frequency.Valid() {
 		ErrorF("频率[%s]不支持", framework.Frequency)
 	}
 
+	for _, freq := range framework.Secondary {
+		if !freq.Valid() {
+			ErrorF("辅助频率[%s]不支持", freq)
+		}
+
+		if slices.Index(Frequencies, freq) <= slices.Index(Frequencies, framework.Frequency) {
+			ErrorF("辅助频率[%s]的周期须大于频率[%s]", freq, framework.Frequency)
+		}
+	}
+
 	if framework.BeginDate != "" {
 		begin, err := time.ParseInLocation(TimeFormatDate, framework.BeginDate, time.Local)
 		if err != nil {
@@ -475,6 +603,23 @@ func checkFramework(framework *Framework) {
 
 }
 
+func checkPerformance(performance *Performance) {
+	for i := range performance.Objectives {
+		o := &performance.Objectives[i]
+		if o.Type == "" {
+			ErrorF("第%d个训练目标未指定评估指标类型", i+1)
+		}
+
+		switch o.Direction {
+		case "":
+			o.Direction = ObjectiveMaximize
+		case ObjectiveMaximize, ObjectiveMinimize:
+		default:
+			ErrorF("训练目标[%s]的优化方向[%s]不支持, 只能为 %s 或 %s", o.Type, o.Direction, ObjectiveMaximize, ObjectiveMinimize)
+		}
+	}
+}
+
 func checkModel(model *Model) {
 	if model.Gep.NumGenesPerGenome < 2 {
 		ErrorF("每个基因组的基因数量不能小于2")
@@ -491,6 +636,30 @@ func checkModel(model *Model) {
 	if model.Gep.NumGenomes < 2 {
 		ErrorF("基因组数量不能小于2")
 	}
+
+	if model.Gep.Workers <= 0 {
+		model.Gep.Workers = runtime.NumCPU()
+	}
+
+	if model.Gep.CheckpointInterval == 0 {
+		model.Gep.CheckpointInterval = 1
+	}
+
+	if model.Gep.Export.Name == "" {
+		model.Gep.Export.Name = "GepSignal"
+	}
+
+	if model.Gep.Export.Package == "" {
+		model.Gep.Export.Package = "indicator"
+	}
+
+	if !token.IsIdentifier(model.Gep.Export.Name) || !token.IsExported(model.Gep.Export.Name) {
+		ErrorF("导出的公式名称[%s]必须是大写字母开头的Go标识符", model.Gep.Export.Name)
+	}
+
+	if !token.IsIdentifier(model.Gep.Export.Package) {
+		ErrorF("导出的公式包名[%s]不是合法的Go标识符", model.Gep.Export.Package)
+	}
 }
 
 func randomID() string {
@@ -645,6 +814,8 @@ func New(mode Mode, dir *Path) *Runtime {
 	// 日期和时间
 	checkFramework(&r.Framework)
 
+	checkPerformance(&r.Performance)
+
 	// 检查指标是否在指标配置中存在或者为常规高开低收、成交量、成交价指标
 	if invalidKey, ok := slice.ContainsFunc(
 		r.Framework.Indicator,
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
 // LogLevel 日志级别
 type LogLevel string
 
@@ -18,18 +24,55 @@ const (
 	ModelTypeGenomeSet ModelType = "GenomeSet"
 )
 
+// ObjectiveDirection 训练目标的优化方向
+type ObjectiveDirection string
+
+const (
+	ObjectiveMaximize ObjectiveDirection = "max" // 最大化
+	ObjectiveMinimize ObjectiveDirection = "min" // 最小化
+)
+
 // Frequency 频率类型
 type Frequency string
 
 const (
-	Frequency1Min  Frequency = "1min"
-	Frequency5Min  Frequency = "5min"
-	Frequency15Min Frequency = "15min"
-	Frequency30Min Frequency = "30min"
+	Frequency1Min   Frequency = "1min"
+	Frequency5Min   Frequency = "5min"
+	Frequency15Min  Frequency = "15min"
+	Frequency30Min  Frequency = "30min"
+	Frequency60Min  Frequency = "60min"
 	Frequency120Min Frequency = "120min"
-	Frequency1Day  Frequency = "1day"
+	Frequency1Day   Frequency = "1day"
+	Frequency1Week  Frequency = "1week"
+	Frequency1Month Frequency = "1month"
 )
 
+// Frequencies 支持的频率，按周期由小到大排列
+var Frequencies = []Frequency{
+	Frequency1Min, Frequency5Min, Frequency15Min, Frequency30Min, Frequency60Min, Frequency120Min,
+	Frequency1Day, Frequency1Week, Frequency1Month,
+}
+
+// Valid 是否为支持的频率
+func (f Frequency) Valid() bool {
+	return slices.Contains(Frequencies, f)
+}
+
+// Minutes 分钟频率的分钟数，日线及以上频率返回0
+func (f Frequency) Minutes() int {
+	if !strings.HasSuffix(string(f), "min") {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSuffix(string(f), "min"))
+	return n
+}
+
+// Field 该频率K线的列在指标中的列名，例如 1day.Close
+func (f Frequency) Field(name string) string {
+	return string(f) + "." + name
+}
+
 type HandlerType string
 
 const (
@@ -42,6 +85,7 @@ const (
 	HandlerTypeStream       HandlerType = "stream"     // 流式加载模式 (用于所有需要流式加载的模式, 例如: 回测, 实盘)
 	HandlerTypeNextMatch    HandlerType = "next-match" // 下一次匹配模式 (用于所有需要下一个周期进行处理的模式, 例如: 撮合，回测)
 	HandlerTypeCurrMatch    HandlerType = "curr-match" // 当前周期匹配模式 (用于所有需要当前周期进行处理的模式, 例如: 撮合，回测)
+	HandlerTypeLimitMatch   HandlerType = "limit-match" // 限价量能匹配模式 (下一周期撮合, 考虑限价、成交量上限与涨跌停)
 	HandlerTypeDailyMode    HandlerType = "daily"      // 每日模式 (专用与创建回测框架)
 	HandlerTypeGepMode      HandlerType = "gep"        // 训练和回测GEP模式
 	HandlerTypeFullXrxdMode HandlerType = "full-xrxd"  // 使用全复权模式(默认)
@@ -55,10 +99,14 @@ const (
 type Mode string // 模式
 
 const (
-	CalcMode  Mode = "calc"    // 训练模式
-	TrainMode Mode = "train"   // 训练模式
-	BTMode    Mode = "bt"      // 回测模式
-	RunMode   Mode = "runtime" // 运行模式
+	CalcMode        Mode = "calc"     // 训练模式
+	TrainMode       Mode = "train"    // 训练模式
+	BTMode          Mode = "bt"       // 回测模式
+	RunMode         Mode = "runtime"  // 运行模式
+	ReportMode      Mode = "report"   // 报告模式, 读取回测结果生成回测报告
+	WalkForwardMode Mode = "wf"       // 滚动训练模式, 分段训练并在下一段样本外验证
+	ExportMode      Mode = "export"   // 导出模式, 将训练得到的表达式导出为指标公式源码
+	ResampleMode    Mode = "resample" // 合成模式, 由小周期行情合成 framework->frequency 周期的行情
 )
 
 // MarketType 市场类型
@@ -78,6 +126,20 @@ const (
 	AccountTypeMarginStock = "margin-stock" // 融资融券账户
 )
 
+// Board 板块及标的类型，与合约配置(contract.yaml)中按板块配置的名称一致
+type Board string
+
+const (
+	BoardMain            = "main-board"       // 沪深主板
+	BoardStar            = "star"             // 科创板
+	BoardChiNext         = "chi-next"         // 创业板
+	BoardETF             = "etf"              // ETF
+	BoardCrossBorderETF  = "cross-border-etf" // 跨境ETF
+	BoardBondETF         = "bond-etf"         // 债券ETF
+	BoardConvertibleBond = "convertible-bond" // 可转债
+	BoardFuture          = "future"           // 期货
+)
+
 // StockSpecType 股票类型特化功能
 type StockSpecType string
 
@@ -89,10 +151,11 @@ const (
 type RecordType string
 
 const (
-	RecordTypeOrder    = "order"
-	RecordTypeTrade    = "trade"
-	RecordTypePosition = "position"
-	RecordTypeAsset    = "asset"
+	RecordTypeOrder     = "order"
+	RecordTypeTrade     = "trade"
+	RecordTypeRoundTrip = "round-trip"
+	RecordTypePosition  = "position"
+	RecordTypeAsset     = "asset"
 )
 
 // OrderDirection 委托方向
@@ -128,8 +191,40 @@ const (
 type OrderType string
 
 const (
-	OrderTypeLimit  = "L"
-	OrderTypeMarket = "M"
+	OrderTypeLimit        = "L"
+	OrderTypeMarket       = "M"
+	OrderTypeStop         = "S"  // 止损单: 价格触及触发价后以市价委托
+	OrderTypeStopLimit    = "SL" // 止损限价单: 价格触及触发价后以限价委托
+	OrderTypeTrailingStop = "TS" // 跟踪止损单: 触发价随最优价移动, 触发后以市价委托
+	OrderTypeTakeProfit   = "TP" // 止盈单: 价格触及止盈价后以止盈价限价委托
+)
+
+// Conditional 是否为条件单, 条件单触发前不参与撮合
+func (t OrderType) Conditional() bool {
+	switch t {
+	case OrderTypeStop, OrderTypeStopLimit, OrderTypeTrailingStop, OrderTypeTakeProfit:
+		return true
+	}
+
+	return false
+}
+
+// TriggerState 条件单触发状态
+type TriggerState string
+
+const (
+	TriggerNone     TriggerState = ""          // 普通订单
+	TriggerPending  TriggerState = "pending"   // 等待触发
+	TriggerFired    TriggerState = "triggered" // 已触发, 转为普通订单
+	TriggerCanceled TriggerState = "canceled"  // 未触发即撤销(包括止盈止损互撤)
+)
+
+// Liquidity 成交的流动性标志
+type Liquidity string
+
+const (
+	LiquidityMaker Liquidity = "maker" // 挂单成交: 限价单以委托价成交
+	LiquidityTaker Liquidity = "taker" // 吃单成交: 市价单或以优于委托价的价格成交
 )
 
 // OrderStatus 委托状态
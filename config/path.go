@@ -48,7 +48,14 @@ type Path struct {
 	KarvaExpressionFile string // 卡尔瓦表达式文件
 	AccountResultFile   string // 账户结果文件
 	OrderResultFile     string // 订单结果文件
+	TradeResultFile     string // 成交结果文件
+	RoundTripResultFile string // 完整交易结果文件
 	PositionResultFile  string // 持仓结果文件
+	ReportJsonFile      string // 回测报告文件(json)
+	ReportHtmlFile      string // 回测报告文件(html)
+	WalkForwardFile     string // 滚动训练分段汇总文件
+	CheckpointFile      string // 训练断点文件
+	FormulaSourceFile   string // 训练结果导出的指标公式源码文件
 }
 
 type WithOption func(*Path)
@@ -334,10 +341,38 @@ func NewPath(mode Mode, options ...WithOption) *Path {
 		p.OrderResultFile = path.Join(p.Output, "order")
 	}
 
+	if p.TradeResultFile == "" {
+		p.TradeResultFile = path.Join(p.Output, "trade")
+	}
+
+	if p.RoundTripResultFile == "" {
+		p.RoundTripResultFile = path.Join(p.Output, "round-trip")
+	}
+
 	if p.PositionResultFile == "" {
 		p.PositionResultFile = path.Join(p.Output, "position")
 	}
 
+	if p.ReportJsonFile == "" {
+		p.ReportJsonFile = path.Join(p.Output, "report.json")
+	}
+
+	if p.ReportHtmlFile == "" {
+		p.ReportHtmlFile = path.Join(p.Output, "report.html")
+	}
+
+	if p.WalkForwardFile == "" {
+		p.WalkForwardFile = path.Join(p.Output, "walkforward.yaml")
+	}
+
+	if p.CheckpointFile == "" {
+		p.CheckpointFile = path.Join(p.Output, "checkpoint.yaml")
+	}
+
+	if p.FormulaSourceFile == "" {
+		p.FormulaSourceFile = path.Join(p.Output, "formula.go")
+	}
+
 	return &p
 }
 
@@ -42,6 +42,9 @@ func parseCmd(vqt *Arg) error {
 		Example: `  vqt --mode=calc        计算指标
   vqt --mode=train       训练模型
   vqt --mode=bt          回测运行
+  vqt --mode=report      生成回测报告
+  vqt --mode=wf          滚动训练(分段训练 + 样本外验证)
+  vqt --mode=export      将训练得到的表达式导出为指标公式源码
   vqt --mode=runtime     实盘运行`,
 	}
 
@@ -52,7 +55,7 @@ func parseCmd(vqt *Arg) error {
 	cmd.Flags().StringVarP(&vqt.SID, "sid", "", "", "策略ID(可选)")
 
 	var mode string
-	cmd.Flags().StringVarP(&mode, "mode", "m", "", "运行模式(指标计算: calc, 训练: train, 回测: bt, 运行: runtime)")
+	cmd.Flags().StringVarP(&mode, "mode", "m", "", "运行模式(指标计算: calc, 训练: train, 回测: bt, 回测报告: report, 滚动训练: wf, 公式导出: export, 行情合成: resample, 运行: runtime)")
 
 	var pathStyle string
 	cmd.Flags().StringVarP(&pathStyle, "style", "s", "", "路径样式")
@@ -106,6 +109,14 @@ func parseCmd(vqt *Arg) error {
 		vqt.Mode = config.TrainMode
 	case "bt":
 		vqt.Mode = config.BTMode
+	case "report":
+		vqt.Mode = config.ReportMode
+	case "wf":
+		vqt.Mode = config.WalkForwardMode
+	case "export":
+		vqt.Mode = config.ExportMode
+	case "resample":
+		vqt.Mode = config.ResampleMode
 	case "rt":
 		vqt.Mode = config.RunMode
 	default:
@@ -22,5 +22,10 @@ type Account interface {
 	CashAccount() string // CashAccount 获取资金账户ID
 }
 
-
-
+// Credit 信用账户(融资融券)
+type Credit interface {
+	Account
+	Liability() float64        // 负债总额: 融资负债 + 融券市值 + 未还利息
+	MaintenanceRatio() float64 // 维持担保比例, 无负债时为+Inf
+	AvailableMargin() float64  // 保证金可用余额
+}
@@ -9,11 +9,18 @@ import (
 
 // & OrderOp 订单操作
 type OrderOp struct {
-	OrderTime      *time.Time            // 订单时间
-	OrderPrice     float64               // 订单价格
-	OrderDirection config.OrderDirection // 订单方向
-	OrderType      config.OrderType      // 订单类型
-	Account        Account               // 账户
+	OrderTime      *time.Time             // 订单时间
+	OrderPrice     float64                // 订单价格
+	OrderDirection config.OrderDirection  // 订单方向
+	OrderType      config.OrderType       // 订单类型
+	Transaction    config.TransactionType // 开平标志(期货有用)，默认开仓
+	Financing      bool                   // 融资买入(融资融券账户有用)
+	StopPrice      float64                // 条件单触发价(止损、止损限价、止盈)
+	TrailPercent   float64                // 跟踪止损: 触发价与最优价的比例距离, 0.05表示5%
+	TrailPrice     float64                // 跟踪止损: 触发价与最优价的固定价差
+	TakeProfit     float64                // 附带止盈价, 入场订单成交后挂出
+	StopLoss       float64                // 附带止损价, 入场订单成交后挂出
+	Account        Account                // 账户
 
 	// ! 以下两项CheckPos和CheckCash在生成订单时并没有使用！！
 	CheckPos       bool                  // 是否检查持仓
@@ -65,6 +72,82 @@ func WithOrderType(orderType config.OrderType) WithOrderOption {
 	}
 }
 
+// WithTransactionType 开平标志，期货订单使用: 开仓、平今、平昨
+func WithTransactionType(transaction config.TransactionType) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.Transaction = transaction
+	}
+}
+
+// WithFinancing 融资买入，融资融券账户使用
+func WithFinancing() WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderDirection = config.OrderBuy
+		opts.Transaction = config.OffsetOpen
+		opts.Financing = true
+	}
+}
+
+// WithShortSell 融券卖出，融资融券账户使用；买券还券为买入平仓
+func WithShortSell() WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderDirection = config.OrderSell
+		opts.Transaction = config.OffsetOpen
+	}
+}
+
+// WithStop 止损单，价格触及stopPrice后以市价委托
+// 买入止损在最高价不低于stopPrice时触发，卖出止损在最低价不高于stopPrice时触发
+func WithStop(stopPrice float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderType = config.OrderTypeStop
+		opts.StopPrice = stopPrice
+	}
+}
+
+// WithStopLimit 止损限价单，价格触及stopPrice后以limitPrice限价委托
+func WithStopLimit(stopPrice, limitPrice float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderType = config.OrderTypeStopLimit
+		opts.StopPrice = stopPrice
+		opts.OrderPrice = limitPrice
+	}
+}
+
+// WithTrailingStop 按比例跟踪止损，卖出时触发价为最高价 * (1 - percent)，买入时为最低价 * (1 + percent)
+func WithTrailingStop(percent float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderType = config.OrderTypeTrailingStop
+		opts.TrailPercent = percent
+	}
+}
+
+// WithTrailingStopPrice 按价差跟踪止损，卖出时触发价为最高价 - offset，买入时为最低价 + offset
+func WithTrailingStopPrice(offset float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderType = config.OrderTypeTrailingStop
+		opts.TrailPrice = offset
+	}
+}
+
+// WithTakeProfit 止盈单，价格触及price后以price限价委托
+// 卖出止盈在最高价不低于price时触发，买入止盈在最低价不高于price时触发
+func WithTakeProfit(price float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.OrderType = config.OrderTypeTakeProfit
+		opts.StopPrice = price
+	}
+}
+
+// WithBracket 入场订单附带止盈止损，成交后按成交数量挂出反向的止盈单和止损单，一方成交后撤销另一方
+// 价格为0表示不设置该项
+func WithBracket(takeProfit, stopLoss float64) WithOrderOption {
+	return func(opts *OrderOp) {
+		opts.TakeProfit = takeProfit
+		opts.StopLoss = stopLoss
+	}
+}
+
 func WithAccount(account Account) WithOrderOption {
 	return func(opts *OrderOp) {
 		opts.Account = account
@@ -1,6 +1,8 @@
 package contract
 
 import (
+	"time"
+
 	"github.com/wonderstone/QuantKit/config"
 )
 
@@ -14,8 +16,8 @@ type Contract interface {
 	GetInstID() string
 	// 获取账户类型
 	GetAccountType() config.AccountType
-	// 计算全部手续费，方向为买卖方向
-	CalcComm(qty float64, price float64, direction config.OrderDirection) float64
+	// 计算全部手续费，方向为买卖方向，按tm(委托时间)时生效的费用计算
+	CalcComm(tm time.Time, qty float64, price float64, direction config.OrderDirection) float64
 	// 计算市值，方向为多空方向
 	CalcMarketValue(qty float64, price float64, direction config.PositionDirection) float64
 	// 计算保证金，方向为多空方向
@@ -24,4 +26,23 @@ type Contract interface {
 	CalcSlipPrice(price float64, slippage float64, direction config.OrderDirection) float64
 	// 计算最大可交易数量
 	CalcMaxQty(qty float64) float64
+	// 买入(开仓)后第几个交易日可以卖出(平仓)，0表示当日可以卖出
+	GetTPlus() int
+}
+
+// Rule 交易所规则，按板块和标的类型区分
+type Rule interface {
+	// 板块
+	GetBoard() config.Board
+	// 按昨收价计算涨跌停价，st表示当日是否为ST，不设涨跌停时返回false
+	CalcPriceLimit(tm time.Time, prevClose float64, st bool) (lower, upper float64, ok bool)
+	// 检查委托数量，holding为持仓数量，平仓(卖出)全部持仓时可以不满足最小下单量和递增单位
+	CheckQty(qty, holding float64, offset config.TransactionType) error
+}
+
+// Future 期货合约，手续费需要区分开平标志
+type Future interface {
+	Contract
+	// 按开平标志计算手续费: 开仓、平今、平昨，按tm(委托时间)时生效的费用计算
+	CalcCommOffset(tm time.Time, qty float64, price float64, offset config.TransactionType) float64
 }
@@ -2,6 +2,7 @@ package formula
 
 import (
 	"fmt"
+	"math"
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
 	"reflect"
@@ -14,6 +15,23 @@ type Formula interface {
 	DoReset()
 }
 
+// FloatFormula 直接返回数值的公式，缺失值返回NaN
+// 指标计算器优先使用 DoCalculateFloat，结果直接写入记录的数值列，不经过字符串转换
+// 只实现 Formula 的公式仍按字符串结果计算
+type FloatFormula interface {
+	Formula
+	DoCalculateFloat(tm time.Time, row dataframe.RecordFunc) float64
+}
+
+// FormatFloat 将数值结果转换为 DoCalculate 的字符串结果，NaN 转换为空
+func FormatFloat(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+
+	return fmt.Sprintf("%.4f", v)
+}
+
 var formulas = make(map[string]reflect.Type)
 
 // RegisterNewFormula 注册新的公式
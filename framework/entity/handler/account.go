@@ -39,6 +39,16 @@ type Account interface {
 	CancelOrder(tm time.Time, id int64, accountId string)
 	// GetCurrTime 获取当前时间
 	GetCurrTime() *time.Time
+	// CheckRisk 下单前风控检查，账户插入订单时调用
+	CheckRisk(order account.Order) error
+	// Kill 熔断: 撤销全部委托、按市价平仓并停止策略
+	Kill(reason string)
+	// Halted 是否已熔断
+	Halted() bool
+	// NotifyOrder 账户记录订单回报，由框架统一回调策略
+	NotifyOrder(event OrderEvent)
+	// AdjustRoundTrip 除权除息调整完整交易日志
+	AdjustRoundTrip(accountID, instID string, xrxd *config.Xrxd)
 }
 
 
@@ -82,7 +92,8 @@ type Account2 interface {
 	DoResume(
 		assets []recorder.AssetRecord, 
 		positions []recorder.PositionRecord, 
-		orders []recorder.OrderRecord)
+		orders []recorder.OrderRecord,
+		trades []recorder.TradeRecord)
 
 	// CalcPositionPnL 计算持仓盈亏
 	CalcPositionPnL(tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord])
@@ -139,4 +150,7 @@ type Accounts interface {
 
 	// GenOrderId 生成订单ID
 	GenOrderId() int64
+
+	// DispatchOrderEvents 将记录的订单回报按顺序回调给实现了OrderListener的策略
+	DispatchOrderEvents(strategy Strategy)
 }
@@ -5,6 +5,8 @@ import (
 
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/modelgene/gep/model"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
 )
 
 type Framework interface {
@@ -25,3 +27,10 @@ type Framework interface {
 	// Evaluate 模型评估函数
 	Evaluate(values model.InputValues) model.OutputValues
 }
+
+// MultiFrequency 多频率行情，策略可通过类型断言获取
+// 配置辅助频率(framework->secondary)后，指标中同时附带辅助频率K线的列，列名为 频率.字段，例如 1day.Close
+type MultiFrequency interface {
+	// Bars 辅助频率各合约最近一根已完成的K线，key: instID；未配置该频率时返回false
+	Bars(freq config.Frequency) (orderedmap.OrderedMap[string, dataframe.StreamingRecord], bool)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
 )
 
@@ -15,6 +16,11 @@ type MatcherOp struct {
 	config         config.Framework // 配置
 }
 
+// Config 获取撮合器配置
+func (op *MatcherOp) Config() config.Framework {
+	return op.config
+}
+
 type WithMatcherOption func(*MatcherOp)
 
 func WithStockSlippage(slippage float64) WithMatcherOption {
@@ -46,3 +52,8 @@ type Matcher interface {
 	MatchOrder(order Order, indicate dataframe.StreamingRecord, matchTime time.Time)
 }
 
+// QuoteObserver 需要观察全部行情的撮合器可选实现该接口(例如: 需要昨收价计算涨跌停)
+// 每根K线撮合完成后调用
+type QuoteObserver interface {
+	ObserveQuote(tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord])
+}
@@ -3,7 +3,9 @@ package handler
 import (
 	"time"
 
+	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
 	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
@@ -16,13 +18,16 @@ type Order interface {
 	// DoTradeUpdate 交易更新
 	DoTradeUpdate(tradePrice float64, tradeQty float64, tradeTime time.Time, recorder recorder.Handler)
 	// DoResume 恢复订单：出现异常情况时，恢复订单。严苛回测场景(立即市价成交)不需要
-	DoResume(record *recorder.OrderRecord)
+	// 有成交记录时逐笔重放成交，否则按订单记录的成交均价和成交数量恢复
+	DoResume(record *recorder.OrderRecord, trades ...recorder.TradeRecord)
 	// DoSettle 闭市结算更新。严苛回测场景(立即市价成交)不需要
 	DoSettle(time time.Time, recorder recorder.Handler)
 	// DoCancelUpdate 撤单更新。严苛回测场景(立即市价成交)不需要
 	DoCancelUpdate(cancelTime time.Time)
 	// DoReject 更新订单，拒单
 	DoReject(rejectTime time.Time, err error)
+	// RejectReason 拒单原因，未拒单时为nil
+	RejectReason() error
 	// DoRTInsert 实盘插入订单
 	DoRTInsert() error
 	// DoRTCancel 实盘取消订单
@@ -30,3 +35,23 @@ type Order interface {
 	// ModifyOrder 修改订单
 	ModifyOrder(tag string, value interface{})
 }
+
+// ConditionalOrder 条件单接口，普通订单的TriggerState为TriggerNone
+// 条件单触发前由账户保存，不冻结资金也不参与撮合；触发后转为市价单或限价单，按普通订单插入账户
+type ConditionalOrder interface {
+	Order
+	// TriggerState 触发状态
+	TriggerState() config.TriggerState
+	// StopPrice 当前触发价，跟踪止损随行情移动
+	StopPrice() float64
+	// TriggerPrice 触发时的参考成交价，跳空时为开盘价
+	TriggerPrice() float64
+	// TriggerTime 触发时间
+	TriggerTime() time.Time
+	// Bracket 入场订单附带的止盈价和止损价
+	Bracket() (takeProfit, stopLoss float64)
+	// DoTrigger 使用K线的最高价、最低价检查是否触发
+	DoTrigger(indicate dataframe.StreamingRecord, triggerTime time.Time) bool
+	// DoTriggerCancel 撤销未触发的条件单
+	DoTriggerCancel(cancelTime time.Time)
+}
@@ -35,6 +35,30 @@ type Strategy interface {
 	OnEnd(framework Framework)
 }
 
+// OrderEvent 订单回报
+type OrderEvent struct {
+	Order      account.Order
+	Status     config.OrderStatus // 回报时的订单状态
+	TradeID    int64              // 成交id，仅成交回报
+	Price      float64            // 本次成交价格，仅成交回报
+	Qty        float64            // 本次成交数量，仅成交回报
+	Commission float64            // 本次成交手续费，仅成交回报
+	Reason     error              // 拒单原因，仅拒单回报
+}
+
+// OrderListener 订单回报，策略可选实现
+// 框架在撮合、下单、撤单和结算之后按发生顺序回调，回调中可以继续下单或撤单
+type OrderListener interface {
+	// OnOrder 订单状态变化: 新建、撤单、过期、完成、部分成交部分撤单
+	OnOrder(framework Framework, event OrderEvent)
+
+	// OnTrade 每笔成交
+	OnTrade(framework Framework, event OrderEvent)
+
+	// OnReject 拒单，包括下单前检查和撮合时的拒单
+	OnReject(framework Framework, event OrderEvent)
+}
+
 // StrategyCreator 创建策略的函数
 type StrategyCreator func() Strategy
 
@@ -62,3 +86,9 @@ func (e EmptyStrategy) OnTick(
 func (e EmptyStrategy) OnDailyClose(framework Framework, acc map[string]account.Account) {}
 
 func (e EmptyStrategy) OnEnd(framework Framework) {}
+
+func (e EmptyStrategy) OnOrder(framework Framework, event OrderEvent) {}
+
+func (e EmptyStrategy) OnTrade(framework Framework, event OrderEvent) {}
+
+func (e EmptyStrategy) OnReject(framework Framework, event OrderEvent) {}
@@ -207,6 +207,8 @@ func Run(options ...WithOption) {
 	}
 
 	modePrefix := ""
+	// 报告模式读取回测结果，路径和配置与回测模式一致; 滚动训练与训练模式一致
+	pathMode := op.Mode
 
 	switch op.Mode {
 	case config.TrainMode, config.BTMode, config.RunMode:
@@ -215,19 +217,33 @@ func Run(options ...WithOption) {
 		}
 		fallthrough
 	case config.CalcMode:
+	case config.ResampleMode:
+		// 合成模式只读取行情，路径和配置与指标计算模式一致
+		pathMode = config.CalcMode
+	case config.ReportMode:
+		pathMode = config.BTMode
+	case config.ExportMode:
+		// 导出模式读取训练结果，路径和配置与训练模式一致
+		pathMode = config.TrainMode
+	case config.WalkForwardMode:
+		// 滚动训练使用训练模式的路径和配置
+		if op.StrategyCreator == nil {
+			panic("未设置策略创建器或者没有选择策略演示模式[T0, DMT, ...]")
+		}
+		pathMode = config.TrainMode
 	default:
-		panic("未设置正确运行模式(mode), 可选择的模式为[calc, bt, train, runtime]")
+		panic("未设置正确运行模式(mode), 可选择的模式为[calc, bt, train, runtime, report, wf, export, resample]")
 	}
 
 	if op.ModePrefix {
-		modePrefix = string(op.Mode)
+		modePrefix = string(pathMode)
 	}
 
 	var dir *config.Path = nil
 	switch op.PathStyle {
 	case PathStyleNone:
 		dir = config.NewDefaultPath(
-			op.Mode,
+			pathMode,
 			config.WithRoot(op.WorkRoot),
 			config.WithStrategyID(op.StrategyPrefix, op.StrategyID),
 			config.WithRunDir(filepath.Join(op.DataPrefix, modePrefix, op.RunID)),
@@ -243,10 +259,10 @@ func Run(options ...WithOption) {
 		}
 
 		dir = config.NewDefaultPath(
-			op.Mode,
+			pathMode,
 			config.WithRoot(op.WorkRoot),
 			config.WithStrategyID("strategy", op.StrategyID),
-			config.WithRunDir(filepath.Join(".vqt", string(op.Mode), op.RunID)),
+			config.WithRunDir(filepath.Join(".vqt", string(pathMode), op.RunID)),
 			config.WithDownloadDir(filepath.Join(".vqt", "download")),
 			config.WithBaseDir("base"),
 			config.WithIndicatorDir(filepath.Join(".vqt", "calc")),
@@ -265,7 +281,8 @@ func Run(options ...WithOption) {
 		// op.Mode == config.RunMode,
 	)
 
-	conf := config.New(op.Mode, dir)
+	conf := config.New(pathMode, dir)
+	conf.Mode = op.Mode
 if op.RunID != "" {
 		conf.ID = op.RunID
 	}
@@ -34,6 +34,15 @@ type DefaultHandler struct {
 
 	recorder map[config.RecordType]recorder.Handler // 记录器
 
+	risk *riskControl // 下单前风控
+
+	events   []handler.OrderEvent         // 待回调的订单回报
+	notified map[int64]config.OrderStatus // 已回报的订单状态，结算后清空
+	tradeID  int64                        // 最新成交id
+	resuming bool                         // 正在恢复订单
+
+	journal *journal // 完整交易日志
+
 	wg sync.WaitGroup
 }
 
@@ -104,6 +113,48 @@ func (d *DefaultHandler) getOrderMaxID() int64 {
 	return maxID
 }
 
+// getTradeMaxID 全部成交记录中最大的成交id，没有成交记录器时返回0
+func (d *DefaultHandler) getTradeMaxID() int64 {
+	r := d.recorder[config.RecordTypeTrade]
+	if r == nil {
+		return 0
+	}
+
+	var maxID int64
+	recs := r.QueryRecord(
+		recorder.WithSQL("SELECT * FROM trade_records ORDER BY trade_id DESC LIMIT 1"),
+	)
+
+	for _, rec := range recs {
+		maxID = rec.(recorder.TradeRecord).TradeId
+	}
+
+	return maxID
+}
+
+// getLastTillNowTradeRecords 上一交易日之后的成交记录，没有成交记录器时返回空
+func (d *DefaultHandler) getLastTillNowTradeRecords(date string) []recorder.TradeRecord {
+	r := d.recorder[config.RecordTypeTrade]
+	if r == nil {
+		return nil
+	}
+
+	var records []recorder.TradeRecord
+	recs := r.QueryRecord(
+		recorder.WithSQL(
+			fmt.Sprintf(
+				"SELECT * FROM trade_records WHERE trade_date > '%s' ORDER BY trade_id", date,
+			),
+		),
+	)
+
+	for _, rec := range recs {
+		records = append(records, rec.(recorder.TradeRecord))
+	}
+
+	return records
+}
+
 func (d *DefaultHandler) getLastTillNowOrderRecords(date string) []recorder.OrderRecord {
 	var records []recorder.OrderRecord
 	recs := d.recorder[config.RecordTypeOrder].QueryRecord(
@@ -128,9 +179,16 @@ func (d *DefaultHandler) DoResume() {
 	orderRec := d.getLastTillNowOrderRecords(date)
 	maxID := d.getOrderMaxID()
 
+	tradeRec := d.getLastTillNowTradeRecords(date)
+
+	d.resuming = true
 	for _, acc := range d.accounts {
-		acc.DoResume(accRec, posRec, orderRec)
+		acc.DoResume(accRec, posRec, orderRec, tradeRec)
 	}
+	d.resuming = false
+
+	// 成交id在整个成交表中唯一，不能只从上一交易日之后的成交中恢复
+	d.tradeID = d.getTradeMaxID()
 
 	d.ai = idgen.New(maxID+1, 1)
 }
@@ -163,11 +221,21 @@ func (d *DefaultHandler) CalcPositionPnL(
 	for _, acc := range d.accounts {
 		acc.CalcPositionPnL(tm, indicators)
 	}
+	d.journal.observe(indicators)
+
+	d.risk.watch()
 }
 
 func (d *DefaultHandler) DoMatch(tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord]) {
+	d.risk.observe(tm, indicators)
+
+	matcher := d.framework.Matcher()
 	for _, acc := range d.accounts {
-		acc.DoMatch(tm, indicators, d.framework.Matcher())
+		acc.DoMatch(tm, indicators, matcher)
+	}
+
+	if observer, ok := matcher.(handler.QuoteObserver); ok {
+		observer.ObserveQuote(tm, indicators)
 	}
 }
 
@@ -180,7 +248,32 @@ func (d *DefaultHandler) Contract() handler.Contract {
 }
 
 func NewDefaultHandler() *DefaultHandler {
-	return &DefaultHandler{}
+	d := &DefaultHandler{notified: make(map[int64]config.OrderStatus)}
+	d.risk = newRiskControl(d, config.Risk{})
+
+	return d
+}
+
+// CheckRisk 下单前风控检查
+func (d *DefaultHandler) CheckRisk(o account.Order) error {
+	return d.risk.check(o)
+}
+
+// Kill 熔断: 撤销全部委托、按市价平仓并停止策略
+func (d *DefaultHandler) Kill(reason string) {
+	if d.risk.halted {
+		return
+	}
+
+	config.WarnF("策略熔断: %s", reason)
+	d.risk.halted = true
+	d.risk.reason = reason
+	d.risk.flatten(*d.GetCurrTime())
+}
+
+// Halted 是否已熔断
+func (d *DefaultHandler) Halted() bool {
+	return d.risk.halted
 }
 
 func (d *DefaultHandler) Init(framework any, recorder map[config.RecordType]recorder.Handler) error {
@@ -200,6 +293,11 @@ func (d *DefaultHandler) Init(framework any, recorder map[config.RecordType]reco
 	d.market2accounts = make(map[config.MarketType][]account.Account)
 
 	d.recorder = recorder
+	d.risk = newRiskControl(d, d.framework.Config().Framework.Risk)
+	d.events = nil
+	d.notified = make(map[int64]config.OrderStatus)
+	d.tradeID = 0
+	d.journal = newJournal(recorder[config.RecordTypeRoundTrip])
 	// 增加账户记录
 	if assetRecorder := recorder[config.RecordTypeAsset]; assetRecorder != nil {
 		d.wg.Add(1)
@@ -224,6 +322,30 @@ func (d *DefaultHandler) Init(framework any, recorder map[config.RecordType]reco
 		}()
 	}
 
+	// 增加成交记录
+	if tradeRecorder := recorder[config.RecordTypeTrade]; tradeRecorder != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			err := tradeRecorder.RecordChan()
+			if err != nil {
+				config.InfoF("成交记录写入结束")
+			}
+		}()
+	}
+
+	// 增加完整交易记录
+	if roundTripRecorder := recorder[config.RecordTypeRoundTrip]; roundTripRecorder != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			err := roundTripRecorder.RecordChan()
+			if err != nil {
+				config.InfoF("完整交易记录写入结束")
+			}
+		}()
+	}
+
 	// 增加持仓记录
 	if positionRecorder := recorder[config.RecordTypePosition]; positionRecorder != nil {
 		d.wg.Add(1)
@@ -262,6 +384,18 @@ func (d *DefaultHandler) Init(framework any, recorder map[config.RecordType]reco
 		d.market2accounts[config.MarketTypeFuture] = append(d.market2accounts[config.MarketTypeFuture], acc)
 	}
 
+	if marginAccConfig := d.framework.Config().Framework.MarginStock; marginAccConfig.Cash > 0 {
+		acc := setting.NewAccount(config.AccountTypeMarginStock)
+		err := acc.Init(d, marginAccConfig)
+		if err != nil {
+			config.ErrorF("融资融券账户初始化失败: %v", err)
+			return err
+		}
+
+		d.accounts[config.AccountTypeMarginStock] = acc
+		d.market2accounts[config.MarketTypeStock] = append(d.market2accounts[config.MarketTypeStock], acc)
+	}
+
 	if d.Config().Mode == config.RunMode {
 		d.DoResume()
 	} else {
@@ -306,7 +440,10 @@ func (d *DefaultHandler) DoSettle(tm time.Time, indicators *orderedmap.OrderedMa
 	for _, acc := range d.accounts {
 		acc.DoSettle(tm, indicators, d.recorder)
 	}
+	d.journal.settle()
 
+	// 结算后当日订单全部结束
+	d.notified = make(map[int64]config.OrderStatus)
 }
 
 func (d *DefaultHandler) Release() {
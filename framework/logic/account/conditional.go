@@ -0,0 +1,248 @@
+package account
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + conditionalBook 账户的条件单簿，股票、期货、融资融券账户共用:
+// + 止损、止损限价、跟踪止损、止盈单触发前保存在条件单簿中，不冻结资金，跨日有效直到触发或撤销
+// + 撮合每个标的前先用K线最高价、最低价检查触发，触发的订单按普通订单插入账户，并在同一根K线撮合
+// + 附带止盈止损(WithBracket)的入场订单成交后，按成交数量挂出反向的止损单和止盈单(平仓)
+// + 止损单和止盈单互为OCO: 一方成交后撤销另一方；一方已触发尚未结束时另一方暂停检查
+// + 同一根K线同时满足止损和止盈时先检查止损
+
+// ocoPair 互相撤销的止损单和止盈单
+type ocoPair struct {
+	legs [2]handler.ConditionalOrder
+}
+
+type conditionalBook struct {
+	acc      account.Account
+	handle   handler.Account                                                    // 账户管理器，用于订单回报
+	pending  map[string]*orderedmap.OrderedMap[int64, handler.ConditionalOrder] // 标的 -> 未触发条件单
+	options  map[int64][]account.WithOrderOption                                // 条件单插入时的参数，触发后插入账户时使用
+	entries  *orderedmap.OrderedMap[int64, handler.ConditionalOrder]            // 附带止盈止损的入场订单
+	pairs    map[int64]*ocoPair                                                 // 止损单、止盈单 -> 所属OCO
+	canceled []handler.ConditionalOrder                                         // 未触发即撤销、等待结算记录的条件单
+}
+
+func newConditionalBook(acc account.Account, handle handler.Account) *conditionalBook {
+	return &conditionalBook{
+		acc:     acc,
+		handle:  handle,
+		pending: make(map[string]*orderedmap.OrderedMap[int64, handler.ConditionalOrder]),
+		options: make(map[int64][]account.WithOrderOption),
+		entries: orderedmap.New[int64, handler.ConditionalOrder](),
+		pairs:   make(map[int64]*ocoPair),
+	}
+}
+
+// hold 账户插入订单时调用，未触发的条件单保存到条件单簿并返回true；附带止盈止损的订单登记为入场订单
+func (b *conditionalBook) hold(o account.Order, options []account.WithOrderOption) bool {
+	c, ok := o.(handler.ConditionalOrder)
+	if !ok {
+		return false
+	}
+
+	if c.TriggerState() != config.TriggerPending {
+		if takeProfit, stopLoss := c.Bracket(); takeProfit > 0 || stopLoss > 0 {
+			b.entries.Set(o.ID(), c)
+		}
+
+		return false
+	}
+
+	orders, ok := b.pending[o.InstID()]
+	if !ok {
+		orders = orderedmap.New[int64, handler.ConditionalOrder]()
+		b.pending[o.InstID()] = orders
+	}
+
+	orders.Set(o.ID(), c)
+	b.options[o.ID()] = options
+
+	return true
+}
+
+// live 订单已触发且仍在撮合中
+func live(o handler.ConditionalOrder) bool {
+	if o.TriggerState() != config.TriggerFired {
+		return false
+	}
+
+	status := o.OrderStatus()
+	return status == config.OrderStatusNew || status == config.OrderStatusPartDone
+}
+
+// sibling OCO中的另一方
+func (b *conditionalBook) sibling(id int64) handler.ConditionalOrder {
+	pair, ok := b.pairs[id]
+	if !ok {
+		return nil
+	}
+
+	if pair.legs[0].ID() == id {
+		return pair.legs[1]
+	}
+
+	return pair.legs[0]
+}
+
+// trigger 撮合标的前检查条件单，触发的订单插入账户
+func (b *conditionalBook) trigger(tm time.Time, instID string, indicate dataframe.StreamingRecord) {
+	// 停牌补齐的K线不触发条件单
+	orders, ok := b.pending[instID]
+	if !ok || indicate.Suspended() {
+		return
+	}
+
+	var fired []handler.ConditionalOrder
+	for pOrder := orders.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+		o := pOrder.Value
+		if s := b.sibling(o.ID()); s != nil && live(s) {
+			continue
+		}
+
+		if o.DoTrigger(indicate, tm) {
+			fired = append(fired, o)
+		}
+	}
+
+	for _, o := range fired {
+		orders.Delete(o.ID())
+		options := b.options[o.ID()]
+		delete(b.options, o.ID())
+
+		if err := b.acc.InsertOrder(o, options...); err != nil {
+			config.WarnF("条件单 %d 触发后下单失败: %v", o.ID(), err)
+		}
+	}
+}
+
+// afterMatch 撮合后处理: 入场订单全部成交后挂出止盈止损，OCO一方成交后撤销另一方
+func (b *conditionalBook) afterMatch(tm time.Time) {
+	var done []handler.ConditionalOrder
+	for pOrder := b.entries.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+		if pOrder.Value.OrderStatus() == config.OrderStatusDone {
+			done = append(done, pOrder.Value)
+		}
+	}
+
+	for _, o := range done {
+		b.entries.Delete(o.ID())
+		b.bracket(o, o.TradeQty())
+	}
+
+	for id, pair := range b.pairs {
+		if pair.legs[0].ID() != id {
+			continue
+		}
+
+		for i, leg := range pair.legs {
+			if leg.TradeQty() > 0 {
+				b.cancel(tm, pair.legs[1-i].ID())
+				delete(b.pairs, pair.legs[0].ID())
+				delete(b.pairs, pair.legs[1].ID())
+				break
+			}
+		}
+	}
+}
+
+// bracket 按入场订单的成交数量挂出反向的止损单和止盈单
+func (b *conditionalBook) bracket(entry handler.ConditionalOrder, qty float64) {
+	takeProfit, stopLoss := entry.Bracket()
+
+	direction := config.OrderSell
+	if entry.OrderDirection() == config.OrderSell {
+		direction = config.OrderBuy
+	}
+
+	var legs []handler.ConditionalOrder
+	for _, option := range []account.WithOrderOption{account.WithStop(stopLoss), account.WithTakeProfit(takeProfit)} {
+		op := account.NewOrderOp(option)
+		if op.StopPrice <= 0 {
+			continue
+		}
+
+		o, err := b.acc.NewOrder(
+			entry.InstID(), qty, option,
+			account.WithOrderDirection(direction), account.WithTransactionType(config.OffsetClose),
+		)
+		if err != nil {
+			config.WarnF("订单 %d 挂出止盈止损失败: %v", entry.ID(), err)
+			continue
+		}
+
+		if err := b.acc.InsertOrder(o); err != nil {
+			config.WarnF("订单 %d 挂出止盈止损失败: %v", entry.ID(), err)
+			continue
+		}
+
+		legs = append(legs, o.(handler.ConditionalOrder))
+	}
+
+	if len(legs) == 2 {
+		pair := &ocoPair{legs: [2]handler.ConditionalOrder{legs[0], legs[1]}}
+		b.pairs[legs[0].ID()] = pair
+		b.pairs[legs[1].ID()] = pair
+	}
+}
+
+// cancel 撤销未触发的条件单，订单不在条件单簿中时返回false
+func (b *conditionalBook) cancel(tm time.Time, id int64) bool {
+	for _, orders := range b.pending {
+		if o, ok := orders.Get(id); ok {
+			o.DoTriggerCancel(tm)
+			notifyStatus(b.handle, o)
+			orders.Delete(id)
+			delete(b.options, id)
+			b.canceled = append(b.canceled, o)
+
+			if s := b.sibling(id); s != nil {
+				delete(b.pairs, s.ID())
+				delete(b.pairs, id)
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// orders 标的未触发的条件单
+func (b *conditionalBook) orders(instID string) []account.Order {
+	var orders []account.Order
+	if v, ok := b.pending[instID]; ok {
+		for pOrder := v.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+			orders = append(orders, pOrder.Value)
+		}
+	}
+
+	return orders
+}
+
+// settle 在账户订单结算后调用: 部分成交后过期的入场订单按已成交数量挂出止盈止损，记录已撤销的条件单
+// 未触发的条件单继续保留
+func (b *conditionalBook) settle(tm time.Time, r recorder.Handler) {
+	entries := b.entries
+	b.entries = orderedmap.New[int64, handler.ConditionalOrder]()
+	for pOrder := entries.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+		if pOrder.Value.TradeQty() > 0 {
+			b.bracket(pOrder.Value, pOrder.Value.TradeQty())
+		}
+	}
+
+	for _, o := range b.canceled {
+		o.DoSettle(tm, r)
+	}
+	b.canceled = nil
+}
@@ -0,0 +1,134 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/matcher"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/idgen"
+)
+
+// Base 测试不使用除权除息数据
+func (f *testFramework) Base() handler.Basic {
+	return nil
+}
+
+func newTestStock(t *testing.T) (*StockSimple, *testFramework) {
+	prop, err := config.NewContractPropertyConfig("../position/contract.yaml")
+	require.NoError(t, err)
+
+	handle, err := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(prop))
+	require.NoError(t, err)
+
+	f := &testFramework{tm: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), contract: handle}
+	d := NewDefaultHandler()
+	d.framework = f
+	d.ai = idgen.New(1, 1)
+	d.Resource = setting.NewResource(setting.WithRuntimeConfig(&config.Runtime{}), setting.WithAccountHandler(d))
+
+	s := &StockSimple{}
+	require.NoError(t, s.Init(d, config.Account{Cash: 100000}))
+
+	return s, f
+}
+
+func bar(open, high, low, close float64) orderedmap.OrderedMap[string, dataframe.StreamingRecord] {
+	quotes := orderedmap.New[string, dataframe.StreamingRecord]()
+	record := dataframe.NewEmptyStreamingRecord(map[string]int{"Open": 0, "High": 1, "Low": 2, "Close": 3})
+	record.Set("Open", open)
+	record.Set("High", high)
+	record.Set("Low", low)
+	record.Set("Close", close)
+	quotes.Set(testInstID, record)
+
+	return *quotes
+}
+
+func place(t *testing.T, s *StockSimple, qty float64, options ...account.WithOrderOption) handler.ConditionalOrder {
+	o, err := s.NewOrder(testInstID, qty, options...)
+	require.NoError(t, err)
+	require.NoError(t, s.InsertOrder(o))
+
+	return o.(handler.ConditionalOrder)
+}
+
+// 测试附带止盈止损的入场订单: 成交后挂出止损单和止盈单，跨日有效，止损触发后撤销止盈
+func TestBracketOrder(t *testing.T) {
+	s, f := newTestStock(t)
+	m := new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+
+	entry := place(t, s, 1000, account.WithOrderPrice(10), account.WithBracket(12, 9))
+	require.Equal(t, config.TriggerNone, entry.TriggerState())
+
+	s.DoMatch(f.tm, bar(10, 10.2, 9.8, 10), m)
+	require.Equal(t, config.OrderStatus(config.OrderStatusDone), entry.OrderStatus())
+
+	legs := s.cond.orders(testInstID)
+	require.Len(t, legs, 2)
+	stop, profit := legs[0].(handler.ConditionalOrder), legs[1].(handler.ConditionalOrder)
+	require.Equal(t, config.OrderType(config.OrderTypeStop), stop.OrderType())
+	require.Equal(t, config.OrderDirection(config.OrderSell), stop.OrderDirection())
+	require.Equal(t, 1000.0, profit.OrderQty())
+
+	// 未触发的条件单跨日保留，且不冻结资金
+	s.DoSettle(f.tm, nil, nil)
+	require.Len(t, s.cond.orders(testInstID), 2)
+	require.Equal(t, 0.0, s.Asset.Frozen)
+
+	// 次日跳空低开，止损以开盘价成交，止盈撤销
+	f.tm = f.tm.AddDate(0, 0, 1)
+	s.DoMatch(f.tm, bar(8.8, 9.1, 8.5, 9), m)
+	require.Equal(t, config.TriggerFired, stop.TriggerState())
+	require.Equal(t, config.OrderType(config.OrderTypeMarket), stop.OrderType())
+	require.InDelta(t, 8.8, stop.TradePrice(), 1e-9)
+	require.Equal(t, config.TriggerCanceled, profit.TriggerState())
+	require.Empty(t, s.cond.orders(testInstID))
+	require.Equal(t, 0.0, s.Position[testInstID].Volume())
+}
+
+// 测试跟踪止损: 触发价随最高价上移，触发后以触发价成交
+func TestTrailingStop(t *testing.T) {
+	s, f := newTestStock(t)
+	m := new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+
+	place(t, s, 1000, account.WithOrderType(config.OrderTypeMarket))
+	s.DoMatch(f.tm, bar(10, 10, 10, 10), m)
+	s.DoSettle(f.tm, nil, nil)
+
+	o := place(t, s, 1000, account.WithOrderDirection(config.OrderSell), account.WithTrailingStop(0.1))
+	s.DoMatch(f.tm, bar(10, 12, 10, 11.5), m)
+	require.Equal(t, config.TriggerPending, o.TriggerState())
+	require.InDelta(t, 10.8, o.StopPrice(), 1e-9)
+
+	s.DoMatch(f.tm.Add(time.Minute), bar(11.5, 11.6, 10.5, 10.6), m)
+	require.Equal(t, config.TriggerFired, o.TriggerState())
+	require.InDelta(t, 10.8, o.TradePrice(), 1e-9)
+	require.Equal(t, 1000.0, o.TradeQty())
+}
+
+// 测试止损限价单: 跳空越过限价时触发但不成交
+func TestStopLimit(t *testing.T) {
+	s, f := newTestStock(t)
+	m := new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+
+	o := place(t, s, 1000, account.WithStopLimit(10.5, 10.6))
+	s.DoMatch(f.tm, bar(10, 10.4, 9.9, 10.2), m)
+	require.Equal(t, config.TriggerPending, o.TriggerState())
+
+	s.DoMatch(f.tm.Add(time.Minute), bar(10.8, 11, 10.7, 10.9), m)
+	require.Equal(t, config.TriggerFired, o.TriggerState())
+	require.Equal(t, config.OrderType(config.OrderTypeLimit), o.OrderType())
+	require.Equal(t, 0.0, o.TradeQty())
+	require.Greater(t, s.Asset.Frozen, 0.0)
+
+	s.DoMatch(f.tm.Add(2*time.Minute), bar(10.7, 10.7, 10.5, 10.6), m)
+	require.InDelta(t, 10.6, o.TradePrice(), 1e-9)
+}
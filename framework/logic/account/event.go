@@ -0,0 +1,134 @@
+package account
+
+import (
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + 订单回报:
+// + 账户在订单状态变化和每笔成交时记录回报，框架在撮合、下单和结算之后统一回调策略，避免在撮合过程中重入下单
+// + 同一订单的同一状态只回报一次，成交回报逐笔记录
+// + 下单前检查拒单的订单不进入账户的订单列表，同样回报拒单
+// + 每笔成交分配成交id并写入成交记录，实盘重启时按成交记录逐笔重放
+// + 成交同时更新完整交易日志，见 journal.go
+
+// NotifyOrder 记录订单回报
+func (d *DefaultHandler) NotifyOrder(event handler.OrderEvent) {
+	// 恢复的订单和成交不回报也不重复记录
+	if d.resuming {
+		return
+	}
+
+	if event.Qty != 0 {
+		d.tradeID++
+		event.TradeID = d.tradeID
+		if r := d.recorder[config.RecordTypeTrade]; r != nil {
+			r.GetChannel() <- makeTradeRecord(event)
+		}
+		d.journal.trade(event)
+	} else {
+		if status, ok := d.notified[event.Order.ID()]; ok && status == event.Status {
+			return
+		}
+
+		d.notified[event.Order.ID()] = event.Status
+	}
+
+	d.events = append(d.events, event)
+}
+
+// DispatchOrderEvents 按顺序回调订单回报，回调中产生的新回报一并处理
+func (d *DefaultHandler) DispatchOrderEvents(strategy handler.Strategy) {
+	listener, ok := strategy.(handler.OrderListener)
+	for len(d.events) > 0 {
+		events := d.events
+		d.events = nil
+		if !ok {
+			continue
+		}
+
+		for _, event := range events {
+			switch {
+			case event.Qty > 0:
+				listener.OnTrade(d.framework, event)
+			case event.Status == config.OrderStatusRejected:
+				listener.OnReject(d.framework, event)
+			default:
+				listener.OnOrder(d.framework, event)
+			}
+		}
+	}
+}
+
+// notifyStatus 订单状态变化回报
+func notifyStatus(acc handler.Account, o handler.Order) {
+	acc.NotifyOrder(handler.OrderEvent{Order: o, Status: o.OrderStatus(), Reason: o.RejectReason()})
+}
+
+// notifyTrade 成交回报
+func notifyTrade(acc handler.Account, o handler.Order, price, qty, commission float64) {
+	if qty == 0 {
+		return
+	}
+
+	acc.NotifyOrder(
+		handler.OrderEvent{Order: o, Status: o.OrderStatus(), Price: price, Qty: qty, Commission: commission},
+	)
+}
+
+// reject 下单前检查拒单并回报
+func reject(acc handler.Account, o handler.Order, err error) error {
+	o.DoReject(*acc.GetCurrTime(), err)
+	notifyStatus(acc, o)
+
+	return err
+}
+
+// AdjustRoundTrip 除权除息调整完整交易日志
+func (d *DefaultHandler) AdjustRoundTrip(accountID, instID string, xrxd *config.Xrxd) {
+	d.journal.adjust(accountID, instID, xrxd)
+}
+
+// liquidity 成交的流动性标志，限价单以委托价成交视为挂单成交
+func liquidity(o account.Order, price float64) config.Liquidity {
+	if o.OrderType() == config.OrderTypeLimit && price == o.OrderPrice() {
+		return config.LiquidityMaker
+	}
+
+	return config.LiquidityTaker
+}
+
+func makeTradeRecord(event handler.OrderEvent) *recorder.TradeRecord {
+	o := event.Order
+	tm := o.TradeTime()
+
+	return &recorder.TradeRecord{
+		TradeDate:         tm.Format(config.TimeFormatDate2),
+		Account:           o.Account().AccountID(),
+		TradeId:           event.TradeID,
+		OrderId:           o.ID(),
+		TradeTime:         tm.Format(config.TimeFormatTime2),
+		InstId:            o.InstID(),
+		OrderDirection:    string(o.OrderDirection()),
+		PositionDirection: string(o.PositionDirection()),
+		TransactionType:   string(o.TransactionType()),
+		Price:             event.Price,
+		Qty:               event.Qty,
+		Commission:        event.Commission,
+		Liquidity:         string(liquidity(o, event.Price)),
+	}
+}
+
+// groupTrades 按订单分组账户的成交记录
+func groupTrades(trades []recorder.TradeRecord, accountID string) map[int64][]recorder.TradeRecord {
+	fills := make(map[int64][]recorder.TradeRecord)
+	for _, t := range trades {
+		if t.Account == accountID {
+			fills[t.OrderId] = append(fills[t.OrderId], t)
+		}
+	}
+
+	return fills
+}
@@ -0,0 +1,186 @@
+package account
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/matcher"
+	"github.com/wonderstone/QuantKit/tools/qk"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// testListener 记录收到的订单回报
+type testListener struct {
+	handler.EmptyStrategy
+	events []string
+	last   handler.OrderEvent
+}
+
+func (l *testListener) OnOrder(framework handler.Framework, event handler.OrderEvent) {
+	l.events = append(l.events, fmt.Sprintf("order:%d", event.Status))
+	l.last = event
+}
+
+func (l *testListener) OnTrade(framework handler.Framework, event handler.OrderEvent) {
+	l.events = append(l.events, "trade")
+	l.last = event
+}
+
+func (l *testListener) OnReject(framework handler.Framework, event handler.OrderEvent) {
+	l.events = append(l.events, "reject")
+	l.last = event
+}
+
+func status(s config.OrderStatus) string {
+	return fmt.Sprintf("order:%d", s)
+}
+
+// 测试订单回报: 新建、成交、完成、下单前拒单、撤单和收盘过期，同一状态只回报一次
+func TestOrderEvents(t *testing.T) {
+	s, f := newTestStock(t)
+	d := s.Account().(*DefaultHandler)
+	m := new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+	l := &testListener{}
+
+	place(t, s, 1000, account.WithOrderPrice(10))
+	s.DoMatch(f.tm, bar(10, 10.2, 9.8, 10), m)
+	d.DispatchOrderEvents(l)
+	require.Equal(t, []string{status(config.OrderStatusNew), "trade", status(config.OrderStatusDone)}, l.events)
+	l.events = nil
+
+	// 当日买入不可卖，下单前拒单回报拒单原因
+	o, err := s.NewOrder(testInstID, 1000, account.WithOrderPrice(10), account.WithOrderDirection(config.OrderSell))
+	require.NoError(t, err)
+	require.Error(t, s.InsertOrder(o, account.WithCheckPosition(true)))
+	d.DispatchOrderEvents(l)
+	require.Equal(t, []string{"reject"}, l.events)
+	require.IsType(t, qk.ErrInsufficientPosition{}, l.last.Reason)
+	l.events = nil
+
+	canceled := place(t, s, 100, account.WithOrderPrice(5))
+	place(t, s, 100, account.WithOrderPrice(5))
+	s.CancelOrder(f.tm, canceled.ID())
+	s.DoSettle(f.tm, nil, nil)
+	d.DoSettle(f.tm, nil)
+	d.DispatchOrderEvents(l)
+	require.Equal(t, []string{
+		status(config.OrderStatusNew), status(config.OrderStatusNew),
+		status(config.OrderStatusCanceled), status(config.OrderStatusExpired),
+	}, l.events)
+}
+
+// 测试成交回报的价格和数量，以及回调中下单产生的回报一并处理
+func TestTradeEvent(t *testing.T) {
+	s, f := newTestStock(t)
+	d := s.Account().(*DefaultHandler)
+	m := new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+
+	place(t, s, 1000, account.WithOrderPrice(10.5))
+	s.DoMatch(f.tm, bar(10, 10.2, 9.8, 10), m)
+
+	var trades []handler.OrderEvent
+	l := &tradeListener{trades: &trades, s: s}
+	d.DispatchOrderEvents(l)
+	require.Len(t, trades, 1)
+	require.InDelta(t, 10, trades[0].Price, 1e-9)
+	require.Equal(t, 1000.0, trades[0].Qty)
+	require.Equal(t, 2, l.orders)
+}
+
+// tradeListener 成交后再下一笔订单
+type tradeListener struct {
+	handler.EmptyStrategy
+	trades *[]handler.OrderEvent
+	s      *StockSimple
+	orders int
+}
+
+func (l *tradeListener) OnTrade(framework handler.Framework, event handler.OrderEvent) {
+	*l.trades = append(*l.trades, event)
+
+	o, _ := l.s.NewOrder(testInstID, 100, account.WithOrderPrice(5))
+	_ = l.s.InsertOrder(o)
+}
+
+func (l *tradeListener) OnOrder(framework handler.Framework, event handler.OrderEvent) {
+	if event.Status == config.OrderStatusNew {
+		l.orders++
+	}
+}
+
+// 测试成交记录: 每笔成交一条记录，重启时按成交记录逐笔恢复订单
+func TestTradeRecord(t *testing.T) {
+	s, f := newTestStock(t)
+	d := s.Account().(*DefaultHandler)
+	trades := recorder.NewMemoryRecorder[recorder.TradeRecord]()
+	d.recorder = map[config.RecordType]recorder.Handler{config.RecordTypeTrade: trades}
+	done := make(chan struct{})
+	go func() {
+		_ = trades.RecordChan()
+		close(done)
+	}()
+
+	o := place(t, s, 1000, account.WithOrderPrice(10))
+	o.DoTradeUpdate(10, 400, f.tm, nil)
+	o.DoTradeUpdate(9.9, 600, f.tm.Add(time.Minute), nil)
+	trades.Release()
+	<-done
+
+	fills := trades.GetRecord()
+	require.Len(t, fills, 2)
+	require.Equal(t, []int64{1, 2}, []int64{fills[0].TradeId, fills[1].TradeId})
+	require.Equal(t, o.ID(), fills[1].OrderId)
+	require.Equal(t, 400.0, fills[0].Qty)
+	require.Equal(t, string(config.LiquidityMaker), fills[0].Liquidity)
+	require.Equal(t, string(config.LiquidityTaker), fills[1].Liquidity)
+	require.Equal(t, f.tm.Add(time.Minute).Format(config.TimeFormatTime2), fills[1].TradeTime)
+	require.Greater(t, fills[0].Commission, 0.0)
+
+	// 恢复时逐笔重放成交，恢复的成交不重复记录
+	r, _ := newTestStock(t)
+	rd := r.Account().(*DefaultHandler)
+	rd.resuming = true
+	r.DoResume(nil, nil, []recorder.OrderRecord{{
+		Account: r.accountID, OrderId: o.ID(), OrderDate: f.tm.Format(config.TimeFormatDate2),
+		OrderTime: f.tm.Format(config.TimeFormatTime2), InstId: testInstID,
+		OrderDirection: string(config.OrderBuy), OrderPrice: 10, OrderQty: 1000,
+		TradePrice: o.TradePrice(), TradeQty: o.TradeQty(),
+	}}, fills)
+	rd.resuming = false
+
+	resumed := r.GetOrders(testInstID)
+	require.Len(t, resumed, 1)
+	require.Equal(t, config.OrderStatus(config.OrderStatusDone), resumed[0].OrderStatus())
+	require.InDelta(t, o.TradePrice(), resumed[0].TradePrice(), 1e-9)
+	require.Equal(t, f.tm.Add(time.Minute).Format(config.TimeFormatTime2), resumed[0].TradeTime().Format(config.TimeFormatTime2))
+	require.Equal(t, 1000.0, r.Position[testInstID].Volume())
+	require.Empty(t, rd.events)
+	require.Zero(t, rd.tradeID)
+}
+
+// 测试恢复成交id: 取整个成交表中最大的成交id，而不只是上一交易日之后的成交
+func TestTradeMaxID(t *testing.T) {
+	trades := recorder.NewSqliteRecorder[recorder.TradeRecord](recorder.WithFilePath(filepath.Join(t.TempDir(), "trade")))
+	done := make(chan struct{})
+	go func() {
+		_ = trades.RecordChan()
+		close(done)
+	}()
+
+	trades.GetChannel() <- &recorder.TradeRecord{TradeDate: "2024.01.02", TradeId: 7}
+	trades.GetChannel() <- &recorder.TradeRecord{TradeDate: "2024.01.03", TradeId: 3}
+	trades.Release()
+	<-done
+
+	d := &DefaultHandler{recorder: map[config.RecordType]recorder.Handler{config.RecordTypeTrade: trades}}
+	require.Equal(t, int64(7), d.getTradeMaxID())
+	require.Len(t, d.getLastTillNowTradeRecords("2024.01.02"), 1)
+
+	require.Zero(t, (&DefaultHandler{}).getTradeMaxID())
+}
@@ -0,0 +1,563 @@
+package account
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/entity/tunnel"
+	"github.com/wonderstone/QuantKit/framework/logic/order"
+	"github.com/wonderstone/QuantKit/framework/logic/position"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/qk"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + Future 期货账户
+// + 多空持仓分别记录，开仓冻结保证金和手续费，成交后转为持仓保证金
+// + 手续费按开仓、平今、平昨分别收取
+// + 每日按结算价盯市，浮动盈亏转入静态权益
+// 动态权益 = 静态权益(上日结算) + 平仓盈亏 + 持仓盯市盈亏 - 当日手续费
+// 可用资金 = 动态权益 - 持仓保证金 - 冻结资金
+type Future struct {
+	handler.Resource
+	// 账户ID
+	accountID string
+	// 资金账户
+	cashAccount *config.TradeAcc
+	// 账户资产
+	Asset account.Asset
+	// 账户盈亏
+	PnL account.PnL
+	// 账户持仓
+	Position map[string]*position.FuturePosition
+
+	preBalance  float64           // 静态权益
+	closeProfit float64           // 当日平仓盈亏(盯市)
+	commission  float64           // 当日手续费
+	orderFrozen map[int64]float64 // 订单冻结资金(保证金+手续费)
+	orders      map[int64]handler.Order
+	inst2open   map[string]*orderedmap.OrderedMap[int64, handler.Order]
+	inst2close  map[string]*orderedmap.OrderedMap[int64, handler.Order]
+	cond        *conditionalBook   // 条件单簿
+	last        map[string]float64 // 各合约最新价，未指定价格的市价单按最新价冻结保证金
+	tunnel      tunnel.Tunnel
+}
+
+func (f *Future) GetRunId() string {
+	return f.Config().ID
+}
+
+func (f *Future) CashAccount() string {
+	if f.cashAccount == nil {
+		return ""
+	}
+
+	return f.cashAccount.Username
+}
+
+func (f *Future) DoRTInsert(order tunnel.Order) (string, error) {
+	return f.tunnel.PlaceOrder(order)
+}
+
+func (f *Future) DoRTCancel(orderId string) error {
+	return f.tunnel.CancelOrder(orderId)
+}
+
+func (f *Future) Init(account handler.Accounts, option any) error {
+	f.Resource = account.(handler.Resource)
+
+	f.accountID = config.AccountTypeFuture
+	f.Asset.Initial = option.(config.Account).Cash
+	f.preBalance = option.(config.Account).Cash
+
+	f.Position = make(map[string]*position.FuturePosition)
+	f.last = make(map[string]float64)
+	f.cond = newConditionalBook(f, f.Account())
+	f.resetOrders()
+	f.refresh()
+
+	// 实盘账户，需要加载交易通道
+	if f.Config().Framework.Realtime && f.Config().Framework.Future.Account != nil {
+		f.cashAccount = f.Config().Framework.Future.Account
+		f.tunnel = setting.MustNewTunnelHandler(
+			config.HandlerType(f.cashAccount.Tunnel),
+			tunnel.TradeOnly(),
+			tunnel.WithConfig(f.Config()),
+			tunnel.WithTunnelUrl(f.cashAccount.URL),
+		)
+	}
+
+	return nil
+}
+
+func (f *Future) resetOrders() {
+	f.orderFrozen = make(map[int64]float64)
+	f.orders = make(map[int64]handler.Order)
+	f.inst2open = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
+	f.inst2close = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
+}
+
+// refresh 根据持仓重新计算保证金、市值、动态权益和可用资金
+func (f *Future) refresh() {
+	margin, floatProfit, marketValue := 0.0, 0.0, 0.0
+	for _, p := range f.Position {
+		margin += p.Margin()
+		floatProfit += p.FloatProfit()
+		marketValue += p.Amt()
+	}
+
+	f.Asset.Margin = margin
+	f.Asset.MarketValue = marketValue
+	f.Asset.Total = f.preBalance + f.closeProfit + floatProfit - f.commission
+	f.Asset.Available = f.Asset.Total - f.Asset.Margin - f.Asset.Frozen
+	f.PnL.Profit = f.Asset.Total - f.Asset.Initial
+}
+
+func (f *Future) Release() {}
+
+func (f *Future) GetType() string {
+	return config.AccountTypeFuture
+}
+
+func (f *Future) AccountID() string {
+	return f.accountID
+}
+
+func (f *Future) GetAsset() account.Asset {
+	return f.Asset
+}
+
+func (f *Future) GetPnL() account.PnL {
+	return f.PnL
+}
+
+func (f *Future) GetPosition() (pos map[string]account.Position) {
+	pos = make(map[string]account.Position)
+	for instID, p := range f.Position {
+		pos[instID] = p
+	}
+
+	return
+}
+
+func (f *Future) GetPositionByInstID(instID string) (account.Position, bool) {
+	p, ok := f.Position[instID]
+	return p, ok
+}
+
+func (f *Future) GetPosInstIDs() []string {
+	var instIDs []string
+	for instID := range f.Position {
+		instIDs = append(instIDs, instID)
+	}
+
+	return instIDs
+}
+
+func (f *Future) GetOrders(instID string) []account.Order {
+	var orders []account.Order
+	for _, m := range []map[string]*orderedmap.OrderedMap[int64, handler.Order]{f.inst2close, f.inst2open} {
+		if v, ok := m[instID]; ok {
+			for pOrder := v.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+				orders = append(orders, pOrder.Value)
+			}
+		}
+	}
+
+	return append(orders, f.cond.orders(instID)...)
+}
+
+func (f *Future) GenOrderId() int64 {
+	return f.Account().(*DefaultHandler).GenOrderId()
+}
+
+// AddAvailable 出入金
+func (f *Future) AddAvailable(available float64) {
+	f.preBalance += available
+	f.Asset.Initial += available
+	f.refresh()
+}
+
+// AddDividend 期货没有分红
+func (f *Future) AddDividend(dividend, tax float64) {}
+
+// AddDynamicPnL 期货盈亏由持仓盯市计算，这里只刷新账户
+func (f *Future) AddDynamicPnL(pnl ...float64) {
+	f.refresh()
+}
+
+// CalcSettleInfo 期货没有除权除息
+func (f *Future) CalcSettleInfo(
+	instID string, qty, price, lastPrice float64,
+) (settleQty, settlePrice, settleLastPrice, dividend, tax float64) {
+	return qty, price, lastPrice, 0, 0
+}
+
+func (f *Future) CalcPositionPnL(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) {
+	for instID, p := range f.Position {
+		if v, ok := indicators.Get(instID); ok {
+			p.CalcPnL(tm, v.ConvertToFloat("Close"))
+		}
+	}
+
+	f.refresh()
+}
+
+func (f *Future) NewOrder(instId string, qty float64, options ...account.WithOrderOption) (account.Order, error) {
+	if qty == 0 {
+		return nil, qk.ErrInsufficientOrderQty{}
+	}
+
+	op := account.NewOrderOp(options...)
+
+	if op.Account == nil {
+		op.Account = f
+	}
+
+	if op.OrderTime == nil {
+		op.OrderTime = f.Account().GetCurrTime()
+	}
+
+	if op.OrderPrice == 0 && op.OrderType == config.OrderTypeLimit {
+		return nil, qk.ErrInsufficientOrderPriceLimit{}
+	}
+
+	return order.NewOrder(f.GenOrderId(), f.Contract().GetContract(instId), qty, op), nil
+}
+
+func (f *Future) InsertOrder(o account.Order, options ...account.WithOrderOption) error {
+	// 未触发的条件单由条件单簿保存
+	if f.cond.hold(o, options) {
+		return nil
+	}
+
+	orderOp := o.(handler.Order)
+	op := account.NewOrderOp(options...)
+	if err := preTrade(f.Account(), orderOp, op); err != nil {
+		return err
+	}
+
+	if o.TransactionType() == config.OffsetOpen {
+		need := f.frozen(orderOp)
+		if op.CheckCash && f.Asset.Available < need {
+			err := qk.ErrInsufficientCash{Need: need, Have: f.Asset.Available}
+			return reject(f.Account(), orderOp, err)
+		}
+	} else if !op.Resumed {
+		// 平仓必须有足够的可平持仓
+		have := 0.0
+		if pos, ok := f.Position[o.InstID()]; ok {
+			have = pos.Closable(o.PositionDirection(), o.TransactionType())
+		}
+
+		if have < o.OrderQty() {
+			err := qk.ErrInsufficientPosition{Need: o.OrderQty(), Have: have}
+			return reject(f.Account(), orderOp, err)
+		}
+	}
+
+	// 通过检查后才进入订单簿，拒单不在结算时重复处理
+	f.orders[o.ID()] = orderOp
+	inst2orders := f.inst2open
+	if o.TransactionType() != config.OffsetOpen {
+		inst2orders = f.inst2close
+	}
+
+	if inst2orders[o.InstID()] == nil {
+		inst2orders[o.InstID()] = orderedmap.New[int64, handler.Order]()
+	}
+	inst2orders[o.InstID()].Set(o.ID(), orderOp)
+
+	f.DoOrderUpdate(orderOp)
+
+	if f.Config().Framework.Realtime && !op.Resumed {
+		_, err := f.DoRTInsert(orderOp.(tunnel.Order))
+		if err != nil {
+			config.WarnF("实盘下单失败: %+v\n", err)
+		} else {
+			config.InfoF("实盘下单成功: %+v\n", orderOp)
+		}
+	}
+
+	return nil
+}
+
+func (f *Future) CancelOrder(tm time.Time, id int64) {
+	if f.cond.cancel(tm, id) {
+		return
+	}
+
+	o, ok := f.orders[id]
+	if !ok {
+		config.WarnF("撤单失败, 未找到订单: %d", id)
+		return
+	}
+
+	o.DoCancelUpdate(tm)
+
+	if orders, ok := f.inst2open[o.InstID()]; ok {
+		orders.Delete(id)
+	}
+
+	if orders, ok := f.inst2close[o.InstID()]; ok {
+		orders.Delete(id)
+	}
+}
+
+// frozen 订单需要冻结的资金，开仓冻结保证金和手续费，平仓只冻结手续费
+// 未指定价格的市价单按最新价估算
+func (f *Future) frozen(o handler.Order) float64 {
+	price, ok := f.last[o.InstID()]
+	if o.OrderPrice() > 0 || !ok {
+		return o.Margin() + o.CommissionFrozen()
+	}
+
+	frozen := 0.0
+	if c, ok := o.Contract().(contract.Future); ok {
+		frozen = c.CalcCommOffset(o.OrderTime(), o.OrderQty(), price, o.TransactionType())
+	}
+
+	if o.TransactionType() == config.OffsetOpen {
+		frozen += o.Contract().CalcMargin(o.OrderQty(), price, o.PositionDirection())
+	}
+
+	return frozen
+}
+
+// releaseFrozen 释放订单冻结资金，ratio为释放比例
+func (f *Future) releaseFrozen(id int64, ratio float64) {
+	frozen, ok := f.orderFrozen[id]
+	if !ok {
+		return
+	}
+
+	release := frozen * ratio
+	f.Asset.Frozen -= release
+	if ratio >= 1 {
+		delete(f.orderFrozen, id)
+	} else {
+		f.orderFrozen[id] = frozen - release
+	}
+}
+
+func (f *Future) DoOrderUpdate(order handler.Order) {
+	notifyStatus(f.Account(), order)
+
+	switch order.OrderStatus() {
+	case config.OrderStatusNew:
+		frozen := f.frozen(order)
+		f.orderFrozen[order.ID()] = frozen
+		f.Asset.Frozen += frozen
+	case config.OrderStatusCanceled, config.OrderStatusPartDonePartCancel,
+		config.OrderStatusDone, config.OrderStatusExpired, config.OrderStatusRejected:
+		f.releaseFrozen(order.ID(), 1)
+	}
+
+	if pos, ok := f.Position[order.InstID()]; ok {
+		pos.DoOrderUpdate(order)
+	}
+
+	f.refresh()
+}
+
+func (f *Future) DoTradeUpdate(qty, price float64, order handler.Order) {
+	// 按成交比例释放冻结资金, 此时订单成交数量已经包含本次成交
+	if remain := order.OrderQty() - order.TradeQty() + qty; remain > 0 {
+		f.releaseFrozen(order.ID(), qty/remain)
+	}
+
+	pos, ok := f.Position[order.InstID()]
+	if !ok {
+		pos = position.NewPosition(f, order.Contract(), 0, price).(*position.FuturePosition)
+		f.Position[order.InstID()] = pos
+	}
+
+	todayQty, hisQty, closeProfit := pos.TradeSplit(qty, price, order)
+
+	// 按开仓、平今、平昨计算手续费
+	commission := 0.0
+	if c, ok := order.Contract().(contract.Future); ok {
+		if order.TransactionType() == config.OffsetOpen {
+			commission = c.CalcCommOffset(order.OrderTime(), qty, price, config.OffsetOpen)
+		} else {
+			commission = c.CalcCommOffset(order.OrderTime(), todayQty, price, config.OffsetClose) +
+				c.CalcCommOffset(order.OrderTime(), hisQty, price, config.OffsetCloseHis)
+		}
+	}
+
+	order.ModifyOrder("commission", order.Commission()+commission)
+
+	f.closeProfit += closeProfit
+	f.commission += commission
+	f.Asset.Commission += commission
+
+	f.refresh()
+	notifyTrade(f.Account(), order, price, qty, commission)
+}
+
+func (f *Future) DoMatch(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord], matcher handler.Matcher,
+) {
+	// 先平后开，平仓释放的保证金可以用于开仓
+	for pInst := indicators.Oldest(); pInst != nil; pInst = pInst.Next() {
+		if price, ok := pInst.Value.Get("Close"); ok && price > 0 {
+			f.last[pInst.Key] = price
+		}
+
+		f.cond.trigger(tm, pInst.Key, pInst.Value)
+		for _, m := range []map[string]*orderedmap.OrderedMap[int64, handler.Order]{f.inst2close, f.inst2open} {
+			if orders := m[pInst.Key]; orders != nil {
+				for pOrder := orders.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+					matcher.MatchOrder(pOrder.Value, pInst.Value, tm)
+				}
+			}
+		}
+	}
+
+	f.cond.afterMatch(tm)
+}
+
+// getFutureSettlePrice 期货结算价，没有结算价时使用收盘价
+func getFutureSettlePrice(
+	instID string, indicators *orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) float64 {
+	if indicators == nil {
+		return 0
+	}
+
+	if v, ok := indicators.Get(instID); ok {
+		if settlePrice, ok := v.Get("Settle"); ok && settlePrice != 0 {
+			return settlePrice
+		}
+	}
+
+	return getSettlePrice(instID, indicators)
+}
+
+func (f *Future) DoSettle(
+	tm time.Time, indicators *orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+	recorder map[config.RecordType]recorder.Handler,
+) {
+	// 处理订单, 未成交订单过期
+	for _, o := range f.orders {
+		status := o.OrderStatus()
+		o.DoSettle(tm, recorder[config.RecordTypeOrder])
+		if o.OrderStatus() != status {
+			notifyStatus(f.Account(), o)
+		}
+	}
+	f.cond.settle(tm, recorder[config.RecordTypeOrder])
+
+	// 冻结资金全部释放
+	f.Asset.Frozen = 0
+	f.resetOrders()
+
+	// 按结算价盯市
+	for instID, p := range f.Position {
+		p.CalcPnL(tm, getFutureSettlePrice(instID, indicators))
+	}
+	f.refresh()
+
+	// 当日动态权益转为静态权益
+	f.preBalance = f.Asset.Total
+	f.closeProfit = 0
+	f.commission = 0
+
+	// 持仓结算，今仓转昨仓，基准价更新为结算价
+	for instID, p := range f.Position {
+		if !p.DoSettle(
+			tm, getFutureSettlePrice(instID, indicators), recorder[config.RecordTypePosition],
+		) {
+			delete(f.Position, instID)
+		}
+	}
+	f.refresh()
+
+	if r := recorder[config.RecordTypeAsset]; r != nil {
+		record := MakeRecord(tm, f.accountID, f.Asset, f.PnL)
+		r.GetChannel() <- &record
+	}
+}
+
+func (f *Future) DoResume(
+	assets []recorder.AssetRecord,
+	positions []recorder.PositionRecord,
+	orders []recorder.OrderRecord,
+	trades []recorder.TradeRecord,
+) {
+	// 恢复持仓，多空两个方向的记录合并到同一持仓
+	for _, record := range positions {
+		if record.Account != f.accountID {
+			continue
+		}
+
+		contractInfo := f.Contract().GetContract(record.InstID)
+		pos, ok := f.Position[record.InstID]
+		if !ok {
+			pos = position.NewPosition(f, contractInfo, 0, record.LastPrice).(*position.FuturePosition)
+			f.Position[record.InstID] = pos
+		}
+
+		pos.DoResume(f, contractInfo, record)
+	}
+
+	// 恢复资金
+	for _, record := range assets {
+		if record.Account != f.accountID {
+			continue
+		}
+
+		f.Asset.Initial = f.Config().Framework.Future.Cash
+		f.Asset.Commission = record.Commission
+		f.preBalance = record.TotalAsset
+	}
+
+	f.refresh()
+
+	// 恢复订单，逐笔重放成交
+	fills := groupTrades(trades, f.accountID)
+	for _, record := range orders {
+		if record.Account != f.accountID {
+			continue
+		}
+
+		tm, err := time.Parse(config.TimeFormatDate2+" "+config.TimeFormatTime2, record.OrderDate+" "+record.OrderTime)
+		if err != nil {
+			config.ErrorF("恢复订单失败: %+v\n", record)
+			return
+		}
+
+		orderType := config.OrderType(config.OrderTypeLimit)
+		if record.OrderPrice == 0.0 {
+			orderType = config.OrderTypeMarket
+		}
+
+		o := order.ResumeOrder(
+			record.OrderId,
+			f.Contract().GetContract(record.InstId), record.OrderQty, &account.OrderOp{
+				OrderTime:      &tm,
+				OrderPrice:     record.OrderPrice,
+				OrderDirection: config.OrderDirection(record.OrderDirection),
+				OrderType:      orderType,
+				Transaction:    config.TransactionType(record.TransactionType),
+				Account:        f,
+			},
+		)
+
+		_ = f.InsertOrder(o, ResumeOrder())
+		o.DoResume(&record, fills[record.OrderId]...)
+	}
+
+	config.InfoF("上场账户%s资金: %+v\n", f.accountID, f.Asset)
+}
+
+func init() {
+	setting.RegisterAccount(&Future{}, config.AccountTypeFuture)
+}
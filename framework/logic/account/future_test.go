@@ -0,0 +1,71 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/logic/matcher"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/idgen"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+const testFutureID = "IF2401.CCFX.CF"
+
+func newTestFuture(t *testing.T, cash float64) (*Future, *testFramework) {
+	prop, err := config.NewContractPropertyConfig("../position/contract.yaml")
+	require.NoError(t, err)
+
+	handle, err := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(prop))
+	require.NoError(t, err)
+
+	f := &testFramework{tm: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), contract: handle}
+	d := NewDefaultHandler()
+	d.framework = f
+	d.ai = idgen.New(1, 1)
+	d.Resource = setting.NewResource(setting.WithRuntimeConfig(&config.Runtime{}), setting.WithAccountHandler(d))
+
+	fut := &Future{}
+	require.NoError(t, fut.Init(d, config.Account{Cash: cash}))
+
+	return fut, f
+}
+
+// 测试市价开仓按最新价冻结保证金和手续费，资金不足时拒单
+func TestFutureMarketOrderFrozen(t *testing.T) {
+	fut, f := newTestFuture(t, 1000000)
+
+	quotes := orderedmap.New[string, dataframe.StreamingRecord]()
+	quotes.Set(testFutureID, dataframe.NewStreamingRecord([]string{"4000"}, map[string]int{"Close": 0}))
+	fut.DoMatch(f.tm, *quotes, new(matcher.NextQuote))
+
+	open := func() account.Order {
+		o, err := fut.NewOrder(
+			testFutureID, 1, account.WithOrderType(config.OrderTypeMarket),
+			account.WithTransactionType(config.OffsetOpen),
+		)
+		require.NoError(t, err)
+		return o
+	}
+
+	c := f.contract.GetContract(testFutureID)
+	margin := c.CalcMargin(1, 4000, config.PositionLong)
+	require.Greater(t, margin, 0.0)
+
+	require.NoError(t, fut.InsertOrder(open(), account.WithCheckCash(true)))
+	require.Greater(t, fut.Asset.Frozen, margin)
+
+	// 剩余资金不足以再冻结一手的保证金
+	fut.Asset.Available = margin / 2
+	rejected := open()
+	err := fut.InsertOrder(rejected, account.WithCheckCash(true))
+	require.ErrorAs(t, err, &qk.ErrInsufficientCash{})
+	require.NotContains(t, fut.orders, rejected.ID(), "拒单不进入订单簿")
+	require.Len(t, fut.orders, 1)
+}
@@ -0,0 +1,227 @@
+package account
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + 完整交易日志:
+// + 按账户、合约、持仓方向跟踪持仓，由空仓开仓到全部平仓记为一笔完整交易，部分平仓不结束交易
+// + 送转股计入开仓数量，派现冲减开仓成本，红利税计入手续费，换股的交易转到新合约
+// + 持仓期间按K线最高、最低价更新最大不利/有利波动(MAE/MFE)，每次结算累计一个持仓交易日
+// + 恢复的持仓没有开仓信息，不计入交易日志
+
+type tripKey struct {
+	account   string
+	instID    string
+	direction config.PositionDirection
+}
+
+type openTrip struct {
+	multiplier float64   // 合约乘数
+	entryTime  time.Time // 首笔开仓时间
+	entryQty   float64   // 累计开仓数量
+	entryAmt   float64   // 累计开仓金额(按价格计算)
+	exitQty    float64   // 累计平仓数量
+	exitAmt    float64   // 累计平仓金额(按价格计算)
+	openQty    float64   // 剩余持仓数量
+	commission float64   // 开平手续费及红利税
+	high       float64   // 持仓期间最高价
+	low        float64   // 持仓期间最低价
+	days       int       // 持仓交易日数
+}
+
+func (t *openTrip) mark(high, low float64) {
+	if high > t.high {
+		t.high = high
+	}
+
+	if low > 0 && (t.low == 0 || low < t.low) {
+		t.low = low
+	}
+}
+
+func (t *openTrip) merge(r *openTrip) {
+	if r.entryTime.Before(t.entryTime) {
+		t.entryTime = r.entryTime
+	}
+
+	t.entryQty += r.entryQty
+	t.entryAmt += r.entryAmt
+	t.exitQty += r.exitQty
+	t.exitAmt += r.exitAmt
+	t.openQty += r.openQty
+	t.commission += r.commission
+	t.days = max(t.days, r.days)
+	t.mark(r.high, r.low)
+}
+
+func (t *openTrip) record(key tripKey, id int64, exitTime time.Time) *recorder.RoundTripRecord {
+	entryPrice := t.entryAmt / t.entryQty
+	exitPrice := t.exitAmt / t.exitQty
+
+	// 多头: 有利为最高价、不利为最低价；空头相反
+	gross := (t.exitAmt - t.entryAmt) * t.multiplier
+	mae, mfe := t.low/entryPrice-1, t.high/entryPrice-1
+	if key.direction == config.PositionShort {
+		gross = -gross
+		mae, mfe = 1-t.high/entryPrice, 1-t.low/entryPrice
+	}
+
+	return &recorder.RoundTripRecord{
+		ExitDate:    exitTime.Format(config.TimeFormatDate2),
+		Account:     key.account,
+		TripId:      id,
+		InstId:      key.instID,
+		Direction:   string(key.direction),
+		EntryDate:   t.entryTime.Format(config.TimeFormatDate2),
+		EntryTime:   t.entryTime.Format(config.TimeFormatTime2),
+		ExitTime:    exitTime.Format(config.TimeFormatTime2),
+		EntryPrice:  entryPrice,
+		ExitPrice:   exitPrice,
+		Qty:         t.entryQty,
+		HoldingDays: t.days,
+		Commission:  t.commission,
+		PnL:         gross - t.commission,
+		MAE:         min(mae, 0),
+		MFE:         max(mfe, 0),
+	}
+}
+
+type journal struct {
+	recorder recorder.Handler
+	trips    map[tripKey]*openTrip
+}
+
+// newJournal 没有完整交易记录器时不记录交易日志
+func newJournal(r recorder.Handler) *journal {
+	if r == nil {
+		return nil
+	}
+
+	return &journal{recorder: r, trips: make(map[tripKey]*openTrip)}
+}
+
+// trade 按成交回报更新交易，全部平仓时写入完整交易记录
+func (j *journal) trade(event handler.OrderEvent) {
+	if j == nil {
+		return
+	}
+
+	o := event.Order
+	key := tripKey{o.Account().AccountID(), o.InstID(), o.PositionDirection()}
+	trip := j.trips[key]
+
+	// 买多、卖空为开仓，否则为平仓
+	long := key.direction != config.PositionShort
+	if (o.OrderDirection() == config.OrderBuy) == long {
+		if trip == nil {
+			trip = &openTrip{
+				multiplier: o.Contract().CalcMarketValue(1, 1, key.direction),
+				entryTime:  o.TradeTime(),
+			}
+			j.trips[key] = trip
+		}
+
+		trip.entryQty += event.Qty
+		trip.entryAmt += event.Qty * event.Price
+		trip.openQty += event.Qty
+		trip.commission += event.Commission
+		trip.mark(event.Price, event.Price)
+		return
+	}
+
+	if trip == nil {
+		return
+	}
+
+	qty := min(event.Qty, trip.openQty)
+	trip.exitQty += qty
+	trip.exitAmt += qty * event.Price
+	trip.openQty -= qty
+	trip.commission += event.Commission
+	trip.mark(event.Price, event.Price)
+	if trip.openQty > 0 {
+		return
+	}
+
+	delete(j.trips, key)
+	j.recorder.GetChannel() <- trip.record(key, event.TradeID, o.TradeTime())
+}
+
+// observe 按K线最高、最低价更新持仓期间的价格区间
+func (j *journal) observe(indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord]) {
+	if j == nil {
+		return
+	}
+
+	for key, trip := range j.trips {
+		quote, ok := indicators.Get(key.instID)
+		if !ok {
+			continue
+		}
+
+		high, ok := quote.Get("High")
+		if !ok {
+			high, _ = quote.Get("Close")
+		}
+
+		low, ok := quote.Get("Low")
+		if !ok {
+			low, _ = quote.Get("Close")
+		}
+
+		trip.mark(high, low)
+	}
+}
+
+// settle 结算时累计持仓交易日数
+func (j *journal) settle() {
+	if j == nil {
+		return
+	}
+
+	for _, trip := range j.trips {
+		trip.days++
+	}
+}
+
+// adjust 除权除息调整交易的数量和成本，价格区间按复权因子折算
+func (j *journal) adjust(accountID, instID string, xrxd *config.Xrxd) {
+	if j == nil {
+		return
+	}
+
+	for key, trip := range j.trips {
+		if key.account != accountID || key.instID != instID {
+			continue
+		}
+
+		qty, _, _, dividend, tax, settleInstID := xrxd.CalcPos(trip.openQty, trip.entryAmt/trip.entryQty)
+		trip.entryQty += qty - trip.openQty
+		trip.openQty = qty
+		trip.entryAmt -= dividend
+		trip.commission += tax
+		if factor := xrxd.Factor(); factor > 0 {
+			trip.high *= factor
+			trip.low *= factor
+		}
+
+		if settleInstID == "" {
+			continue
+		}
+
+		delete(j.trips, key)
+		key.instID = settleInstID
+		if exist, ok := j.trips[key]; ok {
+			exist.merge(trip)
+		} else {
+			j.trips[key] = trip
+		}
+	}
+}
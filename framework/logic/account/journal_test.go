@@ -0,0 +1,75 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// 测试完整交易日志: 分批平仓后记为一笔交易，MAE/MFE取持仓期间K线的最高、最低价
+func TestRoundTripJournal(t *testing.T) {
+	s, f := newTestStock(t)
+	d := s.Account().(*DefaultHandler)
+	trips := recorder.NewMemoryRecorder[recorder.RoundTripRecord]()
+	d.journal = newJournal(trips)
+	done := make(chan struct{})
+	go func() {
+		_ = trips.RecordChan()
+		close(done)
+	}()
+
+	buy := place(t, s, 1000, account.WithOrderPrice(10))
+	buy.DoTradeUpdate(10, 1000, f.tm, nil)
+	d.CalcPositionPnL(f.tm, bar(10, 11, 9.5, 10.5))
+	d.DoSettle(f.tm, nil)
+
+	next := f.tm.AddDate(0, 0, 1)
+	sell := place(t, s, 1000, account.WithOrderPrice(10.5), account.WithOrderDirection(config.OrderSell))
+	sell.DoTradeUpdate(10.5, 400, next, nil)
+	require.Len(t, d.journal.trips, 1, "部分平仓不结束交易")
+	sell.DoTradeUpdate(11, 600, next.Add(time.Minute), nil)
+	require.Empty(t, d.journal.trips)
+
+	trips.Release()
+	<-done
+
+	records := trips.GetRecord()
+	require.Len(t, records, 1)
+	trip := records[0]
+	require.Equal(t, testInstID, trip.InstId)
+	require.Equal(t, d.tradeID, trip.TripId)
+	require.Equal(t, f.tm.Format(config.TimeFormatDate2), trip.EntryDate)
+	require.Equal(t, next.Format(config.TimeFormatDate2), trip.ExitDate)
+	require.Equal(t, 1000.0, trip.Qty)
+	require.Equal(t, 1, trip.HoldingDays)
+	require.InDelta(t, 10, trip.EntryPrice, 1e-9)
+	require.InDelta(t, 10.8, trip.ExitPrice, 1e-9)
+	require.Greater(t, trip.Commission, 0.0)
+	require.InDelta(t, 800-trip.Commission, trip.PnL, 1e-9)
+	require.InDelta(t, 0.1, trip.MFE, 1e-9)
+	require.InDelta(t, -0.05, trip.MAE, 1e-9)
+}
+
+// 测试除权除息调整: 送转股计入开仓数量，派现冲减开仓成本，红利税计入手续费
+func TestRoundTripXrxd(t *testing.T) {
+	j := newJournal(recorder.NewMemoryRecorder[recorder.RoundTripRecord]())
+	key := tripKey{"stock", testInstID, config.PositionLong}
+	j.trips[key] = &openTrip{multiplier: 1, entryQty: 1000, entryAmt: 10000, openQty: 1000, high: 12, low: 8}
+
+	// 10送10派10
+	j.adjust("stock", testInstID, &config.Xrxd{DivCash: 1, DivShare: 1, ExFactor: 0.45})
+	trip := j.trips[key]
+	require.Equal(t, 2000.0, trip.openQty)
+	require.Equal(t, 2000.0, trip.entryQty)
+	require.InDelta(t, 9000, trip.entryAmt, 1e-9)
+	require.InDelta(t, 200, trip.commission, 1e-9)
+	require.InDelta(t, 5.4, trip.high, 1e-9)
+
+	// 其他账户、合约不受影响
+	j.adjust("future", testInstID, &config.Xrxd{DivShare: 1})
+	require.Equal(t, 2000.0, j.trips[key].openQty)
+}
@@ -0,0 +1,816 @@
+package account
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/entity/tunnel"
+	"github.com/wonderstone/QuantKit/framework/logic/order"
+	"github.com/wonderstone/QuantKit/framework/logic/position"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/btree"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/qk"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+const (
+	defaultFinancingRate    = 0.06 // 默认融资年利率
+	defaultLendingRate      = 0.08 // 默认融券年费率
+	defaultDayCount         = 360  // 默认计息天数基准
+	defaultMarginRatio      = 1.0  // 默认融资融券保证金比例
+	defaultHaircut          = 0.7  // 默认担保证券折算率
+	defaultLiquidationRatio = 1.3  // 默认维持担保比例平仓线
+)
+
+// + MarginStock 融资融券账户
+// + 买开=担保品买入(WithFinancing为融资买入) | 卖平=卖券还款，卖出所得优先偿还利息和融资负债
+// + 卖开=融券卖出(WithShortSell)，只能融可融券名单中的标的，所得资金只能用于买券还券 | 买平=买券还券
+// + 融资负债和融券市值每日按自然日计息，利息计入负债
+// + 维持担保比例 = (现金 + 多头市值) / (融资负债 + 融券市值 + 未还利息)，日终低于平仓线时按收盘价强制平仓
+// + 除权除息按多空两个方向分别调整持仓，多头分红(扣税)计入现金，融券持仓应付的分红从现金扣除
+// 资产记录中 Margin 为负债总额，Total 为净资产，MarketValue 为多头市值
+type MarginStock struct {
+	handler.Resource
+	// 账户ID
+	accountID string
+	// 资金账户
+	cashAccount *config.TradeAcc
+	// 信用交易参数
+	param config.MarginStock
+	// 账户资产
+	Asset account.Asset
+	// 账户盈亏
+	PnL account.PnL
+	// 账户持仓
+	Position map[string]*position.MarginPosition
+
+	cash         float64             // 现金余额，包含融券卖出所得
+	shortCash    float64             // 融券卖出所得资金，只能用于买券还券
+	debt         float64             // 融资负债
+	interest     float64             // 未还利息(融资利息和融券费用)
+	creditFrozen float64             // 挂单占用的保证金
+	lastSettle   time.Time           // 上次计息日期
+	borrowable   map[string]struct{} // 可融券标的
+	orderFrozen  map[int64]float64   // 订单冻结资金
+	orderCredit  map[int64]float64   // 订单占用保证金
+	financing    map[int64]bool      // 融资买入订单
+	orders       map[int64]handler.Order
+	inst2open    map[string]*orderedmap.OrderedMap[int64, handler.Order]
+	inst2close   map[string]*orderedmap.OrderedMap[int64, handler.Order]
+	cond         *conditionalBook        // 条件单簿
+	xrxd         *XrxdHandler            // 除权除息数据
+	exRights     map[string]*config.Xrxd // 当日结算的除权除息，多空两个方向共用
+	tunnel       tunnel.Tunnel
+}
+
+func (m *MarginStock) GetRunId() string {
+	return m.Config().ID
+}
+
+func (m *MarginStock) CashAccount() string {
+	if m.cashAccount == nil {
+		return ""
+	}
+
+	return m.cashAccount.Username
+}
+
+func (m *MarginStock) DoRTInsert(order tunnel.Order) (string, error) {
+	return m.tunnel.PlaceOrder(order)
+}
+
+func (m *MarginStock) DoRTCancel(orderId string) error {
+	return m.tunnel.CancelOrder(orderId)
+}
+
+func (m *MarginStock) Init(account handler.Accounts, option any) error {
+	m.xrxd = &XrxdHandler{
+		instID2SettleInfo: make(map[string]*btree.MapIterG[time.Time, *config.Xrxd]),
+		basic:             account.Base(),
+	}
+	m.exRights = make(map[string]*config.Xrxd)
+
+	m.Resource = account.(handler.Resource)
+
+	m.param = option.(config.MarginStock)
+	if m.param.FinancingRate == 0 {
+		m.param.FinancingRate = defaultFinancingRate
+	}
+	if m.param.LendingRate == 0 {
+		m.param.LendingRate = defaultLendingRate
+	}
+	if m.param.DayCount == 0 {
+		m.param.DayCount = defaultDayCount
+	}
+	if m.param.MarginRatio == 0 {
+		m.param.MarginRatio = defaultMarginRatio
+	}
+	if m.param.Haircut == 0 {
+		m.param.Haircut = defaultHaircut
+	}
+	if m.param.LiquidationRatio == 0 {
+		m.param.LiquidationRatio = defaultLiquidationRatio
+	}
+
+	m.borrowable = make(map[string]struct{}, len(m.param.Borrowable))
+	for _, instID := range m.param.Borrowable {
+		m.borrowable[instID] = struct{}{}
+	}
+
+	m.accountID = config.AccountTypeMarginStock
+	m.Asset.Initial = m.param.Cash
+	m.cash = m.param.Cash
+
+	m.Position = make(map[string]*position.MarginPosition)
+	m.cond = newConditionalBook(m, m.Account())
+	m.resetOrders()
+	m.refresh()
+
+	// 实盘账户，需要加载交易通道
+	if m.Config().Framework.Realtime && m.param.Account.Account != nil {
+		m.cashAccount = m.param.Account.Account
+		m.tunnel = setting.MustNewTunnelHandler(
+			config.HandlerType(m.cashAccount.Tunnel),
+			tunnel.TradeOnly(),
+			tunnel.WithConfig(m.Config()),
+			tunnel.WithTunnelUrl(m.cashAccount.URL),
+		)
+	}
+
+	return nil
+}
+
+func (m *MarginStock) resetOrders() {
+	m.creditFrozen = 0
+	m.orderFrozen = make(map[int64]float64)
+	m.orderCredit = make(map[int64]float64)
+	m.financing = make(map[int64]bool)
+	m.orders = make(map[int64]handler.Order)
+	m.inst2open = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
+	m.inst2close = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
+}
+
+// marketValue 多头和空头市值
+func (m *MarginStock) marketValue() (long, short float64) {
+	for _, p := range m.Position {
+		long += p.Amt(account.WithDirection(config.PositionLong))
+		short += p.Amt(account.WithDirection(config.PositionShort))
+	}
+
+	return
+}
+
+// refresh 根据持仓和负债重新计算账户资产
+func (m *MarginStock) refresh() {
+	long, short := m.marketValue()
+
+	m.Asset.MarketValue = long
+	m.Asset.Margin = m.debt + m.interest + short
+	m.Asset.Total = m.cash + long - m.Asset.Margin
+	m.Asset.Available = m.cash - m.shortCash - m.Asset.Frozen
+	m.PnL.Profit = m.Asset.Total - m.Asset.Initial
+}
+
+// Liability 负债总额
+func (m *MarginStock) Liability() float64 {
+	return m.Asset.Margin
+}
+
+// MaintenanceRatio 维持担保比例
+func (m *MarginStock) MaintenanceRatio() float64 {
+	if m.Asset.Margin <= 0 {
+		return math.Inf(1)
+	}
+
+	return (m.cash + m.Asset.MarketValue) / m.Asset.Margin
+}
+
+// AvailableMargin 保证金可用余额 = 可用资金 + 担保证券折算价值 - 负债占用保证金 - 未还利息 - 挂单占用保证金
+func (m *MarginStock) AvailableMargin() float64 {
+	collateral := m.Asset.Available
+	short := 0.0
+	for instID, p := range m.Position {
+		collateral += p.Amt(account.WithDirection(config.PositionLong)) * m.haircut(instID)
+		short += p.Amt(account.WithDirection(config.PositionShort))
+	}
+
+	return collateral - (m.debt+short)*m.param.MarginRatio - m.interest - m.creditFrozen
+}
+
+func (m *MarginStock) haircut(instID string) float64 {
+	if v, ok := m.param.Haircuts[instID]; ok {
+		return v
+	}
+
+	return m.param.Haircut
+}
+
+func (m *MarginStock) Release() {}
+
+func (m *MarginStock) GetType() string {
+	return config.AccountTypeMarginStock
+}
+
+func (m *MarginStock) AccountID() string {
+	return m.accountID
+}
+
+func (m *MarginStock) GetAsset() account.Asset {
+	return m.Asset
+}
+
+func (m *MarginStock) GetPnL() account.PnL {
+	return m.PnL
+}
+
+func (m *MarginStock) GetPosition() (pos map[string]account.Position) {
+	pos = make(map[string]account.Position)
+	for instID, p := range m.Position {
+		pos[instID] = p
+	}
+
+	return
+}
+
+func (m *MarginStock) GetPositionByInstID(instID string) (account.Position, bool) {
+	p, ok := m.Position[instID]
+	return p, ok
+}
+
+func (m *MarginStock) GetPosInstIDs() []string {
+	var instIDs []string
+	for instID := range m.Position {
+		instIDs = append(instIDs, instID)
+	}
+
+	return instIDs
+}
+
+func (m *MarginStock) GetOrders(instID string) []account.Order {
+	var orders []account.Order
+	for _, o := range []map[string]*orderedmap.OrderedMap[int64, handler.Order]{m.inst2close, m.inst2open} {
+		if v, ok := o[instID]; ok {
+			for pOrder := v.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+				orders = append(orders, pOrder.Value)
+			}
+		}
+	}
+
+	return append(orders, m.cond.orders(instID)...)
+}
+
+func (m *MarginStock) GenOrderId() int64 {
+	return m.Account().(*DefaultHandler).GenOrderId()
+}
+
+// AddAvailable 出入金
+func (m *MarginStock) AddAvailable(available float64) {
+	m.cash += available
+	m.Asset.Initial += available
+	m.refresh()
+}
+
+// AddDividend 分红计入现金，税计入手续费
+func (m *MarginStock) AddDividend(dividend, tax float64) {
+	m.cash += dividend - tax
+	m.Asset.Commission += tax
+	m.refresh()
+}
+
+// AddDynamicPnL 盈亏由持仓市值计算，这里只刷新账户
+func (m *MarginStock) AddDynamicPnL(pnl ...float64) {
+	m.refresh()
+}
+
+// CalcSettleInfo 按当日的除权除息调整持仓，qty为负表示融券持仓，应付的分红为负且不计税
+// 融资融券持仓不支持换股
+func (m *MarginStock) CalcSettleInfo(
+	instID string, qty, price, lastPrice float64,
+) (settleQty, settlePrice, settleLastPrice, dividend, tax float64) {
+	xrxd, ok := m.exRights[instID]
+	if !ok {
+		return qty, price, lastPrice, 0, 0
+	}
+
+	settleQty, settlePrice, settleLastPrice, dividend, tax, settleInstID := xrxd.CalcPos(qty, price, lastPrice)
+	if settleInstID != "" {
+		config.WarnF("融资融券持仓不支持换股: %s -> %s", instID, settleInstID)
+		return qty, price, lastPrice, 0, 0
+	}
+
+	return
+}
+
+func (m *MarginStock) CalcPositionPnL(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) {
+	for instID, p := range m.Position {
+		if v, ok := indicators.Get(instID); ok {
+			p.CalcPnL(tm, v.ConvertToFloat("Close"))
+		}
+	}
+
+	m.refresh()
+}
+
+func (m *MarginStock) NewOrder(instId string, qty float64, options ...account.WithOrderOption) (account.Order, error) {
+	if qty == 0 {
+		return nil, qk.ErrInsufficientOrderQty{}
+	}
+
+	op := account.NewOrderOp(options...)
+
+	if op.Account == nil {
+		op.Account = m
+	}
+
+	if op.OrderTime == nil {
+		op.OrderTime = m.Account().GetCurrTime()
+	}
+
+	if op.OrderPrice == 0 && op.OrderType == config.OrderTypeLimit {
+		return nil, qk.ErrInsufficientOrderPriceLimit{}
+	}
+
+	// 未指定开平标志时与普通股票一致: 买入开仓，卖出平仓
+	if op.Transaction == "" {
+		op.Transaction = config.OffsetOpen
+		if op.OrderDirection == config.OrderSell {
+			op.Transaction = config.OffsetClose
+		}
+	}
+
+	o := order.NewCreditOrder(m.GenOrderId(), m.Contract().GetContract(instId), qty, op)
+	if op.Financing {
+		m.financing[o.ID()] = true
+	}
+
+	return o, nil
+}
+
+func (m *MarginStock) InsertOrder(o account.Order, options ...account.WithOrderOption) error {
+	// 未触发的条件单由条件单簿保存
+	if m.cond.hold(o, options) {
+		return nil
+	}
+
+	orderOp := o.(handler.Order)
+	op := account.NewOrderOp(options...)
+	m.orders[o.ID()] = orderOp
+	if op.Financing {
+		m.financing[o.ID()] = true
+	}
+
+	if err := preTrade(m.Account(), orderOp, op); err != nil {
+		return err
+	}
+
+	if err := m.checkOrder(o, op); err != nil {
+		return reject(m.Account(), orderOp, err)
+	}
+
+	inst2orders := m.inst2open
+	if o.TransactionType() != config.OffsetOpen {
+		inst2orders = m.inst2close
+	}
+
+	if inst2orders[o.InstID()] == nil {
+		inst2orders[o.InstID()] = orderedmap.New[int64, handler.Order]()
+	}
+	inst2orders[o.InstID()].Set(o.ID(), orderOp)
+
+	m.DoOrderUpdate(orderOp)
+
+	if m.Config().Framework.Realtime && !op.Resumed {
+		_, err := m.DoRTInsert(orderOp.(tunnel.Order))
+		if err != nil {
+			config.WarnF("实盘下单失败: %+v\n", err)
+		} else {
+			config.InfoF("实盘下单成功: %+v\n", orderOp)
+		}
+	}
+
+	return nil
+}
+
+// checkOrder 下单检查: 融资融券额度、可融券名单、可平持仓以及资金
+func (m *MarginStock) checkOrder(o account.Order, op *account.OrderOp) error {
+	if op.Resumed {
+		return nil
+	}
+
+	amt := o.OrderAmt()
+	switch {
+	case o.TransactionType() == config.OffsetOpen && o.OrderDirection() == config.OrderSell:
+		if _, ok := m.borrowable[o.InstID()]; !ok {
+			return qk.ErrNotBorrowable{InstID: o.InstID()}
+		}
+		fallthrough
+	case m.financing[o.ID()]:
+		if have := m.AvailableMargin(); have < amt*m.param.MarginRatio {
+			return qk.ErrInsufficientMargin{Need: amt * m.param.MarginRatio, Have: have}
+		}
+	case o.TransactionType() != config.OffsetOpen:
+		have := 0.0
+		if pos, ok := m.Position[o.InstID()]; ok {
+			have = pos.Closable(o.PositionDirection())
+		}
+
+		if have < o.OrderQty() {
+			return qk.ErrInsufficientPosition{Need: o.OrderQty(), Have: have}
+		}
+	}
+
+	// 担保品买入和买券还券需要现金，买券还券可以使用融券卖出所得
+	if op.CheckCash && o.OrderDirection() == config.OrderBuy && !m.financing[o.ID()] {
+		have := m.Asset.Available
+		if o.TransactionType() != config.OffsetOpen {
+			have += m.shortCash
+		}
+
+		if need := amt + o.CommissionFrozen(); have < need {
+			return qk.ErrInsufficientCash{Need: need, Have: have}
+		}
+	}
+
+	return nil
+}
+
+func (m *MarginStock) CancelOrder(tm time.Time, id int64) {
+	if m.cond.cancel(tm, id) {
+		return
+	}
+
+	o, ok := m.orders[id]
+	if !ok {
+		config.WarnF("撤单失败, 未找到订单: %d", id)
+		return
+	}
+
+	o.DoCancelUpdate(tm)
+
+	if orders, ok := m.inst2open[o.InstID()]; ok {
+		orders.Delete(id)
+	}
+
+	if orders, ok := m.inst2close[o.InstID()]; ok {
+		orders.Delete(id)
+	}
+}
+
+// releaseFrozen 按比例释放订单冻结的资金和保证金
+func (m *MarginStock) releaseFrozen(id int64, ratio float64) {
+	if frozen, ok := m.orderFrozen[id]; ok {
+		m.Asset.Frozen -= frozen * min(ratio, 1)
+		m.orderFrozen[id] = frozen * (1 - min(ratio, 1))
+	}
+
+	if credit, ok := m.orderCredit[id]; ok {
+		m.creditFrozen -= credit * min(ratio, 1)
+		m.orderCredit[id] = credit * (1 - min(ratio, 1))
+	}
+
+	if ratio >= 1 {
+		delete(m.orderFrozen, id)
+		delete(m.orderCredit, id)
+	}
+}
+
+func (m *MarginStock) DoOrderUpdate(order handler.Order) {
+	notifyStatus(m.Account(), order)
+
+	switch order.OrderStatus() {
+	case config.OrderStatusNew:
+		// 担保品买入和买券还券冻结资金，融资买入和融券卖出占用保证金，所有订单冻结手续费
+		frozen := order.CommissionFrozen()
+		if order.OrderDirection() == config.OrderBuy && !m.financing[order.ID()] {
+			frozen += order.OrderAmt()
+		}
+
+		if m.financing[order.ID()] ||
+			(order.OrderDirection() == config.OrderSell && order.TransactionType() == config.OffsetOpen) {
+			credit := order.OrderAmt() * m.param.MarginRatio
+			m.orderCredit[order.ID()] = credit
+			m.creditFrozen += credit
+		}
+
+		m.orderFrozen[order.ID()] = frozen
+		m.Asset.Frozen += frozen
+	case config.OrderStatusCanceled, config.OrderStatusPartDonePartCancel,
+		config.OrderStatusDone, config.OrderStatusExpired, config.OrderStatusRejected:
+		m.releaseFrozen(order.ID(), 1)
+	}
+
+	if pos, ok := m.Position[order.InstID()]; ok {
+		pos.DoOrderUpdate(order)
+	}
+
+	m.refresh()
+}
+
+func (m *MarginStock) DoTradeUpdate(qty, price float64, order handler.Order) {
+	// 按成交比例释放冻结资金, 此时订单成交数量已经包含本次成交
+	if remain := order.OrderQty() - order.TradeQty() + qty; remain > 0 {
+		m.releaseFrozen(order.ID(), qty/remain)
+	}
+
+	pos, ok := m.Position[order.InstID()]
+	if !ok {
+		pos = position.NewMarginPosition(m, order.Contract(), price)
+		m.Position[order.InstID()] = pos
+	}
+
+	pos.TradeSplit(qty, price, order)
+
+	// 手续费按累计成交计算，保证最低佣金只收取一次
+	commission := order.Contract().CalcComm(order.OrderTime(), order.TradeQty(), order.TradePrice(), order.OrderDirection())
+	delta := commission - order.Commission()
+	order.ModifyOrder("commission", commission)
+	m.cash -= delta
+	m.Asset.Commission += delta
+
+	amt := qty * price
+	switch {
+	case order.OrderDirection() == config.OrderBuy && order.TransactionType() == config.OffsetOpen:
+		// 融资买入增加负债，担保品买入使用现金
+		if m.financing[order.ID()] {
+			m.debt += amt
+		} else {
+			m.cash -= amt
+		}
+	case order.OrderDirection() == config.OrderSell && order.TransactionType() != config.OffsetOpen:
+		// 卖券还款，先还利息再还融资负债
+		m.cash += amt
+		m.repay(amt)
+	case order.OrderDirection() == config.OrderSell:
+		// 融券卖出所得资金锁定
+		m.cash += amt
+		m.shortCash += amt
+	default:
+		// 买券还券，优先使用融券卖出所得
+		m.cash -= amt
+		m.shortCash = max(m.shortCash-amt, 0)
+		if _, short := m.marketValue(); short == 0 {
+			m.shortCash = 0
+		}
+	}
+
+	m.refresh()
+	notifyTrade(m.Account(), order, price, qty, delta)
+}
+
+// repay 使用现金偿还利息和融资负债，最多偿还amt
+func (m *MarginStock) repay(amt float64) {
+	amt = min(amt, m.cash-m.shortCash)
+	if amt <= 0 {
+		return
+	}
+
+	interest := min(amt, m.interest)
+	m.interest -= interest
+	principal := min(amt-interest, m.debt)
+	m.debt -= principal
+	m.cash -= interest + principal
+}
+
+func (m *MarginStock) DoMatch(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord], matcher handler.Matcher,
+) {
+	// 先平后开，卖券还款和买券还券释放的额度可以用于开仓
+	for pInst := indicators.Oldest(); pInst != nil; pInst = pInst.Next() {
+		m.cond.trigger(tm, pInst.Key, pInst.Value)
+		for _, o := range []map[string]*orderedmap.OrderedMap[int64, handler.Order]{m.inst2close, m.inst2open} {
+			if orders := o[pInst.Key]; orders != nil {
+				for pOrder := orders.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
+					matcher.MatchOrder(pOrder.Value, pInst.Value, tm)
+				}
+			}
+		}
+	}
+
+	m.cond.afterMatch(tm)
+}
+
+// accrue 按自然日计提融资利息和融券费用
+func (m *MarginStock) accrue(tm time.Time) {
+	days := 1.0
+	if !m.lastSettle.IsZero() {
+		days = max(math.Round(tm.Sub(m.lastSettle).Hours()/24), 1)
+	}
+	m.lastSettle = tm
+
+	_, short := m.marketValue()
+	m.interest += (m.debt*m.param.FinancingRate + short*m.param.LendingRate) * days / m.param.DayCount
+}
+
+// liquidate 强制平仓: 按结算价买券还券，再卖出多头偿还融资负债和利息
+func (m *MarginStock) liquidate(
+	tm time.Time, indicators *orderedmap.OrderedMap[string, dataframe.StreamingRecord], r recorder.Handler,
+) {
+	config.WarnF(
+		"%s 账户%s维持担保比例 %.4f 低于平仓线 %.4f, 强制平仓",
+		tm.Format(config.TimeFormatDate2), m.accountID, m.MaintenanceRatio(), m.param.LiquidationRatio,
+	)
+
+	instIDs := m.GetPosInstIDs()
+	sort.Strings(instIDs)
+
+	for _, instID := range instIDs {
+		if qty := m.Position[instID].Volume(account.WithDirection(config.PositionShort)); qty > 0 {
+			m.forceTrade(tm, instID, qty, getSettlePrice(instID, indicators), config.OrderBuy, r)
+		}
+	}
+
+	for _, instID := range instIDs {
+		need := m.debt + m.interest - (m.cash - m.shortCash)
+		if need <= 0 {
+			break
+		}
+
+		price := getSettlePrice(instID, indicators)
+		if price == 0 {
+			price = m.Position[instID].OpenPrice(account.WithDirection(config.PositionLong))
+		}
+
+		// 卖出数量需要覆盖卖出手续费
+		c := m.Contract().GetContract(instID)
+		qty := math.Ceil(need / price)
+		qty = math.Ceil((need + c.CalcComm(tm, qty, price, config.OrderSell)) / price)
+		if qty = min(m.Position[instID].Volume(account.WithDirection(config.PositionLong)), qty); qty > 0 {
+			m.forceTrade(tm, instID, qty, price, config.OrderSell, r)
+		}
+	}
+
+	m.repay(m.debt + m.interest)
+}
+
+// forceTrade 强平订单不经过撮合，直接按价格成交
+func (m *MarginStock) forceTrade(
+	tm time.Time, instID string, qty, price float64, direction config.OrderDirection, r recorder.Handler,
+) {
+	if price == 0 {
+		price = m.Position[instID].OpenPrice()
+	}
+
+	o := order.NewCreditOrder(
+		m.GenOrderId(), m.Contract().GetContract(instID), qty, &account.OrderOp{
+			OrderTime:      &tm,
+			OrderPrice:     price,
+			OrderDirection: direction,
+			OrderType:      config.OrderTypeMarket,
+			Transaction:    config.OffsetClose,
+			Account:        m,
+		},
+	)
+
+	o.DoTradeUpdate(price, qty, tm, r)
+}
+
+func (m *MarginStock) DoSettle(
+	tm time.Time, indicators *orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+	recorder map[config.RecordType]recorder.Handler,
+) {
+	// 处理订单, 未成交订单过期
+	for _, o := range m.orders {
+		status := o.OrderStatus()
+		o.DoSettle(tm, recorder[config.RecordTypeOrder])
+		if o.OrderStatus() != status {
+			notifyStatus(m.Account(), o)
+		}
+	}
+	m.cond.settle(tm, recorder[config.RecordTypeOrder])
+
+	// 冻结资金和保证金全部释放
+	m.Asset.Frozen = 0
+	m.resetOrders()
+
+	for instID, p := range m.Position {
+		p.CalcPnL(tm, getSettlePrice(instID, indicators))
+	}
+
+	m.accrue(tm)
+	m.refresh()
+	liquidate := m.MaintenanceRatio() < m.param.LiquidationRatio
+
+	// 除权除息在持仓结算时按多空两个方向分别调整
+	m.exRights = make(map[string]*config.Xrxd)
+	if m.Base() != nil {
+		for instID := range m.Position {
+			if xrxd, ok := m.xrxd.Get(instID, tm); ok {
+				m.exRights[instID] = xrxd
+				m.Account().AdjustRoundTrip(m.accountID, instID, xrxd)
+			}
+		}
+	}
+
+	// 持仓结算，今仓转昨仓
+	for instID, p := range m.Position {
+		if !p.DoSettle(
+			tm, getSettlePrice(instID, indicators), recorder[config.RecordTypePosition],
+		) {
+			delete(m.Position, instID)
+		}
+	}
+	m.xrxd.DoSettle(tm)
+
+	// 强制平仓在今仓转为昨仓之后进行，当日买入的持仓同样受T+N限制
+	if liquidate {
+		m.liquidate(tm, indicators, recorder[config.RecordTypeOrder])
+		for instID, p := range m.Position {
+			if p.Volume() == 0 {
+				delete(m.Position, instID)
+			}
+		}
+	}
+	m.refresh()
+
+	if r := recorder[config.RecordTypeAsset]; r != nil {
+		record := MakeRecord(tm, m.accountID, m.Asset, m.PnL)
+		r.GetChannel() <- &record
+	}
+}
+
+func (m *MarginStock) DoResume(
+	assets []recorder.AssetRecord,
+	positions []recorder.PositionRecord,
+	orders []recorder.OrderRecord,
+	trades []recorder.TradeRecord,
+) {
+	// 恢复持仓，多空两个方向的记录合并到同一持仓
+	for _, record := range positions {
+		if record.Account != m.accountID {
+			continue
+		}
+
+		contractInfo := m.Contract().GetContract(record.InstID)
+		pos, ok := m.Position[record.InstID]
+		if !ok {
+			pos = position.NewMarginPosition(m, contractInfo, record.LastPrice)
+			m.Position[record.InstID] = pos
+		}
+
+		pos.DoResume(m, contractInfo, record)
+	}
+
+	// 恢复资金，负债中扣除融券市值后都视为融资负债
+	_, short := m.marketValue()
+	for _, record := range assets {
+		if record.Account != m.accountID {
+			continue
+		}
+
+		m.Asset.Initial = m.param.Cash
+		m.Asset.Commission = record.Commission
+		m.cash = record.TotalAsset + record.Margin - record.MarketValue
+		m.debt = max(record.Margin-short, 0)
+		m.interest = 0
+	}
+
+	m.refresh()
+
+	// 恢复订单，逐笔重放成交
+	fills := groupTrades(trades, m.accountID)
+	for _, record := range orders {
+		if record.Account != m.accountID {
+			continue
+		}
+
+		tm, err := time.Parse(config.TimeFormatDate2+" "+config.TimeFormatTime2, record.OrderDate+" "+record.OrderTime)
+		if err != nil {
+			config.ErrorF("恢复订单失败: %+v\n", record)
+			return
+		}
+
+		orderType := config.OrderType(config.OrderTypeLimit)
+		if record.OrderPrice == 0.0 {
+			orderType = config.OrderTypeMarket
+		}
+
+		o := order.NewCreditOrder(
+			record.OrderId,
+			m.Contract().GetContract(record.InstId), record.OrderQty, &account.OrderOp{
+				OrderTime:      &tm,
+				OrderPrice:     record.OrderPrice,
+				OrderDirection: config.OrderDirection(record.OrderDirection),
+				OrderType:      orderType,
+				Transaction:    config.TransactionType(record.TransactionType),
+				Account:        m,
+			},
+		)
+
+		_ = m.InsertOrder(o, ResumeOrder())
+		o.DoResume(&record, fills[record.OrderId]...)
+	}
+
+	config.InfoF("上场账户%s资金: %+v\n", m.accountID, m.Asset)
+}
+
+func init() {
+	setting.RegisterAccount(&MarginStock{}, config.AccountTypeMarginStock)
+}
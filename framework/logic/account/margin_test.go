@@ -0,0 +1,163 @@
+package account
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	_ "github.com/wonderstone/QuantKit/framework/logic/contract"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/idgen"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+const testInstID = "000001.XSHE.CS"
+
+// testFramework 只提供当前时间和合约
+type testFramework struct {
+	setting.ReplayFramework
+	tm       time.Time
+	contract handler.Contract
+}
+
+func (f *testFramework) CurrTime() *time.Time {
+	return &f.tm
+}
+
+func (f *testFramework) Contract() handler.Contract {
+	return f.contract
+}
+
+func newTestMarginStock(t *testing.T, conf config.MarginStock) (*MarginStock, *testFramework) {
+	prop, err := config.NewContractPropertyConfig("../position/contract.yaml")
+	require.NoError(t, err)
+
+	handle, err := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(prop))
+	require.NoError(t, err)
+
+	f := &testFramework{tm: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), contract: handle}
+	d := NewDefaultHandler()
+	d.framework = f
+	d.ai = idgen.New(1, 1)
+	d.Resource = setting.NewResource(setting.WithRuntimeConfig(&config.Runtime{}), setting.WithAccountHandler(d))
+
+	m := &MarginStock{}
+	require.NoError(t, m.Init(d, conf))
+
+	return m, f
+}
+
+func fill(t *testing.T, m *MarginStock, f *testFramework, qty, price float64, options ...account.WithOrderOption) error {
+	o, err := m.NewOrder(testInstID, qty, append(options, account.WithOrderPrice(price))...)
+	require.NoError(t, err)
+
+	if err := m.InsertOrder(o, account.WithCheckCash(true)); err != nil {
+		return err
+	}
+
+	o.(handler.Order).DoTradeUpdate(price, qty, f.tm, nil)
+	return nil
+}
+
+func closeQuote(price float64) *orderedmap.OrderedMap[string, dataframe.StreamingRecord] {
+	quotes := orderedmap.New[string, dataframe.StreamingRecord]()
+	quotes.Set(testInstID, dataframe.NewStreamingRecord([]string{"", ""}, map[string]int{"Close": 0, "Settle": 1}))
+	quotes.Value(testInstID).Set("Close", price)
+
+	return quotes
+}
+
+// 测试融资买入、融券卖出、T+1、计息以及负债记录
+func TestMarginStock(t *testing.T) {
+	m, f := newTestMarginStock(
+		t, config.MarginStock{Account: config.Account{Cash: 100000}, Borrowable: []string{testInstID}},
+	)
+
+	// 融资买入1000股
+	require.NoError(t, fill(t, m, f, 1000, 10, account.WithFinancing()))
+	require.InDelta(t, 10000.0, m.debt, 1e-6)
+	require.InDelta(t, 10000.0, m.Asset.MarketValue, 1e-6)
+	require.Less(t, m.cash, 100000.0)
+
+	// 当日买入的股票不能卖出
+	err := fill(t, m, f, 500, 10, account.WithOrderDirection(config.OrderSell))
+	require.ErrorAs(t, err, &qk.ErrInsufficientPosition{})
+
+	// 融券卖出500股, 所得资金锁定
+	require.NoError(t, fill(t, m, f, 500, 10, account.WithShortSell()))
+	require.InDelta(t, 5000.0, m.shortCash, 1e-6)
+	require.InDelta(t, 15000.0, m.Liability(), 1e-6)
+
+	// 不在可融券名单中
+	m.borrowable = map[string]struct{}{}
+	err = fill(t, m, f, 100, 10, account.WithShortSell())
+	require.ErrorAs(t, err, &qk.ErrNotBorrowable{})
+
+	// 日终计提一天利息
+	m.DoSettle(f.tm, closeQuote(10), nil)
+	interest := (10000*defaultFinancingRate + 5000*defaultLendingRate) / defaultDayCount
+	require.InDelta(t, interest, m.interest, 1e-6)
+	require.InDelta(t, m.cash+m.Asset.MarketValue-m.Liability(), m.Asset.Total, 1e-6)
+
+	// 次日卖券还款, 先还利息再还融资负债
+	f.tm = f.tm.AddDate(0, 0, 1)
+	require.NoError(t, fill(t, m, f, 500, 10, account.WithOrderDirection(config.OrderSell)))
+	require.InDelta(t, 0.0, m.interest, 1e-6)
+	require.InDelta(t, 10000-(5000-interest), m.debt, 1e-6)
+
+	// 买券还券, 空头清零后解锁融券所得资金
+	require.NoError(t, fill(t, m, f, 500, 10, account.WithTransactionType(config.OffsetClose)))
+	require.Equal(t, 0.0, m.Position[testInstID].Volume(account.WithDirection(config.PositionShort)))
+	require.Equal(t, 0.0, m.shortCash)
+}
+
+// 测试维持担保比例低于平仓线时强制平仓
+func TestMarginStockLiquidation(t *testing.T) {
+	m, f := newTestMarginStock(t, config.MarginStock{Account: config.Account{Cash: 10000}})
+
+	// 担保品买入后再融资买入, 超出保证金可用余额的融资被拒绝
+	require.NoError(t, fill(t, m, f, 900, 10))
+	err := fill(t, m, f, 900, 10, account.WithFinancing())
+	require.ErrorAs(t, err, &qk.ErrInsufficientMargin{})
+	require.NoError(t, fill(t, m, f, 600, 10, account.WithFinancing()))
+	require.Greater(t, m.MaintenanceRatio(), defaultLiquidationRatio)
+
+	// 价格下跌到4元, 维持担保比例低于平仓线
+	m.DoSettle(f.tm, closeQuote(4), nil)
+	require.InDelta(t, 0.0, m.Liability(), 1e-6)
+	require.True(t, math.IsInf(m.MaintenanceRatio(), 1))
+	require.Less(t, m.Position[testInstID].Volume(), 1500.0)
+	require.Greater(t, m.Position[testInstID].Volume(), 0.0)
+}
+
+// 测试除权除息: 多头获得送股和分红(扣税)，融券持仓同样送股并从现金扣除应付的分红
+func TestMarginStockXrxd(t *testing.T) {
+	m, f := newTestMarginStock(
+		t, config.MarginStock{Account: config.Account{Cash: 100000}, Borrowable: []string{testInstID}},
+	)
+
+	require.NoError(t, fill(t, m, f, 1000, 10))
+	require.NoError(t, fill(t, m, f, 500, 10, account.WithShortSell()))
+	m.refresh()
+	cash, total := m.cash, m.Asset.Total
+
+	// 10送10派5元
+	m.exRights[testInstID] = &config.Xrxd{DivCash: 0.5, DivShare: 1}
+	require.True(t, m.Position[testInstID].DoSettle(f.tm, 10, nil))
+
+	p := m.Position[testInstID]
+	require.Equal(t, 2000.0, p.Volume(account.WithDirection(config.PositionLong)))
+	require.Equal(t, 1000.0, p.Volume(account.WithDirection(config.PositionShort)))
+	require.InDelta(t, 4.75, p.OpenPrice(account.WithDirection(config.PositionShort)), 1e-9)
+
+	// 多头分红500扣税100，融券应付分红250
+	require.InDelta(t, cash+500-100-250, m.cash, 1e-6)
+	require.InDelta(t, total-100, m.Asset.Total, 1e-6)
+}
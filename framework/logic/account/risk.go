@@ -0,0 +1,356 @@
+package account
+
+import (
+	"math"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// + riskControl 下单前风控，所有账户共用，账户插入订单时在资金、持仓检查之前调用
+// + 单笔委托金额和数量、每分钟委托笔数、价格笼子和禁止交易名单检查全部订单
+// + 标的持仓、账户持仓、总敞口和当日亏损只检查开仓订单，平仓订单不受限制
+// + 持仓市值按最新价计算，并计入尚未成交的开仓委托；市价单按最新价估值
+// + 熔断(Kill)后撤销全部委托、按市价平掉可平持仓并停止策略，此后只允许熔断平仓的订单
+// + 熔断当日因T+1等原因未能平掉的持仓，在之后每个交易日开盘时继续平仓
+
+type riskControl struct {
+	d     *DefaultHandler
+	param config.Risk
+
+	restricted map[string]struct{}
+	last       map[string]float64  // 标的最新价
+	instIDs    map[string]struct{} // 下过单的标的，熔断时撤单使用
+	orderTimes []time.Time         // 最近一分钟内的委托时间
+	date       time.Time           // 当前交易日
+	dayStart   float64             // 日初总权益
+
+	halted     bool   // 已熔断
+	reason     string // 熔断原因
+	flattening bool   // 正在熔断平仓，不做风控检查
+}
+
+func newRiskControl(d *DefaultHandler, param config.Risk) *riskControl {
+	r := &riskControl{
+		d:          d,
+		param:      param,
+		restricted: make(map[string]struct{}, len(param.Restricted)),
+		last:       make(map[string]float64),
+		instIDs:    make(map[string]struct{}),
+	}
+
+	for _, instID := range param.Restricted {
+		r.restricted[instID] = struct{}{}
+	}
+
+	return r
+}
+
+// equity 全部账户的总权益
+func (r *riskControl) equity() float64 {
+	total := 0.0
+	for _, acc := range r.d.accounts {
+		total += acc.GetAsset().Total
+	}
+
+	return total
+}
+
+// dailyLoss 当日亏损金额，盈利时为负
+func (r *riskControl) dailyLoss() float64 {
+	if r.date.IsZero() {
+		return 0
+	}
+
+	return r.dayStart - r.equity()
+}
+
+// observe 撮合前记录最新价，交易日切换时记录日初权益，已熔断时继续平仓
+func (r *riskControl) observe(tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord]) {
+	date := time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, tm.Location())
+	if !date.Equal(r.date) {
+		r.date = date
+		r.dayStart = r.equity()
+
+		if r.halted {
+			defer r.flatten(tm)
+		}
+	}
+
+	for pair := indicators.Oldest(); pair != nil; pair = pair.Next() {
+		if price, ok := pair.Value.Get("Close"); ok && price > 0 {
+			r.last[pair.Key] = price
+		}
+	}
+}
+
+// watch 计算持仓盈亏后检查当日亏损，配置了KillOnDailyLoss时触及上限即熔断
+func (r *riskControl) watch() {
+	if r.halted || !r.param.KillOnDailyLoss || r.param.MaxDailyLoss <= 0 {
+		return
+	}
+
+	if loss := r.dailyLoss(); loss >= r.param.MaxDailyLoss {
+		r.d.Kill(qk.ErrRiskLimit{Rule: "当日亏损", Value: loss, Limit: r.param.MaxDailyLoss}.Error())
+	}
+}
+
+// price 订单估值价格: 市价单或未指定价格时使用最新价
+func (r *riskControl) price(o account.Order) float64 {
+	if o.OrderType() != config.OrderTypeMarket && o.OrderPrice() > 0 {
+		return o.OrderPrice()
+	}
+
+	if last, ok := r.last[o.InstID()]; ok {
+		return last
+	}
+
+	return o.OrderPrice()
+}
+
+// exposure 账户按最新价计算的持仓市值，instID不为空时只计算该标的
+func exposure(acc account.Account, instID string) float64 {
+	instIDs := acc.GetPosInstIDs()
+	if instID != "" {
+		instIDs = []string{instID}
+	}
+
+	amt := 0.0
+	for _, id := range instIDs {
+		if pos, ok := acc.GetPositionByInstID(id); ok {
+			amt += pos.Amt()
+		}
+	}
+
+	return amt
+}
+
+// pending 尚未成交的开仓委托金额
+func (r *riskControl) pending(acc account.Account, instID string) float64 {
+	instIDs := []string{instID}
+	if instID == "" {
+		instIDs = make([]string, 0, len(r.instIDs))
+		for id := range r.instIDs {
+			instIDs = append(instIDs, id)
+		}
+	}
+
+	amt := 0.0
+	for _, id := range instIDs {
+		for _, o := range acc.GetOrders(id) {
+			if o.TransactionType() != config.OffsetOpen || !working(o) {
+				continue
+			}
+
+			if c, ok := o.(handler.ConditionalOrder); ok && c.TriggerState() == config.TriggerPending {
+				continue
+			}
+
+			amt += o.Contract().CalcMarketValue(o.OrderQty()-o.TradeQty(), r.price(o), o.PositionDirection())
+		}
+	}
+
+	return amt
+}
+
+// working 订单仍在撮合中
+func working(o account.Order) bool {
+	status := o.OrderStatus()
+	return status == config.OrderStatusNew || status == config.OrderStatusPartDone
+}
+
+// check 检查订单，未通过时返回原因
+func (r *riskControl) check(o account.Order) error {
+	if r.flattening {
+		return nil
+	}
+
+	if r.halted {
+		return qk.ErrHalted{Reason: r.reason}
+	}
+
+	if _, ok := r.restricted[o.InstID()]; ok {
+		return qk.ErrRestricted{InstID: o.InstID()}
+	}
+
+	if r.param.MaxOrderQty > 0 && o.OrderQty() > r.param.MaxOrderQty {
+		return qk.ErrRiskLimit{Rule: "单笔委托数量", Value: o.OrderQty(), Limit: r.param.MaxOrderQty}
+	}
+
+	price := r.price(o)
+	amt := o.Contract().CalcMarketValue(o.OrderQty(), price, o.PositionDirection())
+	if r.param.MaxOrderAmt > 0 && amt > r.param.MaxOrderAmt {
+		return qk.ErrRiskLimit{Rule: "单笔委托金额", Value: amt, Limit: r.param.MaxOrderAmt}
+	}
+
+	if last, ok := r.last[o.InstID()]; ok && r.param.PriceCollar > 0 && o.OrderType() != config.OrderTypeMarket {
+		if deviation := math.Abs(price/last - 1); deviation > r.param.PriceCollar {
+			return qk.ErrRiskLimit{Rule: "委托价偏离最新价", Value: deviation, Limit: r.param.PriceCollar}
+		}
+	}
+
+	tm := *r.d.GetCurrTime()
+	if r.param.MaxOrdersPerMin > 0 {
+		i := 0
+		for i < len(r.orderTimes) && !r.orderTimes[i].After(tm.Add(-time.Minute)) {
+			i++
+		}
+		r.orderTimes = r.orderTimes[i:]
+
+		if len(r.orderTimes) >= r.param.MaxOrdersPerMin {
+			return qk.ErrRiskLimit{
+				Rule: "每分钟委托笔数", Value: float64(len(r.orderTimes) + 1), Limit: float64(r.param.MaxOrdersPerMin),
+			}
+		}
+	}
+
+	if o.TransactionType() == config.OffsetOpen {
+		if err := r.checkOpen(o, amt); err != nil {
+			return err
+		}
+	}
+
+	r.instIDs[o.InstID()] = struct{}{}
+	if r.param.MaxOrdersPerMin > 0 {
+		r.orderTimes = append(r.orderTimes, tm)
+	}
+
+	return nil
+}
+
+// checkOpen 开仓订单的持仓、敞口和当日亏损检查
+func (r *riskControl) checkOpen(o account.Order, amt float64) error {
+	if r.param.MaxDailyLoss > 0 {
+		if loss := r.dailyLoss(); loss >= r.param.MaxDailyLoss {
+			return qk.ErrRiskLimit{Rule: "当日亏损", Value: loss, Limit: r.param.MaxDailyLoss}
+		}
+	}
+
+	if r.param.MaxInstAmt > 0 {
+		total := amt
+		for _, acc := range r.d.accounts {
+			total += exposure(acc, o.InstID()) + r.pending(acc, o.InstID())
+		}
+
+		if total > r.param.MaxInstAmt {
+			return qk.ErrRiskLimit{Rule: "标的持仓市值", Value: total, Limit: r.param.MaxInstAmt}
+		}
+	}
+
+	if r.param.MaxAccountAmt > 0 {
+		total := amt + exposure(o.Account(), "") + r.pending(o.Account(), "")
+		if total > r.param.MaxAccountAmt {
+			return qk.ErrRiskLimit{Rule: "账户持仓市值", Value: total, Limit: r.param.MaxAccountAmt}
+		}
+	}
+
+	if r.param.MaxGrossExposure > 0 {
+		total := amt
+		for _, acc := range r.d.accounts {
+			total += exposure(acc, "") + r.pending(acc, "")
+		}
+
+		if total > r.param.MaxGrossExposure {
+			return qk.ErrRiskLimit{Rule: "总敞口", Value: total, Limit: r.param.MaxGrossExposure}
+		}
+	}
+
+	return nil
+}
+
+// flatten 撤销全部委托并按市价平掉可平持仓
+func (r *riskControl) flatten(tm time.Time) {
+	r.flattening = true
+	defer func() { r.flattening = false }()
+
+	for _, acc := range r.d.accounts {
+		instIDs := acc.GetPosInstIDs()
+		for instID := range r.instIDs {
+			instIDs = append(instIDs, instID)
+		}
+
+		canceled := make(map[int64]struct{})
+		for _, instID := range instIDs {
+			for _, o := range acc.GetOrders(instID) {
+				if _, ok := canceled[o.ID()]; ok || !working(o) {
+					continue
+				}
+
+				canceled[o.ID()] = struct{}{}
+				acc.CancelOrder(tm, o.ID())
+			}
+		}
+
+		for _, instID := range acc.GetPosInstIDs() {
+			pos, ok := acc.GetPositionByInstID(instID)
+			if !ok {
+				continue
+			}
+
+			for _, direction := range pos.AvailableDirection() {
+				qty := pos.Volume(account.WithDirection(direction), account.WithSellAvailable(true))
+				if qty <= 0 {
+					continue
+				}
+
+				orderDirection := config.OrderSell
+				if direction == config.PositionShort {
+					orderDirection = config.OrderBuy
+				}
+
+				o, err := acc.NewOrder(
+					instID, qty,
+					account.WithOrderType(config.OrderTypeMarket), account.WithOrderPrice(r.last[instID]),
+					account.WithOrderDirection(orderDirection), account.WithTransactionType(config.OffsetClose),
+				)
+				if err != nil {
+					config.WarnF("熔断平仓 %s 下单失败: %v", instID, err)
+					continue
+				}
+
+				if err := acc.InsertOrder(o); err != nil {
+					config.WarnF("熔断平仓 %s 下单失败: %v", instID, err)
+				}
+			}
+		}
+	}
+}
+
+// checkRule 按交易所规则检查委托数量
+func checkRule(o handler.Order) error {
+	r, ok := o.Contract().(contract.Rule)
+	if !ok {
+		return nil
+	}
+
+	holding := 0.0
+	if pos, ok := o.Account().GetPositionByInstID(o.InstID()); ok {
+		holding = pos.Volume(account.WithDirection(o.PositionDirection()))
+	}
+
+	return r.CheckQty(o.OrderQty(), holding, o.TransactionType())
+}
+
+// preTrade 账户插入订单时的交易规则和风控检查，未通过时拒绝订单，恢复的订单不检查
+func preTrade(acc handler.Account, o handler.Order, op *account.OrderOp) error {
+	if op.Resumed {
+		return nil
+	}
+
+	err := checkRule(o)
+	if err == nil {
+		err = acc.CheckRisk(o)
+	}
+
+	if err != nil {
+		return reject(acc, o, err)
+	}
+
+	return nil
+}
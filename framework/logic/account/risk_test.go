@@ -0,0 +1,106 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/matcher"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// Matcher 测试使用限价撮合
+func (f *testFramework) Matcher() handler.Matcher {
+	return new(matcher.LimitQuote).Init(handler.WithConfig(config.Framework{}))
+}
+
+func newTestRisk(t *testing.T, param config.Risk) (*StockSimple, *testFramework, *DefaultHandler) {
+	s, f := newTestStock(t)
+	d := s.Account().(*DefaultHandler)
+	d.accounts = map[string]handler.Account2{config.AccountTypeStockSimple: s}
+	d.risk = newRiskControl(d, param)
+
+	return s, f, d
+}
+
+func insert(s *StockSimple, qty float64, options ...account.WithOrderOption) (account.Order, error) {
+	o, err := s.NewOrder(testInstID, qty, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, s.InsertOrder(o)
+}
+
+// 测试下单前风控: 拒绝的订单状态为拒单，平仓订单不受持仓限制
+func TestRiskControl(t *testing.T) {
+	s, f, d := newTestRisk(t, config.Risk{
+		MaxOrderQty: 5000, PriceCollar: 0.05, MaxInstAmt: 30000, MaxOrdersPerMin: 3,
+	})
+	d.risk.observe(f.tm, bar(10, 10, 10, 10))
+
+	o, err := insert(s, 6000, account.WithOrderPrice(10))
+	require.ErrorAs(t, err, &qk.ErrRiskLimit{})
+	require.Equal(t, config.OrderStatus(config.OrderStatusRejected), o.OrderStatus())
+
+	_, err = insert(s, 100, account.WithOrderPrice(10.6))
+	require.ErrorAs(t, err, &qk.ErrRiskLimit{})
+
+	// 标的持仓限制计入未成交的开仓委托
+	_, err = insert(s, 2000, account.WithOrderPrice(10))
+	require.NoError(t, err)
+	_, err = insert(s, 2000, account.WithOrderPrice(10))
+	require.ErrorAs(t, err, &qk.ErrRiskLimit{})
+
+	// 被拒绝的订单不计入每分钟委托笔数
+	for i := 0; i < 2; i++ {
+		_, err = insert(s, 100, account.WithOrderPrice(10))
+		require.NoError(t, err)
+	}
+
+	_, err = insert(s, 100, account.WithOrderPrice(10))
+	require.ErrorAs(t, err, &qk.ErrRiskLimit{})
+
+	f.tm = f.tm.Add(time.Minute)
+	_, err = insert(s, 100, account.WithOrderPrice(10))
+	require.NoError(t, err)
+
+	_, _, d = newTestRisk(t, config.Risk{Restricted: []string{testInstID}})
+	s = d.accounts[config.AccountTypeStockSimple].(*StockSimple)
+	_, err = insert(s, 100, account.WithOrderPrice(10))
+	require.ErrorAs(t, err, &qk.ErrRestricted{})
+}
+
+// 测试当日亏损熔断: 平掉持仓并拒绝之后的订单
+func TestKill(t *testing.T) {
+	s, f, d := newTestRisk(t, config.Risk{MaxDailyLoss: 1000, KillOnDailyLoss: true})
+
+	d.DoMatch(f.tm, bar(10, 10, 10, 10))
+	_, err := insert(s, 1000, account.WithOrderType(config.OrderTypeMarket))
+	require.NoError(t, err)
+
+	f.tm = f.tm.Add(time.Minute)
+	d.DoMatch(f.tm, bar(10, 10, 10, 10))
+	d.CalcPositionPnL(f.tm, bar(10, 10, 10, 10))
+	s.DoSettle(f.tm, nil, nil)
+	require.Equal(t, 1000.0, s.Position[testInstID].Volume())
+
+	f.tm = f.tm.AddDate(0, 0, 1)
+	d.DoMatch(f.tm, bar(8, 8, 8, 8))
+	d.CalcPositionPnL(f.tm, bar(8, 8, 8, 8))
+	require.True(t, d.Halted())
+
+	orders := s.GetOrders(testInstID)
+	require.Len(t, orders, 1)
+	require.Equal(t, config.OrderDirection(config.OrderSell), orders[0].OrderDirection())
+
+	f.tm = f.tm.Add(time.Minute)
+	d.DoMatch(f.tm, bar(8, 8, 8, 8))
+	require.Equal(t, 0.0, s.Position[testInstID].Volume())
+
+	_, err = insert(s, 100, account.WithOrderPrice(8))
+	require.ErrorAs(t, err, &qk.ErrHalted{})
+}
@@ -82,6 +82,7 @@ type StockSimple struct {
 	orders          []handler.Order
 	inst2buyOrders  map[string]*orderedmap.OrderedMap[int64, handler.Order]
 	inst2sellOrders map[string]*orderedmap.OrderedMap[int64, handler.Order]
+	cond            *conditionalBook // 条件单簿
 
 	// 除权除息数据集合
 	xrxd   *XrxdHandler
@@ -113,6 +114,7 @@ func (s *StockSimple) DoResume(
 	assets []recorder.AssetRecord,
 	positions []recorder.PositionRecord,
 	orders []recorder.OrderRecord,
+	trades []recorder.TradeRecord,
 ) {
 	// 恢复持仓
 	for _, record := range positions {
@@ -141,7 +143,8 @@ func (s *StockSimple) DoResume(
 		s.PnL.Profit = record.Profit
 	}
 
-	// 计算并恢复订单
+	// 计算并恢复订单，逐笔重放成交
+	fills := groupTrades(trades, s.accountID)
 	for _, record := range orders {
 		if record.Account != s.accountID {
 			continue
@@ -164,6 +167,7 @@ func (s *StockSimple) DoResume(
 		o := order.ResumeOrder(
 			record.OrderId,
 			contractInfo, record.OrderQty, &account.OrderOp{
+				Account:        s,
 				OrderTime:      &tm,
 				OrderPrice:     record.OrderPrice,
 				OrderDirection: config.OrderDirection(record.OrderDirection),
@@ -172,7 +176,7 @@ func (s *StockSimple) DoResume(
 		)
 
 		_ = s.InsertOrder(o, ResumeOrder())
-		o.DoResume(&record)
+		o.DoResume(&record, fills[record.OrderId]...)
 	}
 
 	config.InfoF("上场账户%s资金: %+v\n", s.accountID, s.Asset)
@@ -247,6 +251,7 @@ func (s *StockSimple) Init(account handler.Accounts, option any) error {
 	s.orders = make([]handler.Order, 0)
 	s.inst2buyOrders = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
 	s.inst2sellOrders = make(map[string]*orderedmap.OrderedMap[int64, handler.Order])
+	s.cond = newConditionalBook(s, s.Account())
 
 	// 实盘账户，需要加载交易通道
 	if s.Config().Framework.Realtime {
@@ -288,6 +293,8 @@ func (s *StockSimple) GetPnL() account.PnL {
 }
 
 func (s *StockSimple) DoOrderUpdate(order handler.Order) {
+	notifyStatus(s.Account(), order)
+
 	if order.OrderDirection() == config.OrderBuy {
 		// 买入
 		switch order.OrderStatus() {
@@ -300,7 +307,12 @@ func (s *StockSimple) DoOrderUpdate(order handler.Order) {
 			orderAmt := order.OrderAmt()
 			s.Asset.Frozen -= orderAmt
 			s.Asset.Available += orderAmt
-		case config.OrderStatusDone:
+		case config.OrderStatusRejected:
+			// 撮合阶段拒单，解冻资金和手续费
+			frozen := order.OrderAmt() + order.CommissionFrozen()
+			s.Asset.Frozen -= frozen
+			s.Asset.Available += frozen
+		case config.OrderStatusPartDonePartCancel, config.OrderStatusDone:
 			orderAmt := order.OrderAmt()
 			tradeAmt := order.TradeAmt()
 			commissionFrozen := order.CommissionFrozen()
@@ -312,7 +324,11 @@ func (s *StockSimple) DoOrderUpdate(order handler.Order) {
 			s.Asset.Frozen -= orderAmt - tradeAmt + commissionFrozen
 		}
 	} else {
-		// 卖出
+		// 卖出，冻结、解冻持仓可卖数量
+		if pos, ok := s.Position[order.InstID()]; ok {
+			pos.DoOrderUpdate(order)
+		}
+
 		switch order.OrderStatus() {
 		case config.OrderStatusNew:
 			commission := order.CommissionFrozen()
@@ -321,7 +337,8 @@ func (s *StockSimple) DoOrderUpdate(order handler.Order) {
 			s.Asset.Available -= commission
 			s.Asset.Frozen += commission
 		// 完结订单，解冻手续费资金，扣除手续费
-		case config.OrderStatusCanceled, config.OrderStatusPartDonePartCancel, config.OrderStatusDone:
+		case config.OrderStatusCanceled, config.OrderStatusRejected,
+			config.OrderStatusPartDonePartCancel, config.OrderStatusDone:
 			commissionFrozen := order.CommissionFrozen()
 			commission := order.Commission()
 			// 解冻手续费资金
@@ -336,6 +353,9 @@ func (s *StockSimple) DoOrderUpdate(order handler.Order) {
 }
 
 func (s *StockSimple) DoTradeUpdate(qty, price float64, order handler.Order) {
+	// 股票手续费在订单完成时统一扣除，成交记录按本笔成交计算
+	notifyTrade(s.Account(), order, price, qty, order.Contract().CalcComm(order.OrderTime(), qty, price, order.OrderDirection()))
+
 	deltaMarketValue := 0.0
 	deltaPnL := 0.0
 	if s.Asset.Frozen < -0.1 {
@@ -372,8 +392,7 @@ func getSettlePrice(instID string, indicators *orderedmap.OrderedMap[string, dat
 	}
 
 	if v, ok := indicators.Get(instID); ok {
-		settlePrice, err := v.TryConvertToFloat("Close")
-		if err == nil {
+		if settlePrice, ok := v.Get("Close"); ok {
 			return settlePrice
 		}
 	}
@@ -387,8 +406,13 @@ func (s *StockSimple) DoSettle(
 ) {
 	// 处理订单
 	for _, o := range s.orders {
+		status := o.OrderStatus()
 		o.DoSettle(tm, recorder[config.RecordTypeOrder])
+		if o.OrderStatus() != status {
+			notifyStatus(s.Account(), o)
+		}
 	}
+	s.cond.settle(tm, recorder[config.RecordTypeOrder])
 
 	// 处理持仓
 	// 如果持仓为空，不处理，且清理持仓
@@ -458,13 +482,14 @@ func (s *StockSimple) GetOrders(instID string) []account.Order {
 			orders = append(orders, pOrder.Value)
 		}
 	}
-	return orders
+	return append(orders, s.cond.orders(instID)...)
 }
 
 func (s *StockSimple) DoMatch(
 	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord], matcher handler.Matcher,
 ) {
 	for pInst := indicators.Oldest(); pInst != nil; pInst = pInst.Next() {
+		s.cond.trigger(tm, pInst.Key, pInst.Value)
 		orders := s.inst2sellOrders[pInst.Key]
 		if orders != nil {
 			for pOrder := orders.Oldest(); pOrder != nil; pOrder = pOrder.Next() {
@@ -480,6 +505,8 @@ func (s *StockSimple) DoMatch(
 		}
 
 	}
+
+	s.cond.afterMatch(tm)
 }
 
 func (s *StockSimple) NewOrder(instId string, qty float64, options ...account.WithOrderOption) (account.Order, error) {
@@ -508,14 +535,22 @@ func (s *StockSimple) NewOrder(instId string, qty float64, options ...account.Wi
 }
 
 func (s *StockSimple) InsertOrder(o account.Order, options ...account.WithOrderOption) error {
+	// 未触发的条件单由条件单簿保存
+	if s.cond.hold(o, options) {
+		return nil
+	}
+
 	orderOp := o.(handler.Order)
 	s.orders = append(s.orders, orderOp)
 	op := account.NewOrderOp(options...)
+	if err := preTrade(s.Account(), orderOp, op); err != nil {
+		return err
+	}
+
 	if op.CheckCash && o.OrderDirection() == config.OrderBuy {
 		if s.Asset.Available < o.OrderAmt() {
 			err := qk.ErrInsufficientCash{Need: o.OrderAmt(), Have: s.Asset.Available}
-			orderOp.DoReject(*s.Account().GetCurrTime(), err)
-			return err
+			return reject(s.Account(), orderOp, err)
 		}
 	}
 
@@ -523,12 +558,10 @@ func (s *StockSimple) InsertOrder(o account.Order, options ...account.WithOrderO
 		pos := s.Position[o.InstID()]
 		if pos == nil {
 			err := qk.ErrInsufficientPosition{Need: o.OrderQty(), Have: 0}
-			orderOp.DoReject(*s.Account().GetCurrTime(), err)
-			return err
+			return reject(s.Account(), orderOp, err)
 		} else if pos.Volume(account.WithSellAvailable(true)) < o.OrderQty() {
 			err := qk.ErrInsufficientPosition{Need: o.OrderQty(), Have: pos.Volume()}
-			orderOp.DoReject(*s.Account().GetCurrTime(), err)
-			return err
+			return reject(s.Account(), orderOp, err)
 		}
 	}
 
@@ -578,18 +611,24 @@ func (s *StockSimple) InsertOrder(o account.Order, options ...account.WithOrderO
 }
 
 func (s *StockSimple) CancelOrder(tm time.Time, id int64) {
-	o := s.orders[id]
-	o.DoCancelUpdate(tm)
-
-	// 将订单从列表中删除
-	if o.OrderDirection() == config.OrderSell {
-		orders := *s.inst2sellOrders[o.InstID()]
-		orders.Delete(id)
-	} else {
-		orders := *s.inst2buyOrders[o.InstID()]
-		orders.Delete(id)
+	if s.cond.cancel(tm, id) {
+		return
+	}
+
+	// 在挂单中查找订单，并将订单从列表中删除
+	for _, inst2orders := range []map[string]*orderedmap.OrderedMap[int64, handler.Order]{
+		s.inst2buyOrders, s.inst2sellOrders,
+	} {
+		for _, orders := range inst2orders {
+			if o, ok := orders.Get(id); ok {
+				o.DoCancelUpdate(tm)
+				orders.Delete(id)
+				return
+			}
+		}
 	}
 
+	config.WarnF("撤单失败, 未找到订单: %d", id)
 }
 
 func init() {
@@ -611,6 +650,7 @@ func (s *StockSimple) CalcSettleInfo(
 	// 计算除权除息
 	settleInstID := instID
 	settleQty, settlePrice, settleLastPrice, dividend, tax, settleInstID = xrxd.CalcPos(qty, price, lastPrice)
+	s.Account().AdjustRoundTrip(s.accountID, instID, xrxd)
 
 	// 如果换股，instID会变化，因此直接清空本position的数据，添加或者更新新的position
 	if settleInstID != "" {
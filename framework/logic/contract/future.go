@@ -36,9 +36,18 @@ func (c FutureContract) GetInstID() string {
 	return c.instId
 }
 
-func (c FutureContract) CalcComm(qty float64, price float64, direction config.OrderDirection) float64 {
-	// TODO implement me
-	panic("implement me")
+func (c FutureContract) GetTPlus() int {
+	return int(c.Basic.TPlus)
+}
+
+// CalcComm 期货按买卖方向无法区分开平，统一按开仓手续费估算
+func (c FutureContract) CalcComm(tm time.Time, qty float64, price float64, direction config.OrderDirection) float64 {
+	return c.CalcCommOffset(tm, qty, price, config.OffsetOpen)
+}
+
+// CalcCommOffset 按开平标志计算手续费
+func (c FutureContract) CalcCommOffset(tm time.Time, qty float64, price float64, offset config.TransactionType) float64 {
+	return common.CalcCommFuture(c.FeeAt(tm), c.Basic.ContractSize, qty, price, offset)
 }
 
 func (c FutureContract) CalcMarketValue(qty float64, price float64, direction config.PositionDirection) float64 {
@@ -0,0 +1,46 @@
+package contract
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/setting"
+)
+
+// 测试期货合约按品种查找以及开平手续费计算
+func TestFutureContract(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("./contract.yaml")
+	require.NoError(t, err)
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+
+	// 合约代码按品种IF查找配置
+	c := handle.GetContract("IF2406.CCFX.CF")
+	require.Equal(t, config.AccountType(config.AccountTypeFuture), c.GetAccountType())
+
+	tm := time.Now()
+	future, ok := c.(contract.Future)
+	require.True(t, ok)
+
+	// 1手 4000点, 名义价值 = 1*4000*300 = 1200000
+	require.InDelta(t, 1200000.0, c.CalcMarketValue(1, 4000, config.PositionLong), 1e-6)
+
+	// 保证金 = 1200000*0.12 = 144000
+	require.InDelta(t, 144000.0, c.CalcMargin(1, 4000, config.PositionShort), 1e-6)
+
+	// 开仓手续费 = 1200000*0.000023 = 27.6
+	require.Equal(t, 27.6, future.CalcCommOffset(tm, 1, 4000, config.OffsetOpen))
+	require.Equal(t, 27.6, c.CalcComm(tm, 1, 4000, config.OrderBuy))
+
+	// 平今手续费 = 1200000*0.000345 = 414
+	require.Equal(t, 414.0, future.CalcCommOffset(tm, 1, 4000, config.OffsetClose))
+
+	// 平昨手续费 = 1200000*0.000023 = 27.6
+	require.Equal(t, 27.6, future.CalcCommOffset(tm, 1, 4000, config.OffsetCloseHis))
+
+	// 未配置的品种
+	require.Panics(t, func() { handle.GetContract("rb2410.XSGE.CF") })
+}
@@ -3,7 +3,10 @@ package contract
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/contract"
@@ -27,7 +30,15 @@ func (c *Handler) Init(option ...contract.WithOption) (handler.Contract, error)
 	c.mutex = sync.RWMutex{}
 
 	for i := range op.Property.TargetProp.Stock {
-		c.stock[op.Property.TargetProp.Stock[i].Name] = op.Property.TargetProp.Stock[i]
+		prop := op.Property.TargetProp.Stock[i]
+		if len(prop.FeeSchedules) == 0 {
+			// 未配置费用表时按法定调整切换印花税，配置的税率视为调整前的税率
+			withSchedule := *prop
+			withSchedule.FeeSchedules = stampDutyHalved(prop.StockFee)
+			prop = &withSchedule
+		}
+
+		c.stock[prop.Name] = prop
 	}
 
 	for i := range op.Property.TargetProp.Future {
@@ -51,8 +62,14 @@ func (c *Handler) GetContract(instId string) contract.Contract {
 			panic(fmt.Sprintf("未支持的股票合约: %s", instId))
 		}
 
+		board := prop.Basic.Board
+		if board == "" {
+			board = boardOf(instIdSlice[0])
+		}
+
 		return &StockContract{
 			instId:        instId,
+			board:         board,
 			StockContract: prop,
 		}
 	} else if instIdSlice[2] == "CF" {
@@ -70,9 +87,20 @@ func (c *Handler) GetContract(instId string) contract.Contract {
 	panic(fmt.Sprintf("未知的合约类型: %s", instIdSlice[2]))
 }
 
-// 科创板/创业板股票默认合约
+// stampDutyHalved 2023-08-28起A股印花税减半，其余费用不变
+func stampDutyHalved(fee config.StockFee) []config.StockFeeSchedule {
+	halved := fee
+	halved.TaxRate /= 2
+
+	return []config.StockFeeSchedule{{
+		EffectiveDate: config.Time{Time: time.Date(2023, 8, 28, 0, 0, 0, 0, time.Local)},
+		StockFee:      halved,
+	}}
+}
+
+// 科创板股票默认合约，最少200股，按1股递增
 func makeStarDefaultContract(instId *string) (*config.StockContract, bool) {
-	return &config.StockContract{
+	c := &config.StockContract{
 		Name: *instId,
 		Basic: config.ContractBasic{
 			MinOrderVol:  200,
@@ -86,12 +114,37 @@ func makeStarDefaultContract(instId *string) (*config.StockContract, bool) {
 			CommBrokerRate:  0.0003,
 			MinFees:         5,
 		},
-	}, true
+	}
+	c.FeeSchedules = stampDutyHalved(c.StockFee)
+
+	return c, true
+}
+
+// 创业板股票默认合约，最少100股，按100股递增
+func makeChiNextDefaultContract(instId *string) (*config.StockContract, bool) {
+	c := &config.StockContract{
+		Name: *instId,
+		Basic: config.ContractBasic{
+			MinOrderVol:  100,
+			ContractSize: 100,
+			TickSize:     0.01,
+			TPlus:        1,
+		},
+		StockFee: config.StockFee{
+			TransferFeeRate: 0.00001,
+			TaxRate:         0.001,
+			CommBrokerRate:  0.0003,
+			MinFees:         5,
+		},
+	}
+	c.FeeSchedules = stampDutyHalved(c.StockFee)
+
+	return c, true
 }
 
 // 主板股票默认合约
 func makeMainBoardDefaultContract(instId *string) (*config.StockContract, bool) {
-	return &config.StockContract{
+	c := &config.StockContract{
 		Name: *instId,
 		Basic: config.ContractBasic{
 			MinOrderVol:  100,
@@ -105,7 +158,10 @@ func makeMainBoardDefaultContract(instId *string) (*config.StockContract, bool)
 			CommBrokerRate:  0.0003,
 			MinFees:         5,
 		},
-	}, true
+	}
+	c.FeeSchedules = stampDutyHalved(c.StockFee)
+
+	return c, true
 }
 
 // ETF默认合约
@@ -127,6 +183,55 @@ func makeETFDefaultContract(instId *string) (*config.StockContract, bool) {
 	}, true
 }
 
+// 跨境ETF、债券ETF默认合约，T+0交易
+func makeT0ETFDefaultContract(instId *string) (*config.StockContract, bool) {
+	return &config.StockContract{
+		Name: *instId,
+		Basic: config.ContractBasic{
+			MinOrderVol:  100,
+			ContractSize: 100,
+			TickSize:     0.001,
+			TPlus:        0,
+		},
+		StockFee: config.StockFee{
+			TransferFeeRate: 0.0,
+			TaxRate:         0.0,
+			CommBrokerRate:  0.00005,
+			MinFees:         0.1,
+		},
+	}, true
+}
+
+// 可转债默认合约，T+0交易，一手10张
+func makeConvertibleBondDefaultContract(instId *string) (*config.StockContract, bool) {
+	return &config.StockContract{
+		Name: *instId,
+		Basic: config.ContractBasic{
+			MinOrderVol:  10,
+			ContractSize: 10,
+			TickSize:     0.001,
+			TPlus:        0,
+		},
+		StockFee: config.StockFee{
+			TransferFeeRate: 0.0,
+			TaxRate:         0.0,
+			CommBrokerRate:  0.0001,
+			MinFees:         0.1,
+		},
+	}, true
+}
+
+// 各板块的默认合约
+var defaultStockContracts = map[config.Board]func(instId *string) (*config.StockContract, bool){
+	config.BoardMain:            makeMainBoardDefaultContract,
+	config.BoardStar:            makeStarDefaultContract,
+	config.BoardChiNext:         makeChiNextDefaultContract,
+	config.BoardETF:             makeETFDefaultContract,
+	config.BoardCrossBorderETF:  makeT0ETFDefaultContract,
+	config.BoardBondETF:         makeT0ETFDefaultContract,
+	config.BoardConvertibleBond: makeConvertibleBondDefaultContract,
+}
+
 // 合约代码格式: 合约代码.交易所.合约类型
 // code 为合约代码
 // market 为交易所mic代码
@@ -141,34 +246,12 @@ func (c *Handler) getStockContract(instId, code, market *string) (*config.StockC
 		return v, ok
 	}
 
-	// TODO 否则使用合约特殊类型的属性
-	switch (*code)[:3] {
-	case "688":
-		prop, ok = c.stock["star"]
-		if !ok {
-			prop, ok = makeStarDefaultContract(instId)
-		}
-	case "300":
-		prop, ok = c.stock["chi-next"]
-		if !ok {
-			prop, ok = makeStarDefaultContract(instId)
-		}
-	case "159", "510":
-		prop, ok = c.stock["etf"]
-		if !ok {
-			prop, ok = makeETFDefaultContract(instId)
-		}
-	default:
-		switch (*code)[:2] {
-		case "60", "00":
-			prop, ok = c.stock["main-board"]
-			if !ok {
-				prop, ok = makeMainBoardDefaultContract(instId)
-			}
-		default:
-			prop, ok = makeMainBoardDefaultContract(instId)
-		}
-
+	// 否则使用合约所属板块的属性，没有配置时使用板块默认属性
+	// 深市跨境ETF、债券ETF与普通ETF代码段相同，需要按合约代码单独配置
+	board := boardOf(*code)
+	prop, ok = c.stock[string(board)]
+	if !ok {
+		prop, ok = defaultStockContracts[board](instId)
 	}
 
 	c.mutex.RUnlock()
@@ -181,12 +264,20 @@ func (c *Handler) getStockContract(instId, code, market *string) (*config.StockC
 }
 
 func (c *Handler) getFutureContract(instId, code, market *string) (*config.FutureContract, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
 	if v, ok := c.future[*instId]; ok {
 		return v, ok
 	}
 
-	// TODO 期货未完全实现
-	panic(fmt.Sprintf("未知的期货合约: %s", *instId))
+	// 按品种查找, 例如: au2406 -> au
+	product := strings.TrimRightFunc(*code, unicode.IsDigit)
+	if v, ok := c.future[product]; ok {
+		return v, ok
+	}
+
+	return nil, false
 }
 
 func init() {
@@ -0,0 +1,157 @@
+package contract
+
+import (
+	"math"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	math2 "github.com/wonderstone/QuantKit/tools/math"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// + 交易所规则，按板块和标的类型区分:
+// + 涨跌停幅度: 主板、ETF ±10%，主板ST ±5%；科创板、创业板、可转债 ±20%，ST相同；期货默认 ±10%
+// + 合约配置了涨跌停幅度时优先使用配置，小于0表示不设涨跌停；上市首日不设涨跌停
+// + 涨跌停价按昨收价计算，按最小变动价位四舍五入
+// + 委托数量不少于最小下单量，超出部分按递增单位(股票为每手数量，期货为1手)递增
+// + 平仓(卖出)全部持仓，或者一次性卖出全部零股时，不受最小下单量和递增单位限制
+
+const defaultFutureLimitRate = 0.1 // 期货默认涨跌停幅度
+
+type limitRate struct {
+	normal float64 // 涨跌停幅度
+	st     float64 // ST涨跌停幅度
+}
+
+var boardLimitRates = map[config.Board]limitRate{
+	config.BoardMain:            {normal: 0.1, st: 0.05},
+	config.BoardStar:            {normal: 0.2, st: 0.2},
+	config.BoardChiNext:         {normal: 0.2, st: 0.2},
+	config.BoardETF:             {normal: 0.1, st: 0.1},
+	config.BoardCrossBorderETF:  {normal: 0.1, st: 0.1},
+	config.BoardBondETF:         {normal: 0.1, st: 0.1},
+	config.BoardConvertibleBond: {normal: 0.2, st: 0.2},
+}
+
+// boardOf 按合约代码判断股票板块
+func boardOf(code string) config.Board {
+	if len(code) < 3 {
+		return config.BoardMain
+	}
+
+	switch code[:3] {
+	case "688", "689":
+		return config.BoardStar
+	case "300", "301":
+		return config.BoardChiNext
+	case "159", "510", "512", "515", "516", "560", "561", "562", "588":
+		return config.BoardETF
+	case "513":
+		return config.BoardCrossBorderETF
+	case "511":
+		return config.BoardBondETF
+	case "110", "111", "113", "118", "123", "127", "128":
+		return config.BoardConvertibleBond
+	}
+
+	return config.BoardMain
+}
+
+// priceLimit 按昨收价和涨跌停幅度计算涨跌停价
+func priceLimit(prevClose, rate, tickSize float64) (lower, upper float64, ok bool) {
+	if rate < 0 || prevClose <= 0 {
+		return 0, 0, false
+	}
+
+	if tickSize <= 0 {
+		tickSize = 0.01
+	}
+
+	round := func(price float64) float64 {
+		return math2.Round(math2.Round(price/tickSize, 0)*tickSize, 6)
+	}
+
+	return round(prevClose * (1 - rate)), round(prevClose * (1 + rate)), true
+}
+
+// multiple qty是否为increment的整数倍
+func multiple(qty, increment float64) bool {
+	if increment <= 0 {
+		return true
+	}
+
+	r := math.Mod(qty, increment)
+	return r < 1e-6 || increment-r < 1e-6
+}
+
+// checkQty 检查委托数量
+func checkQty(basic config.ContractBasic, increment, qty, holding float64, offset config.TransactionType) error {
+	if qty <= 0 {
+		return qk.ErrInsufficientOrderQty{}
+	}
+
+	if offset != config.OffsetOpen {
+		if qty == holding {
+			return nil
+		}
+
+		if qty >= basic.MinOrderVol && multiple(qty-math.Mod(holding, increment), increment) {
+			return nil
+		}
+	}
+
+	if qty < basic.MinOrderVol || !multiple(qty-basic.MinOrderVol, increment) {
+		return qk.ErrOrderQty{Qty: qty, Min: basic.MinOrderVol, Increment: increment}
+	}
+
+	return nil
+}
+
+func (c StockContract) GetBoard() config.Board {
+	return c.board
+}
+
+// CalcPriceLimit 股票涨跌停价，上市首日不设涨跌停
+func (c StockContract) CalcPriceLimit(tm time.Time, prevClose float64, st bool) (lower, upper float64, ok bool) {
+	if c.ListDate != nil && c.ListDate.Format(time.DateOnly) == tm.Format(time.DateOnly) {
+		return 0, 0, false
+	}
+
+	rate := c.Basic.PriceLimitRate
+	if rate == 0 {
+		r, ok := boardLimitRates[c.board]
+		if !ok {
+			r = boardLimitRates[config.BoardMain]
+		}
+
+		rate = r.normal
+		if st {
+			rate = r.st
+		}
+	}
+
+	return priceLimit(prevClose, rate, c.Basic.TickSize)
+}
+
+func (c StockContract) CheckQty(qty, holding float64, offset config.TransactionType) error {
+	return checkQty(c.Basic, c.Basic.ContractSize, qty, holding, offset)
+}
+
+func (c FutureContract) GetBoard() config.Board {
+	return config.BoardFuture
+}
+
+// CalcPriceLimit 期货涨跌停价，按品种配置的涨跌停幅度计算
+func (c FutureContract) CalcPriceLimit(tm time.Time, prevClose float64, st bool) (lower, upper float64, ok bool) {
+	rate := c.Basic.PriceLimitRate
+	if rate == 0 {
+		rate = defaultFutureLimitRate
+	}
+
+	return priceLimit(prevClose, rate, c.Basic.TickSize)
+}
+
+// CheckQty 期货按整手委托
+func (c FutureContract) CheckQty(qty, holding float64, offset config.TransactionType) error {
+	return checkQty(c.Basic, 1, qty, holding, offset)
+}
@@ -0,0 +1,85 @@
+package contract
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// 测试按板块区分的涨跌停价和委托数量规则
+func TestRule(t *testing.T) {
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(&config.ContractProperty{}))
+	tm := time.Date(2023, 1, 4, 10, 0, 0, 0, time.Local)
+
+	rule := func(instID string) contract.Rule {
+		r, ok := handle.GetContract(instID).(contract.Rule)
+		require.True(t, ok)
+		return r
+	}
+
+	// 主板 ±10%，ST ±5%
+	main := rule("600000.XSHG.CS")
+	require.Equal(t, config.Board(config.BoardMain), main.GetBoard())
+	lower, upper, ok := main.CalcPriceLimit(tm, 10.05, false)
+	require.True(t, ok)
+	require.Equal(t, 9.05, lower)
+	require.Equal(t, 11.06, upper)
+	lower, upper, _ = main.CalcPriceLimit(tm, 10, true)
+	require.Equal(t, 9.5, lower)
+	require.Equal(t, 10.5, upper)
+
+	// 科创板 ±20%
+	star := rule("688001.XSHG.CS")
+	require.Equal(t, config.Board(config.BoardStar), star.GetBoard())
+	lower, upper, _ = star.CalcPriceLimit(tm, 10, true)
+	require.Equal(t, 8.0, lower)
+	require.Equal(t, 12.0, upper)
+
+	// 可转债 ±20%，最小变动价位0.001
+	_, upper, _ = rule("113050.XSHG.CS").CalcPriceLimit(tm, 120.1234, false)
+	require.Equal(t, 144.148, upper)
+
+	// 主板按100股整数倍买入，零股需要一次性卖出
+	require.NoError(t, main.CheckQty(300, 0, config.OffsetOpen))
+	require.ErrorAs(t, main.CheckQty(150, 0, config.OffsetOpen), &qk.ErrOrderQty{})
+	require.NoError(t, main.CheckQty(150, 1050, config.OffsetClose))
+	require.NoError(t, main.CheckQty(50, 50, config.OffsetClose))
+	require.ErrorAs(t, main.CheckQty(120, 1050, config.OffsetClose), &qk.ErrOrderQty{})
+
+	// 科创板最少200股，按1股递增，剩余不足200股时一次性卖出
+	require.NoError(t, star.CheckQty(201, 0, config.OffsetOpen))
+	require.ErrorAs(t, star.CheckQty(199, 0, config.OffsetOpen), &qk.ErrOrderQty{})
+	require.ErrorAs(t, star.CheckQty(150, 1000, config.OffsetClose), &qk.ErrOrderQty{})
+	require.NoError(t, star.CheckQty(150, 150, config.OffsetClose))
+
+	// 创业板 ±20%，最少100股，按100股递增
+	chiNext := rule("301001.XSHE.CS")
+	require.Equal(t, config.Board(config.BoardChiNext), chiNext.GetBoard())
+	lower, upper, _ = chiNext.CalcPriceLimit(tm, 10, false)
+	require.Equal(t, 8.0, lower)
+	require.Equal(t, 12.0, upper)
+	require.NoError(t, chiNext.CheckQty(200, 0, config.OffsetOpen))
+	require.ErrorAs(t, chiNext.CheckQty(150, 0, config.OffsetOpen), &qk.ErrOrderQty{})
+
+	require.Equal(t, config.Board(config.BoardStar), rule("689009.XSHG.CS").GetBoard())
+
+	// 沪市ETF代码段不收印花税
+	for _, instID := range []string{"512880.XSHG.CS", "515050.XSHG.CS", "588000.XSHG.CS", "560010.XSHG.CS"} {
+		etf := handle.GetContract(instID)
+		require.Equal(t, config.Board(config.BoardETF), etf.(contract.Rule).GetBoard(), instID)
+		require.Zero(t, etf.(*StockContract).FeeAt(tm).TaxRate, instID)
+	}
+
+	// 上市首日不设涨跌停
+	listDate := config.Time{Time: time.Date(2023, 1, 4, 0, 0, 0, 0, time.Local)}
+	ipo := StockContract{instId: "688001.XSHG.CS", board: config.BoardStar, StockContract: &config.StockContract{ListDate: &listDate}}
+	_, _, ok = ipo.CalcPriceLimit(tm, 10, false)
+	require.False(t, ok)
+	_, _, ok = ipo.CalcPriceLimit(tm.AddDate(0, 0, 1), 10, false)
+	require.True(t, ok)
+}
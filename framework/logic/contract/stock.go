@@ -10,7 +10,8 @@ import (
 )
 
 type StockContract struct {
-	instId string // 合约代码
+	instId string       // 合约代码
+	board  config.Board // 板块
 	*config.StockContract
 
 	btree.MapG[time.Time, *config.Xrxd] // 除权除息数据, tm(除权登记日) -> xrxd
@@ -33,8 +34,12 @@ func (c StockContract) GetInstID() string {
 	return c.instId
 }
 
-func (c StockContract) CalcComm(qty float64, price float64, direction config.OrderDirection) float64 {
-	return common.CalcCommStock(c.StockFee, qty, price, direction)
+func (c StockContract) GetTPlus() int {
+	return int(c.Basic.TPlus)
+}
+
+func (c StockContract) CalcComm(tm time.Time, qty float64, price float64, direction config.OrderDirection) float64 {
+	return common.CalcCommStock(c.FeeAt(tm), qty, price, direction)
 }
 
 func (c StockContract) CalcMarketValue(qty float64, price float64, direction config.PositionDirection) float64 {
@@ -3,6 +3,7 @@ package contract
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/wonderstone/QuantKit/config"
@@ -26,7 +27,7 @@ func TestConfigMode(t *testing.T) {
 	// ! 手续费貌似这里的预期计算有问题，暂时还按照QK的计算方式来
 	// 买入 主板 1000股 8.1元
 	// 买入手续费 = max(1000*0.00001, 1) + max(1000*8.1*0.00003, 5) = 6
-	require.Equalf(t, 9.86, contract.CalcComm(1000, 8.1, config.OrderBuy), "计算手续费错误")
+	require.Equalf(t, 9.86, contract.CalcComm(time.Now(), 1000, 8.1, config.OrderBuy), "计算手续费错误")
 
 	// 市值为 1000*8.1 = 8100
 	require.Equalf(t, 8100.0, contract.CalcMarketValue(1000, 8.1, config.PositionLong), "计算市值错误")
@@ -42,7 +43,7 @@ func TestConfigMode(t *testing.T) {
 
 	// 卖出 主板 1000股 17 元
 	// 卖出手续费 = max(1000*0.00001, 1) + max(1000*17*0.00003, 5) + 1000*17*0.001 = 23.1
-	// require.Equalf(t, 23.1, contract.CalcComm(1000, 17, config.OrderSell), "计算手续费错误")
+	// require.Equalf(t, 23.1, contract.CalcComm(time.Now(), 1000, 17, config.OrderSell), "计算手续费错误")
 
 	// 市值为 1000*17 = 17000
 	require.Equalf(t, 17000.0, contract.CalcMarketValue(1000, 17, config.PositionShort), "计算市值错误")
@@ -57,3 +58,39 @@ func TestConfigMode(t *testing.T) {
 	require.Equalf(t, 16.99, contract.CalcSlipPrice(17, 1, config.OrderSell), "计算滑点价格错误")
 
 }
+
+// 测试按生效日期切换费用: 按标的配置的费用表，以及默认合约的印花税减半
+func TestFeeSchedule(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("./contract.yaml")
+	require.NoError(t, err)
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+	c := handle.GetContract("600519.XSHG.CS")
+
+	// 卖出 1000股 100元, 成交额100000
+	// 2022-04-29之前: 过户费2 + 印花税100 + 佣金30 = 132
+	tm := time.Date(2021, 6, 1, 10, 0, 0, 0, time.Local)
+	require.Equal(t, 132.0, c.CalcComm(tm, 1000, 100, config.OrderSell))
+
+	// 过户费下调: 1 + 100 + 30 = 131
+	tm = time.Date(2023, 8, 25, 10, 0, 0, 0, time.Local)
+	require.Equal(t, 131.0, c.CalcComm(tm, 1000, 100, config.OrderSell))
+
+	// 印花税减半: 1 + 50 + 30 = 81
+	tm = time.Date(2023, 8, 28, 9, 30, 0, 0, time.Local)
+	require.Equal(t, 81.0, c.CalcComm(tm, 1000, 100, config.OrderSell))
+
+	// 配置了主板属性但未配置费用表时，印花税同样减半
+	// 卖出 1000股 100元: 过户费60 + 印花税100 + 佣金30 = 190，印花税减半后为140
+	c = handle.GetContract("000001.XSHE.CS")
+	require.Equal(t, 190.0, c.CalcComm(time.Date(2023, 8, 25, 10, 0, 0, 0, time.Local), 1000, 100, config.OrderSell))
+	require.Equal(t, 140.0, c.CalcComm(time.Date(2023, 8, 28, 10, 0, 0, 0, time.Local), 1000, 100, config.OrderSell))
+	require.Empty(t, conf.TargetProp.Stock[0].FeeSchedules, "不修改配置")
+
+	// 未配置主板属性时使用默认合约
+	// 卖出 1000股 10元: 过户费0.1 + 印花税10 + 最低佣金5 = 15.1，印花税减半后为10.1
+	handle, _ = setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(&config.ContractProperty{}))
+	c = handle.GetContract("000001.XSHE.CS")
+	require.Equal(t, 15.1, c.CalcComm(time.Date(2023, 8, 25, 10, 0, 0, 0, time.Local), 1000, 10, config.OrderSell))
+	require.Equal(t, 10.1, c.CalcComm(time.Date(2023, 8, 28, 10, 0, 0, 0, time.Local), 1000, 10, config.OrderSell))
+}
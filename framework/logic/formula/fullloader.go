@@ -10,6 +10,7 @@ import (
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/tools/dag"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/resample"
 	"github.com/wonderstone/QuantKit/tools/times"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
@@ -53,6 +54,7 @@ type FullLoadCalculator struct {
 	config            config.Runtime
 
 	instID2Path map[string]string // 合约ID->路径
+	resampleTo  config.Frequency  // 行情目录不存在时由小周期行情合成的频率
 	indicator   []string          // 指标名称
 
 	*simple.DirectedGraph
@@ -73,6 +75,11 @@ func (f *FullLoadCalculator) Init(option ...formula.WithOption) error {
 
 	f.config = op.Config
 	f.quoteDataPath = filepath.Join(op.Config.Path.Download, string(op.Config.Framework.Frequency))
+	if dir, from, err := resample.Locate(op.Config.Path.Download, op.Config.Framework.Frequency); err == nil && from != op.Config.Framework.Frequency {
+		config.InfoF("行情目录 %s 不存在, 由 %s 行情合成", f.quoteDataPath, from)
+		f.quoteDataPath = dir
+		f.resampleTo = op.Config.Framework.Frequency
+	}
 	f.outputPath = op.Config.Path.Indicator
 
 	for _, inst := range op.Config.Framework.Instrument {
@@ -139,6 +146,15 @@ func (f *FullLoadCalculator) StartCalc() {
 	for instID, file := range f.instID2Path {
 		// 读取已有数据，包括行情的高开低收，成交量，成交额
 		f.df = dataframe.CreateDataFrame(filepath.Dir(file), filepath.Base(file))
+		if f.resampleTo != "" {
+			df, err := resample.Frame(f.df, f.resampleTo, instID)
+			if err != nil {
+				config.ErrorF("合成 %s 的 %s 行情失败: %v", instID, f.resampleTo, err)
+			}
+
+			f.df = df
+		}
+
 		for _, indicator := range f.indicator {
 			f.df.NewField(indicator)
 		}
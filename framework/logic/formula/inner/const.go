@@ -9,7 +9,6 @@
 package indicator
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/wonderstone/QuantKit/config"
@@ -29,8 +28,12 @@ func (c *Const) DoInit(f config.Formula) {
 }
 
 func (c *Const) DoCalculate(tm time.Time, row dataframe.RecordFunc) string {
+	return formula.FormatFloat(c.DoCalculateFloat(tm, row))
+}
+
+func (c *Const) DoCalculateFloat(tm time.Time, row dataframe.RecordFunc) float64 {
 
-	return fmt.Sprintf("%.4f", c.Num)
+	return c.Num
 }
 
 func (c *Const) DoReset() {
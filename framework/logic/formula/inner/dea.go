@@ -8,7 +8,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 
 	"time"
 
@@ -36,14 +36,18 @@ func (d *DEA) DoInit(f config.Formula) {
 }
 
 
-func (d *DEA) DoCalculate(tm time.Time, data dataframe.RecordFunc) string{
+func (d *DEA) DoCalculate(tm time.Time, data dataframe.RecordFunc) string {
+	return formula.FormatFloat(d.DoCalculateFloat(tm, data))
+}
+
+func (d *DEA) DoCalculateFloat(tm time.Time, data dataframe.RecordFunc) float64 {
 	// d.LoadData(dataframe.ConvertToFloat(data, d.Base))
 	v, err := dataframe.TryConvertToFloat(data, d.Base)
 	if err != nil {
-		return ""
+		return math.NaN()
 	}
 	d.LoadData(v)
-	return fmt.Sprintf("%.4f", d.Eval())
+	return d.Eval()
 }
 
 func (d *DEA) DoReset() {
@@ -8,7 +8,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 	"time"
 
 	"github.com/wonderstone/QuantKit/framework/entity/formula"
@@ -31,14 +31,18 @@ func (d *DIF) DoInit(f config.Formula) {
 	d.EMA_S = NewEMA("EMA_S", d.S, d.Base)
 	d.EMA_L = NewEMA("EMA_L", d.L, d.Base)
 }
-func (d *DIF) DoCalculate(tm time.Time, data dataframe.RecordFunc) string{
+func (d *DIF) DoCalculate(tm time.Time, data dataframe.RecordFunc) string {
+	return formula.FormatFloat(d.DoCalculateFloat(tm, data))
+}
+
+func (d *DIF) DoCalculateFloat(tm time.Time, data dataframe.RecordFunc) float64 {
 	// d.LoadData(dataframe.ConvertToFloat(data, d.Base))
 	v, err := dataframe.TryConvertToFloat(data, d.Base)
 	if err != nil {
-		return ""
+		return math.NaN()
 	}
 	d.LoadData(v)	
-	return fmt.Sprintf("%.4f", d.Eval())
+	return d.Eval()
 	
 }
 
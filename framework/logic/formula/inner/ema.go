@@ -9,7 +9,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 	"time"
 
 	"github.com/wonderstone/QuantKit/framework/entity/formula"
@@ -31,12 +31,16 @@ func (e *EMA) DoInit(f config.Formula) {
 	e.N = config.MustGetParamInt(f.Param, "N")
 }
 func (e *EMA) DoCalculate(tm time.Time, data dataframe.RecordFunc) string {
+	return formula.FormatFloat(e.DoCalculateFloat(tm, data))
+}
+
+func (e *EMA) DoCalculateFloat(tm time.Time, data dataframe.RecordFunc) float64 {
 	v, err := dataframe.TryConvertToFloat(data, e.Base)
 	if err != nil {
-		return ""
+		return math.NaN()
 	}
 	e.LoadData(v)
-	return fmt.Sprintf("%.4f", e.Eval())
+	return e.Eval()
 }
 
 func (e *EMA) DoReset() {
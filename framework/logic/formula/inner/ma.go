@@ -9,7 +9,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 	"strconv"
 	"time"
 
@@ -47,18 +47,22 @@ func (m *MA) DoInit(f config.Formula) {
 }
 
 func (m *MA) DoCalculate(tm time.Time, row dataframe.RecordFunc) string {
+	return formula.FormatFloat(m.DoCalculateFloat(tm, row))
+}
+
+func (m *MA) DoCalculateFloat(tm time.Time, row dataframe.RecordFunc) float64 {
 	// m.LoadData(dataframe.ConvertToFloat(row, m.Base))
 	v, err := dataframe.TryConvertToFloat(row, m.Base)
 	if err != nil {
-		return ""
+		return math.NaN()
 	} else {
 		m.LoadData(v)
 	}
 	// 如果数据不够，不计算，只更新累计值
 	if !m.DQ.Full() {
-		return ""
+		return math.NaN()
 	}
-	return fmt.Sprintf("%.4f", m.Eval())
+	return m.Eval()
 }
 
 func (m *MA) DoReset() {
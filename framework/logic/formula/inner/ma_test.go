@@ -10,8 +10,11 @@ package indicator
 
 import (
 	"fmt"
+	"math"
 	"testing"
 	"time"
+
+	"github.com/wonderstone/QuantKit/framework/entity/formula"
 )
 
 // func to test ma.go
@@ -54,4 +57,22 @@ func TestMA(t *testing.T) {
 		t.Error("MA.DoCalculate() error")
 	}
 
-}
\ No newline at end of file
+}
+
+// 数值结果与字符串结果一致，数据不足时为NaN
+func TestMAFloat(t *testing.T) {
+	ma := NewMA("MA", 2, "Close")
+	tr := &tmpRecordFunc{Data: []string{"1.0"}, Header: map[string]int{"Close": 0}}
+	if v := ma.DoCalculateFloat(time.Now(), tr); !math.IsNaN(v) {
+		t.Errorf("MA.DoCalculateFloat() = %v, want NaN", v)
+	}
+
+	tr.Data = []string{"2.5"}
+	if v := ma.DoCalculateFloat(time.Now(), tr); v != 1.75 {
+		t.Errorf("MA.DoCalculateFloat() = %v, want 1.75", v)
+	}
+
+	if _, ok := formula.Formula(ma).(formula.FloatFormula); !ok {
+		t.Error("MA 没有实现 FloatFormula")
+	}
+}
@@ -8,7 +8,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 	"time"
 
 	"github.com/wonderstone/QuantKit/framework/entity/formula"
@@ -34,13 +34,17 @@ func (m *MACD) DoInit(f config.Formula) {
 }
 
 func (m *MACD) DoCalculate(tm time.Time, data dataframe.RecordFunc) string {
+	return formula.FormatFloat(m.DoCalculateFloat(tm, data))
+}
+
+func (m *MACD) DoCalculateFloat(tm time.Time, data dataframe.RecordFunc) float64 {
 	// m.LoadData(dataframe.ConvertToFloat(data, m.Base))
 	v, err := dataframe.TryConvertToFloat(data, m.Base)
 	if err != nil {
-		return ""
+		return math.NaN()
 	}
 	m.LoadData(v)
-	return fmt.Sprintf("%.4f", m.Eval())
+	return m.Eval()
 }
 
 func (m *MACD) DoReset() {
@@ -9,7 +9,7 @@
 package indicator
 
 import (
-	"fmt"
+	"math"
 	"time"
 
 	"github.com/wonderstone/QuantKit/framework/entity/formula"
@@ -37,22 +37,26 @@ func (r *Ref) DoInit(f config.Formula) {
 }
 
 func (r *Ref) DoCalculate(tm time.Time, data dataframe.RecordFunc) string {
+	return formula.FormatFloat(r.DoCalculateFloat(tm, data))
+}
+
+func (r *Ref) DoCalculateFloat(tm time.Time, data dataframe.RecordFunc) float64 {
 	if tm != r.t {
 		r.t = tm
 		// 原则上一般数据指标计算都有值。空值问题来自于ref指标。
 		// 此处留下ref作为ref输入的可能性通畅吧
 		v, err := dataframe.TryConvertToFloat(data, r.Base)
 		if err != nil {
-			return ""
+			return math.NaN()
 		} else {
 			r.LoadData(v)
 		}
 	}
 	// 如果队列是满的，那么就返回队列的头部
 	if r.DQ.Full() {
-		return fmt.Sprintf("%.4f", r.Eval())
+		return r.Eval()
 	} else {
-		return ""
+		return math.NaN()
 	}
 }
 
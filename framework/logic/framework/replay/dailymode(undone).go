@@ -5,7 +5,9 @@ import (
 
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/entity/formula"
 	account2 "github.com/wonderstone/QuantKit/framework/logic/account"
+	"github.com/wonderstone/QuantKit/framework/logic/indicator"
 
 	"github.com/wonderstone/QuantKit/framework/logic/perfeval"
 	"github.com/wonderstone/QuantKit/framework/setting"
@@ -14,7 +16,6 @@ import (
 	"github.com/wonderstone/QuantKit/modelgene/gep/model"
 	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
-	"github.com/wonderstone/QuantKit/tools/perf"
 	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
@@ -40,7 +41,7 @@ type DailyMode struct {
 
 	currIndicators *orderedmap.OrderedMap[string, dataframe.StreamingRecord]
 
-	trainRecorder *recorder.MemoryRecorder[recorder.AssetRecord] // 记录训练结果，用于计算最终的结果
+	train perfeval.TrainRecords // 训练模式的评估数据
 	// OrderRecorder *recorder.MemoryRecorder[account.OrderRecord] // 记录训练结果，用于计算最终的结果
 
 	account handler.Accounts
@@ -57,9 +58,12 @@ type DailyMode struct {
 	nextSettleTime time.Time // 下次结算时间
 	beginTime      time.Time // 启动时间
 	endTime        time.Time // 停止时间
+	warmupTime     time.Time // 指标预热开始时间，为空时不预热
 
 	ch *handler.Channel
 
+	calc indicator.StreamLoadCalculator
+
 	finish          chan bool
 	processChan     chan float64
 	enableStatusLog bool
@@ -109,13 +113,16 @@ func (b *DailyMode) GetPerformance() (result float64) {
 		config.ErrorF("非训练的模式下，无法计算回测结果")
 	}
 
-	pe := perfeval.NewPerfEval(b.trainRecorder.GetRecord(), true)
-	result = pe.CalcPerfEvalResult(
-		perfeval.WithPerformanceIndicateType(perf.IndicateType(b.Config().Performance.PerformanceType)),
-		perfeval.WithRiskFreeRate(b.Config().Performance.RiskFreeRate),
-	)
+	return b.train.Performance()
+}
 
-	return
+// GetObjectives 按配置的训练目标顺序获取各指标结果
+func (b *DailyMode) GetObjectives() []float64 {
+	if !b.trainMode {
+		config.ErrorF("非训练的模式下，无法计算回测结果")
+	}
+
+	return b.train.Objectives()
 }
 
 func (b *DailyMode) CurrTime() *time.Time {
@@ -156,6 +163,11 @@ func (b *DailyMode) Run() {
 			}
 
 			if d.Key.Before(b.beginTime) {
+				// 预热: 开始日期以前的行情只用于计算指标
+				if !b.warmupTime.IsZero() && !d.Key.Before(b.warmupTime) {
+					b.calc.Calculate(d.Key, *d.Value)
+					b.calc.DoSettle(settleTime(d.Key), b.Resource.Base())
+				}
 				continue
 			}
 
@@ -178,7 +190,9 @@ func (b *DailyMode) Run() {
 			// 设置当前时间
 			b.currTime = d.Key
 
-			orders := b.strategy.OnTick(b, d.Key, *d.Value)
+			// 计算指标
+			indicate := b.calc.Calculate(d.Key, *d.Value)
+			orders := b.strategy.OnTick(b, d.Key, indicate)
 			for _, o := range orders {
 				err := b.Account().InsertOrder(o)
 				if err != nil {
@@ -197,12 +211,20 @@ func (b *DailyMode) Run() {
 			b.account.CalcPositionPnL(d.Key, *d.Value)
 
 			b.strategy.OnDailyClose(b, b.Account().GetAccounts())
+			b.currIndicators = d.Value
 			b.account.DoSettle(b.currTime, b.currIndicators)
+			// @ 进行指标计算结算
+			b.calc.DoSettle(settleTime(d.Key), b.Resource.Base())
 
 		}
 	}
 }
 
+// settleTime 当日的结算时间
+func settleTime(tm time.Time) time.Time {
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), 16, 0, 0, 0, tm.Location())
+}
+
 func (b *DailyMode) IsFinished() bool {
 	select {
 	case v, ok := <-b.finish: // 从ch1接收数据
@@ -236,7 +258,7 @@ func (b *DailyMode) Init(resource setting.Resource, options ...setting.WithResou
 		return model.OutputValues(values)
 	}
 
-	if b.Config().Mode == config.TrainMode {
+	if b.Config().Mode == config.TrainMode || b.Config().Mode == config.WalkForwardMode {
 		b.trainMode = true
 	} else {
 		b.trainMode = false
@@ -244,6 +266,7 @@ func (b *DailyMode) Init(resource setting.Resource, options ...setting.WithResou
 
 	b.beginTime = b.Config().Framework.Begin
 	b.endTime = b.Config().Framework.End.Add(time.Hour * 17)
+	b.warmupTime = b.Config().Framework.Warmup
 
 	// 设置一下开盘时间
 	b.currTime = b.beginTime.Add(time.Hour * 8)
@@ -268,19 +291,17 @@ func (b *DailyMode) Init(resource setting.Resource, options ...setting.WithResou
 			recorder.WithFilePath(b.Dir().OrderResultFile),
 		)
 
-		b.trainRecorder = recorder.NewMemoryRecorder[recorder.AssetRecord]()
 		err := b.account.Init(b, recorders)
 		if err != nil {
 			return err
 		}
 	} else {
-		recorders := make(map[config.RecordType]recorder.Handler)
-		b.trainRecorder = recorder.NewMemoryRecorder[recorder.AssetRecord]()
-
-		// 只记录资产
-		recorders[config.RecordTypeAsset] = b.trainRecorder
+		recorders, err := b.train.Recorders(b.Config().Performance, b.Dir().Base)
+		if err != nil {
+			return err
+		}
 
-		err := b.account.Init(b, recorders)
+		err = b.account.Init(b, recorders)
 		if err != nil {
 			return err
 		}
@@ -294,7 +315,10 @@ func (b *DailyMode) Init(resource setting.Resource, options ...setting.WithResou
 	)
 
 	b.finish = make(chan bool, 1)
-	return nil
+
+	// 指标计算模块加载
+	b.calc = indicator.StreamLoadCalculator{}
+	return b.calc.Init(formula.WithRuntime(*b.Config()))
 }
 
 func (b *DailyMode) SetStrategy(strategy handler.Strategy) {
@@ -10,6 +10,7 @@ import (
 
 	account2 "github.com/wonderstone/QuantKit/framework/logic/account"
 	"github.com/wonderstone/QuantKit/framework/logic/indicator"
+	"github.com/wonderstone/QuantKit/framework/logic/quote"
 	"github.com/wonderstone/QuantKit/framework/logic/perfeval"
 	"github.com/wonderstone/QuantKit/framework/setting"
 	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
@@ -17,7 +18,6 @@ import (
 	"github.com/wonderstone/QuantKit/modelgene/gep/model"
 	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
-	"github.com/wonderstone/QuantKit/tools/perf"
 	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
@@ -28,7 +28,7 @@ type NextMode struct {
 
 	currCloseTick orderedmap.OrderedMap[string, dataframe.StreamingRecord]
 
-	trainRecorder *recorder.MemoryRecorder[recorder.AssetRecord] // 记录训练结果，用于计算最终的结果
+	train perfeval.TrainRecords // 训练模式的评估数据
 
 	account handler.Accounts
 	matcher handler.Matcher
@@ -50,6 +50,8 @@ type NextMode struct {
 
 	calc indicator.StreamLoadCalculator
 
+	secondary *quote.Secondary // 辅助频率行情，未配置时为nil
+
 	finish      chan bool
 	processChan chan float64
 }
@@ -98,12 +100,16 @@ func (b *NextMode) GetPerformance() (result float64) {
 		config.ErrorF("非训练的模式下，无法计算回测结果")
 	}
 
-	pe := perfeval.NewPerfEval(b.trainRecorder.GetRecord(), true)
-	result = pe.CalcPerfEvalResult(
-		perfeval.WithPerformanceIndicateType(perf.IndicateType(b.Config().Performance.PerformanceType)),
-		perfeval.WithRiskFreeRate(b.Config().Performance.RiskFreeRate),
-	)
-	return
+	return b.train.Performance()
+}
+
+// GetObjectives 按配置的训练目标顺序获取各指标结果
+func (b *NextMode) GetObjectives() []float64 {
+	if !b.trainMode {
+		config.ErrorF("非训练的模式下，无法计算回测结果")
+	}
+
+	return b.train.Objectives()
 }
 
 func (b *NextMode) CurrTime() *time.Time {
@@ -117,6 +123,15 @@ func (b *NextMode) CurrDate() time.Time {
 	)
 }
 
+// Bars 辅助频率各合约最近一根已完成的K线，key: instID
+func (b *NextMode) Bars(freq config.Frequency) (orderedmap.OrderedMap[string, dataframe.StreamingRecord], bool) {
+	if b.secondary == nil {
+		return *orderedmap.New[string, dataframe.StreamingRecord](), false
+	}
+
+	return b.secondary.Bars(freq)
+}
+
 func (b *NextMode) SubscribeData() {
 	b.ch = b.Quote().Subscribe()
 }
@@ -135,6 +150,7 @@ func (b *NextMode) Run() {
 				b.currTime = b.nextSettleTime
 
 				b.account.DoSettle(b.currTime, &b.currCloseTick)
+				b.account.DispatchOrderEvents(b.strategy)
 				b.strategy.OnDailyClose(b, b.Account().GetAccounts())
 
 				// 结束释放资源
@@ -163,6 +179,11 @@ func (b *NextMode) Run() {
 				)
 
 				b.strategy.OnDailyOpen(b, config.MarketTypeStock, b.Account().GetAccount(config.MarketTypeStock)...)
+				if accs := b.Account().GetAccount(config.MarketTypeFuture); len(accs) > 0 {
+					b.strategy.OnDailyOpen(b, config.MarketTypeFuture, accs...)
+				}
+
+				b.account.DispatchOrderEvents(b.strategy)
 
 				b.nextMarketOpenTime = time.Date(
 					d.Key.Year(),
@@ -178,9 +199,11 @@ func (b *NextMode) Run() {
 				b.currTime = b.nextSettleTime
 
 				b.account.DoSettle(b.currTime, &b.currCloseTick)
+				b.account.DispatchOrderEvents(b.strategy)
 				// @ 进行指标计算结算
 				b.calc.DoSettle(b.currTime, b.Resource.Base())
 				b.strategy.OnDailyClose(b, b.Account().GetAccounts())
+				b.account.DispatchOrderEvents(b.strategy)
 
 				b.nextSettleTime = time.Date(
 					d.Key.Year(),
@@ -204,10 +227,23 @@ func (b *NextMode) Run() {
 
 			// 计算当前持仓的指标
 			b.account.CalcPositionPnL(d.Key, *d.Value)
+			// 回调撮合产生的订单回报
+			b.account.DispatchOrderEvents(b.strategy)
+
+			// 熔断后不再运行策略
+			if b.account.Halted() {
+				b.currCloseTick = *d.Value
+				continue
+			}
 
 			if b.Config().Framework.Frequency != config.Frequency1Day || b.CurrDate().Add(b.Config().Framework.DailyTriggerTime).Equal(d.Key) {
 				// 计算指标
 				indicate := b.calc.Calculate(d.Key, *d.Value)
+				// 辅助频率只提供已完成的K线
+				if b.secondary != nil {
+					b.secondary.Advance(d.Key)
+					b.secondary.Merge(indicate)
+				}
 
 				orders := b.strategy.OnTick(b, d.Key, indicate)
 				for _, o := range orders {
@@ -221,6 +257,7 @@ func (b *NextMode) Run() {
 						)
 					}
 				}
+				b.account.DispatchOrderEvents(b.strategy)
 			}
 
 			b.currCloseTick = *d.Value
@@ -260,7 +297,8 @@ func (b *NextMode) Init(resource setting.Resource, options ...setting.WithResour
 		return model.OutputValues(values)
 	}
 
-	if b.Config().Mode == config.TrainMode {
+	// 滚动训练的训练段和验证段都只在内存中记录
+	if b.Config().Mode == config.TrainMode || b.Config().Mode == config.WalkForwardMode {
 		b.trainMode = true
 	} else {
 		b.trainMode = false
@@ -293,18 +331,27 @@ func (b *NextMode) Init(resource setting.Resource, options ...setting.WithResour
 			// recorder.WithPlusMode(),
 		)
 
+		recorders[config.RecordTypeTrade] = recorder.NewRecorder[recorder.TradeRecord](
+			b.Config().System.RecordHandlerType,
+			recorder.WithFilePath(b.Dir().TradeResultFile),
+		)
+
+		recorders[config.RecordTypeRoundTrip] = recorder.NewRecorder[recorder.RoundTripRecord](
+			b.Config().System.RecordHandlerType,
+			recorder.WithFilePath(b.Dir().RoundTripResultFile),
+		)
+
 		err := b.account.Init(b, recorders)
 		if err != nil {
 			return err
 		}
 	} else {
-		recorders := make(map[config.RecordType]recorder.Handler)
-		b.trainRecorder = recorder.NewMemoryRecorder[recorder.AssetRecord]()
-
-		// 只记录资产
-		recorders[config.RecordTypeAsset] = b.trainRecorder
+		recorders, err := b.train.Recorders(b.Config().Performance, b.Dir().Base)
+		if err != nil {
+			return err
+		}
 
-		err := b.account.Init(b, recorders)
+		err = b.account.Init(b, recorders)
 		if err != nil {
 			return err
 		}
@@ -313,7 +360,16 @@ func (b *NextMode) Init(resource setting.Resource, options ...setting.WithResour
 	stockSlippage := b.Config().Framework.Stock.Slippage
 	futureSlippage := b.Config().Framework.Future.Slippage
 
-	b.matcher = &matcher2.NextQuote{StockSlippage: stockSlippage, FutureSlippage: futureSlippage}
+	if matcherType := b.Config().System.ReplayMatcher; matcherType != "" && matcherType != config.HandlerTypeDefault {
+		b.matcher = setting.MustNewMatcher(
+			matcherType,
+			handler.WithStockSlippage(stockSlippage),
+			handler.WithFutureSlippage(futureSlippage),
+			handler.WithConfig(b.Config().Framework),
+		)
+	} else {
+		b.matcher = &matcher2.NextQuote{StockSlippage: stockSlippage, FutureSlippage: futureSlippage}
+	}
 	b.finish = make(chan bool, 1)
 
 	// 指标计算模块加载
@@ -323,6 +379,17 @@ func (b *NextMode) Init(resource setting.Resource, options ...setting.WithResour
 		return err
 	}
 
+	// 辅助频率行情由回放行情提供
+	if freqs := b.Config().Framework.Secondary; len(freqs) > 0 {
+		if provider, ok := b.Quote().(quote.SecondaryProvider); ok {
+			b.secondary = provider.Secondary()
+		}
+
+		if b.secondary == nil {
+			config.WarnF("行情处理器不支持辅助频率 %v", freqs)
+		}
+	}
+
 	return nil
 }
 
@@ -1,7 +1,6 @@
 package indicator
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -9,6 +8,7 @@ import (
 
 	"github.com/wonderstone/QuantKit/framework/entity/formula"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/quote"
 	_ "github.com/wonderstone/QuantKit/framework/logic/formula/inner"
 	"github.com/wonderstone/QuantKit/tools/container/btree"
 
@@ -16,6 +16,8 @@ import (
 	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dag"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/math"
+	"github.com/wonderstone/QuantKit/tools/resample"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
@@ -25,6 +27,7 @@ type Cell struct {
 	NodeID  int64 // node id
 	Config  *config.Formula
 	Formula formula.Formula
+	Float   formula.FloatFormula // 公式支持直接返回数值时不为nil
 }
 
 func (c Cell) ID() int64 {
@@ -60,6 +63,9 @@ type StreamLoadCalculator struct {
 	xrxd map[string]*btree.MapIterG[time.Time, *config.Xrxd]
 
 	settleTimeQueue map[time.Time][]string
+
+	// 前复权模式: 使用行情附带的复权因子调整价格和成交量后再计算指标，除权日不再重算历史
+	preAdjust bool
 }
 
 func (f *StreamLoadCalculator) NewCell(conf config.Formula) (cell *Cell) {
@@ -135,8 +141,14 @@ func (f *StreamLoadCalculator) Init(option ...formula.WithOption) error {
 	op := formula.NewOp(option...)
 
 	f.config = op.Config
+	f.preAdjust = op.Config.System.DataType == config.HandlerTypePreXrxdMode
 
-	f.SetSourceDataPath(filepath.Join(op.Config.Path.Download, string(op.Config.Framework.Frequency)))
+	// 行情目录不存在时由回放合成行情，检查可合成该频率的小周期行情
+	dir, _, err := resample.Locate(op.Config.Path.Download, op.Config.Framework.Frequency)
+	if err != nil {
+		dir = filepath.Join(op.Config.Path.Download, string(op.Config.Framework.Frequency))
+	}
+	f.SetSourceDataPath(dir)
 
 	f.buildDAG(*op.Config.Indicator)
 
@@ -148,22 +160,36 @@ func (f *StreamLoadCalculator) Init(option ...formula.WithOption) error {
 		}
 
 		// 构建拓扑排序
+		headers := make(map[string]int, len(f.sortedNodes))
+		for i, node := range f.sortedNodes {
+			headers[node.(*Cell).Config.Name] = i
+		}
+		// 附带停牌标记，策略据此区分停牌和未订阅
+		if _, ok := headers[config.SuspendedField]; !ok {
+			headers[config.SuspendedField] = len(headers)
+		}
+		// 辅助频率K线的列，由框架按主频率的时间写入
+		for _, freq := range op.Config.Framework.Secondary {
+			for _, field := range config.SecondaryFields {
+				if _, ok := headers[freq.Field(field)]; !ok {
+					headers[freq.Field(field)] = len(headers)
+				}
+			}
+		}
+
 		g := &StreamCalcGraph{
 			calculator: f,
 			instID:     inst,
-			record: dataframe.StreamingRecord{
-				Data:    make([]string, len(f.sortedNodes)),
-				Headers: make(map[string]int),
-			},
+			record:     dataframe.NewEmptyStreamingRecord(headers),
 		}
 
-		for i, node := range f.sortedNodes {
-			g.record.Headers[node.(*Cell).Config.Name] = i
+		for _, node := range f.sortedNodes {
 			cell := *node.(*Cell)
 			if cell.Config.Func != "" {
 				cell.Formula = formula.NewFormula(cell.Config.Func)
 				cell.Config.InstID = g.instID
 				cell.Formula.DoInit(*cell.Config)
+				cell.Float, _ = cell.Formula.(formula.FloatFormula)
 			}
 			g.calcCell = append(g.calcCell, cell)
 		}
@@ -179,7 +205,7 @@ func (f *StreamCalcGraph) GetColumns() map[string]int {
 }
 
 func (f *StreamCalcGraph) GetIndicator(name string, row int) float64 {
-	return f.record.ConvertToFloat(name)
+	return f.record.Float(name)
 }
 
 func (f *StreamLoadCalculator) SetSourceDataPath(dir string) {
@@ -195,7 +221,9 @@ func (g *StreamCalcGraph) calcInstIDOneLine(
 ) {
 	for _, cell := range g.calcCell {
 		if cell.Config.Func == "" {
-			g.record.Update(cell.Config.Name, record.Val(cell.Config.Name))
+			g.record.Copy(cell.Config.Name, record)
+		} else if cell.Float != nil {
+			g.record.Set(cell.Config.Name, cell.Float.DoCalculateFloat(tm, g.record))
 		} else {
 			g.record.Update(cell.Config.Name, cell.Formula.DoCalculate(tm, g.record))
 		}
@@ -210,15 +238,24 @@ func (f *StreamLoadCalculator) Calculate(
 	records := orderedmap.New[string, dataframe.StreamingRecord]()
 	for curr := indicators.Oldest(); curr != nil; curr = curr.Next() {
 		instID := curr.Key
-		var quoteRecord dataframe.StreamingRecord
-		quoteRecord.Headers = curr.Value.Headers
-		quoteRecord.Data = make([]string, len(curr.Value.Data))
-		copy(quoteRecord.Data, curr.Value.Data)
+		quoteRecord := curr.Value.Clone()
 		g := f.inst2Graph[instID]
-		// 记录一下目前的合约行情，用于计算除权除息
-		g.quotes = append(g.quotes, quoteRecord)
+		// 停牌补齐的K线不参与指标计算，沿用停牌前的指标值
+		if quoteRecord.Suspended() {
+			g.record.Set(config.SuspendedField, 1)
+			records.Set(instID, g.record)
+			continue
+		}
+
+		if f.preAdjust {
+			quote.ForwardAdjust(quoteRecord)
+		} else {
+			// 记录一下目前的合约行情，用于计算除权除息
+			g.quotes = append(g.quotes, quoteRecord)
+		}
 
 		g.calcInstIDOneLine(tm, quoteRecord)
+		g.record.Set(config.SuspendedField, 0)
 
 		records.Set(instID, g.record)
 	}
@@ -254,25 +291,13 @@ func (v *StreamCalcGraph) doSettle(tm time.Time, exFactor float64) {
 		}
 	}
 
-	for i, quote := range v.quotes {
-		v.quotes[i].Update(
-			"Close", fmt.Sprintf("%.2f", quote.ConvertToFloat("Close")*exFactor),
-		)
-		v.quotes[i].Update(
-			"Open", fmt.Sprintf("%.2f", quote.ConvertToFloat("Open")*exFactor),
-		)
-		v.quotes[i].Update(
-			"High", fmt.Sprintf("%.2f", quote.ConvertToFloat("High")*exFactor),
-		)
-		v.quotes[i].Update(
-			"Low", fmt.Sprintf("%.2f", quote.ConvertToFloat("Low")*exFactor),
-		)
-		v.quotes[i].Update(
-			"Volume", fmt.Sprintf("%.2f", quote.ConvertToFloat("Volume")/exFactor),
-		)
-		v.quotes[i].Update(
-			"Amount", fmt.Sprintf("%.2f", quote.ConvertToFloat("Amount")/exFactor),
-		)
+	for _, quote := range v.quotes {
+		for _, field := range []string{"Close", "Open", "High", "Low"} {
+			quote.Set(field, math.Round(quote.Float(field)*exFactor, 2))
+		}
+		for _, field := range []string{"Volume", "Amount"} {
+			quote.Set(field, math.Round(quote.Float(field)/exFactor, 2))
+		}
 	}
 
 	for _, quoteRecord := range v.quotes {
@@ -284,6 +309,11 @@ func (f *StreamLoadCalculator) DoSettle(
 	tm time.Time,
 	base handler.Basic,
 ) {
+	// 前复权数据已经包含了全部除权除息，不需要重算历史
+	if f.preAdjust {
+		return
+	}
+
 	// 获取除权除息数据
 	if base.NeedReload() {
 		f.getXrxds(base, tm)
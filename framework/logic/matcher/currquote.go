@@ -6,25 +6,33 @@ import (
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
 	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
 )
 
 type CurrQuote struct {
 	StockSlippage  float64 // 滑点
 	FutureSlippage float64 // 滑点
+
+	quotes closes // 上一交易日收盘价，用于行情没有昨收价时计算涨跌停
 }
 
-func newCurrOp() *handler.MatcherOp {
+func newCurrOp(opts ...handler.WithMatcherOption) *handler.MatcherOp {
 	op := &handler.MatcherOp{}
 
+	for _, option := range opts {
+		option(op)
+	}
+
 	return op
 }
 
 func (c *CurrQuote) Init(opts ...handler.WithMatcherOption) handler.Matcher {
-	op := newCurrOp()
+	op := newCurrOp(opts...)
 	return &CurrQuote{
 		StockSlippage:  op.StockSlippage,
 		FutureSlippage: op.FutureSlippage,
+		quotes:         make(closes),
 	}
 }
 
@@ -33,7 +41,17 @@ func (c *CurrQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 	if order.IsExecuted() {
 		return
 	}
-	matchPrice := indicate.ConvertToFloat("Close")
+	// 停牌拒单，一字涨跌停不撮合
+	if rejectSuspended(order, indicate, matchTime) || !tradable(order.Contract(), order.OrderDirection(), indicate, matchTime, c.quotes) {
+		return
+	}
+
+	matchPrice := basePrice(order, indicate, "Close", matchTime)
+	qty := closeQty(order, order.OrderQty()-order.TradeQty())
+	if qty <= 0 {
+		return
+	}
+
 	// 目前是使用的是本根K线的收盘作为成交价
 	switch order.OrderDirection() {
 	case config.OrderBuy:
@@ -45,7 +63,7 @@ func (c *CurrQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 				matchPrice,
 				c.StockSlippage,
 				order.OrderDirection()),
-			order.OrderQty(), matchTime, nil,
+			qty, matchTime, nil,
 		)
 		// } else {
 		// 	order.DoCancelUpdate(matchTime)
@@ -59,7 +77,7 @@ func (c *CurrQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 				matchPrice,
 				c.StockSlippage,
 				order.OrderDirection()),
-			order.OrderQty(), matchTime, nil,
+			qty, matchTime, nil,
 		)
 		// } else {
 		// 	order.DoCancelUpdate(matchTime)
@@ -67,6 +85,17 @@ func (c *CurrQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 	}
 }
 
+// ObserveQuote 记录每根K线的收盘价，用于计算下一交易日的涨跌停
+func (c *CurrQuote) ObserveQuote(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) {
+	if c.quotes == nil {
+		c.quotes = make(closes)
+	}
+
+	c.quotes.observe(tm, indicators)
+}
+
 func init() {
 	setting.RegisterMatcher(
 		new(CurrQuote),
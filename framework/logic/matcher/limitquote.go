@@ -0,0 +1,260 @@
+package matcher
+
+import (
+	"math"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	math2 "github.com/wonderstone/QuantKit/tools/math"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// + LimitQuote 限价量能撮合器，使用下一根K线撮合:
+// + 市价单以开盘价(含滑点)成交
+// + 限价买单在最低价不高于委托价时成交，成交价为 min(开盘价, 委托价)；限价卖单对称使用最高价
+// + 单根K线的成交量不超过该K线成交量 * VolumeRatio，剩余部分保持部分成交状态，留待下一根K线继续撮合
+// + 委托价超出涨跌停范围的订单直接拒单，涨停(跌停)封板的K线不能买入(卖出)，停牌的K线不撮合
+// + 涨跌停幅度未配置时按合约的板块规则计算，合约没有交易规则时默认为10%
+// + 平仓(卖出)数量不超过持仓按合约T+N计算的可卖数量
+// + 未成交订单在闭市结算时由订单自身过期
+// + 条件单在本根K线触发时，以触发价代替开盘价
+
+const (
+	defaultVolumeRatio    = 1.0 // 默认可成交量占比
+	defaultPriceLimitRate = 0.1 // 合约没有交易规则时的默认涨跌停幅度
+)
+
+type limitParam struct {
+	slippage       float64 // 滑点
+	volumeRatio    float64 // 可成交量占比
+	priceLimitRate float64 // 涨跌停幅度, 0表示按合约规则, 小于0表示不限制
+}
+
+func newLimitParam(slippage float64, acc config.Account) limitParam {
+	p := limitParam{
+		slippage:       slippage,
+		volumeRatio:    acc.VolumeRatio,
+		priceLimitRate: acc.PriceLimitRate,
+	}
+
+	if p.volumeRatio <= 0 {
+		p.volumeRatio = defaultVolumeRatio
+	}
+
+	return p
+}
+
+// volumeUsed 单根K线已经撮合的成交量
+type volumeUsed struct {
+	tm  time.Time
+	qty float64
+}
+
+type LimitQuote struct {
+	Stock  limitParam
+	Future limitParam
+
+	quotes closes
+	used   map[string]*volumeUsed
+}
+
+func (l *LimitQuote) Init(opts ...handler.WithMatcherOption) handler.Matcher {
+	op := newNextOp(opts...)
+	return &LimitQuote{
+		Stock:  newLimitParam(op.StockSlippage, op.Config().Stock),
+		Future: newLimitParam(op.FutureSlippage, op.Config().Future),
+		quotes: make(closes),
+		used:   make(map[string]*volumeUsed),
+	}
+}
+
+func (l *LimitQuote) param(c contract.Contract) limitParam {
+	if c.GetAccountType() == config.AccountTypeFuture {
+		return l.Future
+	}
+
+	return l.Stock
+}
+
+// basePrice 成交基准价，条件单在本根K线触发时使用触发价代替行情字段
+func basePrice(order handler.Order, indicate dataframe.StreamingRecord, name string, matchTime time.Time) float64 {
+	if c, ok := order.(handler.ConditionalOrder); ok &&
+		c.TriggerState() == config.TriggerFired && c.TriggerTime().Equal(matchTime) {
+		return c.TriggerPrice()
+	}
+
+	return indicate.ConvertToFloat(name)
+}
+
+// priceLimit 计算涨跌停价，优先使用行情中的涨跌停价，其次使用昨收价 * (1 ± 涨跌停幅度)
+// 未配置涨跌停幅度时按合约的板块规则计算
+func (l *LimitQuote) priceLimit(
+	c contract.Contract, indicate dataframe.StreamingRecord, matchTime time.Time, rate float64,
+) (lower, upper float64, ok bool) {
+	if lower, upper, ok := quoteLimit(indicate); ok {
+		return lower, upper, true
+	}
+
+	if rate < 0 {
+		return 0, 0, false
+	}
+
+	prevClose, okPrev := l.quotes.prevClose(c.GetInstID(), indicate, matchTime)
+	if !okPrev || prevClose <= 0 {
+		return 0, 0, false
+	}
+
+	if rate == 0 {
+		if _, isRule := c.(contract.Rule); isRule {
+			return ruleLimit(c, indicate, matchTime, prevClose)
+		}
+
+		rate = defaultPriceLimitRate
+	}
+
+	return math2.Round(prevClose*(1-rate), 2), math2.Round(prevClose*(1+rate), 2), true
+}
+
+// available 本根K线剩余可成交数量
+func (l *LimitQuote) available(
+	instID string, indicate dataframe.StreamingRecord, matchTime time.Time, ratio float64,
+) float64 {
+	volume, ok := indicate.Get("Volume")
+	if !ok {
+		return math.MaxFloat64
+	}
+
+	u, ok := l.used[instID]
+	if !ok || !u.tm.Equal(matchTime) {
+		u = &volumeUsed{tm: matchTime}
+		l.used[instID] = u
+	}
+
+	return volume*ratio - u.qty
+}
+
+// closeQty 平仓订单按持仓可卖(可平)数量限制成交数量，开仓订单不限制
+// 可卖数量已经扣除了本订单冻结的部分，需要加回本订单剩余数量
+func closeQty(order handler.Order, qty float64) float64 {
+	if order.TransactionType() == config.OffsetOpen {
+		return qty
+	}
+
+	pos, ok := order.Account().GetPositionByInstID(order.InstID())
+	if !ok {
+		return 0
+	}
+
+	sellable := pos.Volume(
+		account.WithDirection(order.PositionDirection()), account.WithSellAvailable(true),
+	) + order.OrderQty() - order.TradeQty()
+	if sellable >= qty {
+		return qty
+	}
+
+	if order.Contract().GetAccountType() == config.AccountTypeFuture {
+		return math.Floor(sellable)
+	}
+
+	return sellable
+}
+
+func (l *LimitQuote) MatchOrder(order handler.Order, indicate dataframe.StreamingRecord, matchTime time.Time) {
+	// 如果订单已经结束，则不再处理
+	if order.IsExecuted() {
+		return
+	}
+
+	// 停牌拒单，成交量为0不撮合
+	if rejectSuspended(order, indicate, matchTime) || suspended(indicate) {
+		return
+	}
+
+	c := order.Contract()
+	p := l.param(c)
+	direction := order.OrderDirection()
+
+	open := basePrice(order, indicate, "Open", matchTime)
+	high, ok := indicate.Get("High")
+	if !ok {
+		high = open
+	}
+	low, ok := indicate.Get("Low")
+	if !ok {
+		low = open
+	}
+
+	lower, upper, limited := l.priceLimit(c, indicate, matchTime, p.priceLimitRate)
+
+	// 委托价格超出涨跌停范围，拒单并解冻
+	if limited && order.OrderStatus() == config.OrderStatusNew && order.OrderType() == config.OrderTypeLimit &&
+		(order.OrderPrice() > upper || order.OrderPrice() < lower) {
+		order.DoReject(matchTime, qk.ErrPriceLimit{Price: order.OrderPrice(), Lower: lower, Upper: upper})
+		order.Account().(handler.Account2).DoOrderUpdate(order)
+		return
+	}
+
+	// 涨停封板无法买入，跌停封板无法卖出
+	if limited && sealed(direction, indicate, lower, upper) {
+		return
+	}
+
+	// 计算成交价格
+	var matchPrice float64
+	switch {
+	case order.OrderType() != config.OrderTypeLimit:
+		matchPrice = c.CalcSlipPrice(open, p.slippage, direction)
+	case direction == config.OrderBuy:
+		if low > order.OrderPrice() {
+			return
+		}
+		matchPrice = math.Min(c.CalcSlipPrice(math.Min(open, order.OrderPrice()), p.slippage, direction), order.OrderPrice())
+	case direction == config.OrderSell:
+		if high < order.OrderPrice() {
+			return
+		}
+		matchPrice = math.Max(c.CalcSlipPrice(math.Max(open, order.OrderPrice()), p.slippage, direction), order.OrderPrice())
+	}
+
+	if limited {
+		matchPrice = math.Min(math.Max(matchPrice, lower), upper)
+	}
+
+	// 计算成交数量，受本根K线成交量限制
+	remain := order.OrderQty() - order.TradeQty()
+	qty := math.Min(closeQty(order, remain), l.available(order.InstID(), indicate, matchTime, p.volumeRatio))
+	if qty < remain {
+		if c.GetAccountType() == config.AccountTypeFuture {
+			qty = math.Floor(qty)
+		} else {
+			qty = c.CalcMaxQty(qty)
+		}
+	}
+
+	if qty <= 0 {
+		return
+	}
+
+	if u, ok := l.used[order.InstID()]; ok {
+		u.qty += qty
+	}
+
+	order.DoTradeUpdate(matchPrice, qty, matchTime, nil)
+}
+
+// ObserveQuote 记录每根K线的收盘价，用于计算下一交易日的涨跌停
+func (l *LimitQuote) ObserveQuote(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) {
+	l.quotes.observe(tm, indicators)
+}
+
+func init() {
+	setting.RegisterMatcher(new(LimitQuote), config.HandlerTypeLimitMatch)
+}
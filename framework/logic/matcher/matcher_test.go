@@ -1,11 +1,19 @@
 package matcher
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	_ "github.com/wonderstone/QuantKit/framework/logic/contract"
 	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
 func TestNewMatcher(t *testing.T) {
@@ -17,4 +25,245 @@ func TestNewMatcher(t *testing.T) {
 		t.Error("matcher is nil")
 	}
 
-}
\ No newline at end of file
+}
+
+type testContract struct {
+	contract.Contract
+}
+
+func (c testContract) GetInstID() string                                               { return "600000.XSHG.CS" }
+func (c testContract) GetAccountType() config.AccountType                              { return config.AccountTypeStockSimple }
+func (c testContract) CalcMaxQty(qty float64) float64                                  { return qty - math.Mod(qty, 100) }
+func (c testContract) CalcSlipPrice(price, _ float64, _ config.OrderDirection) float64 { return price }
+
+type testPosition struct {
+	account.Position
+	sellable float64
+}
+
+func (p testPosition) Volume(...account.WithOpFilterPos) float64 { return p.sellable }
+
+// testAccount 卖出时按positions返回持仓，没有持仓的标的不能卖出
+type testAccount struct {
+	handler.Account2
+	positions map[string]testPosition
+}
+
+func (a testAccount) DoOrderUpdate(handler.Order) {}
+
+func (a testAccount) GetPositionByInstID(instID string) (account.Position, bool) {
+	p, ok := a.positions[instID]
+	return p, ok
+}
+
+type testOrder struct {
+	handler.Order
+	direction config.OrderDirection
+	orderType config.OrderType
+	price     float64
+	qty       float64
+	tradeQty  float64
+	status    config.OrderStatus
+	trades    []float64
+	acc       testAccount
+	contract  contract.Contract
+}
+
+func (o *testOrder) IsExecuted() bool {
+	return o.status == config.OrderStatusDone || o.status == config.OrderStatusRejected
+}
+func (o *testOrder) Contract() contract.Contract {
+	if o.contract != nil {
+		return o.contract
+	}
+
+	return testContract{}
+}
+func (o *testOrder) InstID() string { return o.Contract().GetInstID() }
+func (o *testOrder) OrderDirection() config.OrderDirection { return o.direction }
+func (o *testOrder) OrderType() config.OrderType           { return o.orderType }
+func (o *testOrder) OrderPrice() float64                   { return o.price }
+func (o *testOrder) OrderQty() float64                     { return o.qty }
+func (o *testOrder) TradeQty() float64                     { return o.tradeQty }
+func (o *testOrder) OrderStatus() config.OrderStatus       { return o.status }
+func (o *testOrder) DoReject(time.Time, error)             { o.status = config.OrderStatusRejected }
+func (o *testOrder) RejectReason() error                   { return nil }
+func (o *testOrder) Account() account.Account              { return o.acc }
+
+func (o *testOrder) PositionDirection() config.PositionDirection { return config.PositionLong }
+
+func (o *testOrder) TransactionType() config.TransactionType {
+	if o.direction == config.OrderBuy {
+		return config.OffsetOpen
+	}
+
+	return config.OffsetClose
+}
+
+func (o *testOrder) DoTradeUpdate(price, qty float64, _ time.Time, _ recorder.Handler) {
+	o.tradeQty += qty
+	o.trades = append(o.trades, price)
+	if o.tradeQty >= o.qty {
+		o.status = config.OrderStatusDone
+	} else {
+		o.status = config.OrderStatusPartDone
+	}
+}
+
+func testBar(open, high, low, close, volume string) dataframe.StreamingRecord {
+	return dataframe.StreamingRecord{
+		Data:    []string{open, high, low, close, volume},
+		Headers: map[string]int{"Open": 0, "High": 1, "Low": 2, "Close": 3, "Volume": 4},
+	}
+}
+
+func TestLimitQuote(t *testing.T) {
+	cfg := config.Framework{Stock: config.Account{VolumeRatio: 0.5}}
+	mtch := new(LimitQuote).Init(handler.WithConfig(cfg)).(*LimitQuote)
+
+	day1 := time.Date(2023, 1, 3, 15, 0, 0, 0, time.Local)
+	day2 := time.Date(2023, 1, 4, 10, 0, 0, 0, time.Local)
+	day2Next := day2.Add(time.Hour)
+
+	bars := orderedmap.New[string, dataframe.StreamingRecord]()
+	bars.Set("600000.XSHG.CS", testBar("10", "10.2", "9.8", "10", "1000"))
+	mtch.ObserveQuote(day1, *bars)
+
+	// 限价买单低于最低价，不成交
+	buy := &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeLimit, price: 9.5, qty: 800, status: config.OrderStatusNew}
+	mtch.MatchOrder(buy, testBar("10.1", "10.5", "9.9", "10.3", "1000"), day2)
+	if buy.tradeQty != 0 {
+		t.Errorf("limit buy below low should not trade, got %v", buy.tradeQty)
+	}
+
+	// 限价买单按 min(开盘价, 委托价) 成交，成交量不超过K线成交量的一半
+	buy.price = 10
+	mtch.MatchOrder(buy, testBar("10.1", "10.5", "9.9", "10.3", "1000"), day2)
+	if buy.tradeQty != 500 || buy.trades[0] != 10 || buy.status != config.OrderStatusPartDone {
+		t.Errorf("want part done 500@10, got %v@%v %v", buy.tradeQty, buy.trades, buy.status)
+	}
+
+	// 剩余数量留待下一根K线撮合
+	mtch.MatchOrder(buy, testBar("9.9", "10", "9.8", "9.9", "1000"), day2Next)
+	if buy.tradeQty != 800 || buy.trades[1] != 9.9 || buy.status != config.OrderStatusDone {
+		t.Errorf("want done 800, got %v@%v %v", buy.tradeQty, buy.trades, buy.status)
+	}
+
+	// 委托价超出涨停价(昨收10 * 1.1)，拒单
+	over := &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeLimit, price: 11.5, qty: 100, status: config.OrderStatusNew}
+	mtch.MatchOrder(over, testBar("10.1", "10.5", "9.9", "10.3", "1000"), day2)
+	if over.status != config.OrderStatusRejected {
+		t.Errorf("order above upper limit should be rejected, got %v", over.status)
+	}
+
+	// 涨停封板，市价买单不成交
+	locked := &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew}
+	mtch.MatchOrder(locked, testBar("11", "11", "11", "11", "1000"), day2)
+	if locked.tradeQty != 0 {
+		t.Errorf("market buy at limit-up should not trade, got %v", locked.tradeQty)
+	}
+}
+
+// 测试卖出数量受持仓可卖数量限制: 可卖数量已扣除本订单冻结的数量
+func TestCloseQty(t *testing.T) {
+	day := time.Date(2023, 1, 4, 10, 0, 0, 0, time.Local)
+	acc := testAccount{positions: map[string]testPosition{"600000.XSHG.CS": {sellable: -200}}}
+
+	// 持有可卖300股，卖出500股只能成交300股
+	sell := &testOrder{direction: config.OrderSell, orderType: config.OrderTypeMarket, qty: 500, status: config.OrderStatusNew, acc: acc}
+	new(NextQuote).MatchOrder(sell, testBar("10", "10", "10", "10", "1000"), day)
+	if sell.tradeQty != 300 {
+		t.Errorf("sell should be capped by sellable 300, got %v", sell.tradeQty)
+	}
+
+	// 没有持仓不能卖出
+	none := &testOrder{direction: config.OrderSell, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew}
+	mtch := new(LimitQuote).Init(handler.WithConfig(config.Framework{})).(*LimitQuote)
+	mtch.MatchOrder(none, testBar("10", "10", "10", "10", "1000"), day)
+	if none.tradeQty != 0 {
+		t.Errorf("sell without position should not trade, got %v", none.tradeQty)
+	}
+}
+
+// 测试交易所规则: 科创板涨跌停幅度20%，一字涨停不能买入，停牌不撮合
+func TestExchangeRule(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("../contract/contract.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+	star := handle.GetContract("688001.XSHG.CS")
+
+	mtch := new(LimitQuote).Init(handler.WithConfig(config.Framework{})).(*LimitQuote)
+	day1 := time.Date(2023, 1, 3, 15, 0, 0, 0, time.Local)
+	day2 := time.Date(2023, 1, 4, 10, 0, 0, 0, time.Local)
+
+	bars := orderedmap.New[string, dataframe.StreamingRecord]()
+	bars.Set(star.GetInstID(), testBar("10", "10", "10", "10", "1000"))
+	mtch.ObserveQuote(day1, *bars)
+
+	// 委托价11.5超过10%但在20%以内，不拒单
+	buy := &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeLimit, price: 11.5, qty: 200, status: config.OrderStatusNew, contract: star}
+	mtch.MatchOrder(buy, testBar("12", "12", "12", "12", "1000"), day2)
+	if buy.status != config.OrderStatusNew || buy.tradeQty != 0 {
+		t.Errorf("star order within 20%% should stay new at limit-up, got %v %v", buy.status, buy.tradeQty)
+	}
+
+	mtch.MatchOrder(buy, testBar("11.5", "11.8", "11.2", "11.5", "1000"), day2.Add(time.Hour))
+	if buy.tradeQty != 200 {
+		t.Errorf("star order should trade below limit-up, got %v", buy.tradeQty)
+	}
+
+	// 停牌不撮合
+	next := &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew}
+	new(NextQuote).MatchOrder(next, testBar("10", "10", "10", "10", "0"), day2)
+	if next.tradeQty != 0 {
+		t.Errorf("suspended bar should not trade, got %v", next.tradeQty)
+	}
+
+	// 回放补齐的停牌K线拒单
+	halted := dataframe.StreamingRecord{
+		Data:    []string{"10", "10", "10", "10", "0", "1"},
+		Headers: map[string]int{"Open": 0, "High": 1, "Low": 2, "Close": 3, "Volume": 4, config.SuspendedField: 5},
+	}
+	for _, m := range []handler.Matcher{mtch, new(NextQuote), new(CurrQuote)} {
+		next = &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew}
+		m.MatchOrder(next, halted, day2)
+		if next.status != config.OrderStatusRejected || next.tradeQty != 0 {
+			t.Errorf("%T should reject orders on suspended bars, got %v %v", m, next.status, next.tradeQty)
+		}
+	}
+
+	// 行情中有昨收价时按板块规则判断一字涨停: 主板10%
+	main := handle.GetContract("600000.XSHG.CS")
+	limitUp := dataframe.StreamingRecord{
+		Data:    []string{"11", "11", "11", "11", "1000", "10"},
+		Headers: map[string]int{"Open": 0, "High": 1, "Low": 2, "Close": 3, "Volume": 4, "PreClose": 5},
+	}
+	next = &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew, contract: main}
+	new(CurrQuote).MatchOrder(next, limitUp, day2)
+	if next.tradeQty != 0 {
+		t.Errorf("market buy at limit-up should not trade, got %v", next.tradeQty)
+	}
+
+	// 行情没有昨收价时使用撮合器记录的上一交易日收盘价
+	bars = orderedmap.New[string, dataframe.StreamingRecord]()
+	bars.Set(main.GetInstID(), testBar("10", "10", "10", "10", "1000"))
+	for _, m := range []handler.Matcher{
+		new(NextQuote).Init(handler.WithConfig(config.Framework{})), new(CurrQuote).Init(handler.WithConfig(config.Framework{})),
+	} {
+		m.(handler.QuoteObserver).ObserveQuote(day1, *bars)
+
+		next = &testOrder{direction: config.OrderBuy, orderType: config.OrderTypeMarket, qty: 100, status: config.OrderStatusNew, contract: main}
+		m.MatchOrder(next, testBar("11", "11", "11", "11", "1000"), day2)
+		if next.tradeQty != 0 {
+			t.Errorf("%T market buy at limit-up should not trade, got %v", m, next.tradeQty)
+		}
+
+		m.MatchOrder(next, testBar("10.5", "11", "10.5", "10.8", "1000"), day2.Add(time.Hour))
+		if next.tradeQty != 100 {
+			t.Errorf("%T market buy below limit-up should trade, got %v", m, next.tradeQty)
+		}
+	}
+}
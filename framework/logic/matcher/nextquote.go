@@ -6,12 +6,15 @@ import (
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
 	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
 )
 
 type NextQuote struct {
 	StockSlippage  float64 // 滑点
 	FutureSlippage float64 // 滑点
+
+	quotes closes // 上一交易日收盘价，用于行情没有昨收价时计算涨跌停
 }
 
 func newNextOp(opts ...handler.WithMatcherOption) *handler.MatcherOp {
@@ -29,6 +32,7 @@ func (c *NextQuote) Init(opts ...handler.WithMatcherOption) handler.Matcher {
 	return &NextQuote{
 		StockSlippage:  op.StockSlippage,
 		FutureSlippage: op.FutureSlippage,
+		quotes:         make(closes),
 	}
 }
 
@@ -38,7 +42,17 @@ func (c *NextQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 		return
 	}
 
-	matchPrice := indicate.ConvertToFloat("Open")
+	// 停牌拒单，一字涨跌停不撮合
+	if rejectSuspended(order, indicate, matchTime) || !tradable(order.Contract(), order.OrderDirection(), indicate, matchTime, c.quotes) {
+		return
+	}
+
+	matchPrice := basePrice(order, indicate, "Open", matchTime)
+	qty := closeQty(order, order.OrderQty()-order.TradeQty())
+	if qty <= 0 {
+		return
+	}
+
 	// 目前是使用的是下一根K线的开盘价作为成交价
 	switch order.OrderDirection() {
 	case config.OrderBuy:
@@ -50,7 +64,7 @@ func (c *NextQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 				matchPrice,
 				c.StockSlippage,
 				order.OrderDirection()),
-			order.OrderQty(), matchTime, nil,
+			qty, matchTime, nil,
 		)
 		// } else {
 		// 	order.DoCancelUpdate(matchTime)
@@ -64,7 +78,7 @@ func (c *NextQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 				matchPrice, 
 				c.StockSlippage, 
 				order.OrderDirection()),
-			order.OrderQty(), matchTime, nil,
+			qty, matchTime, nil,
 		)
 		// } else {
 		// 	order.DoCancelUpdate(matchTime)
@@ -72,6 +86,17 @@ func (c *NextQuote) MatchOrder(order handler.Order, indicate dataframe.Streaming
 	}
 }
 
+// ObserveQuote 记录每根K线的收盘价，用于计算下一交易日的涨跌停
+func (c *NextQuote) ObserveQuote(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) {
+	if c.quotes == nil {
+		c.quotes = make(closes)
+	}
+
+	c.quotes.observe(tm, indicators)
+}
+
 func init() {
 	setting.RegisterMatcher(
 		new(NextQuote), 
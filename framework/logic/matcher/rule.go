@@ -0,0 +1,157 @@
+package matcher
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/qk"
+)
+
+// + 撮合时的交易所规则:
+// + 行情停牌标记非0(回放时缺失K线按前收盘补齐)的合约拒绝新订单，成交量为0的K线不撮合
+// + 涨跌停价优先使用行情中的涨跌停价，其次按昨收价和合约的板块规则计算，ST由行情标记判断
+// + 昨收价优先使用行情中的 PreClose，行情没有该列时由撮合器记录的上一交易日收盘价代替
+// + 一字涨停(最低价不低于涨停价)不能买入，一字跌停(最高价不高于跌停价)不能卖出
+
+const fieldST = "ST" // ST标记，非0表示当日为ST
+
+// suspended 本根K线是否停牌
+func suspended(indicate dataframe.StreamingRecord) bool {
+	if indicate.Suspended() {
+		return true
+	}
+
+	volume, ok := indicate.Get("Volume")
+	return ok && volume == 0
+}
+
+// rejectSuspended 停牌合约的新订单拒单并解冻，已部分成交的订单保留到收盘结算
+func rejectSuspended(order handler.Order, indicate dataframe.StreamingRecord, matchTime time.Time) bool {
+	if !indicate.Suspended() {
+		return false
+	}
+
+	if order.OrderStatus() == config.OrderStatusNew {
+		order.DoReject(matchTime, qk.ErrSuspended{InstID: order.InstID()})
+		order.Account().(handler.Account2).DoOrderUpdate(order)
+	}
+
+	return true
+}
+
+// isST 本根K线是否为ST
+func isST(indicate dataframe.StreamingRecord) bool {
+	v, ok := indicate.Get(fieldST)
+	return ok && v != 0
+}
+
+// quoteLimit 行情中的涨跌停价
+func quoteLimit(indicate dataframe.StreamingRecord) (lower, upper float64, ok bool) {
+	lower, okLower := indicate.Get("LowLimit")
+	upper, okUpper := indicate.Get("HighLimit")
+
+	return lower, upper, okLower && okUpper
+}
+
+// ruleLimit 按昨收价和合约的板块规则计算涨跌停价，合约没有交易规则时返回false
+func ruleLimit(
+	c contract.Contract, indicate dataframe.StreamingRecord, matchTime time.Time, prevClose float64,
+) (lower, upper float64, ok bool) {
+	r, ok := c.(contract.Rule)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return r.CalcPriceLimit(matchTime, prevClose, isST(indicate))
+}
+
+// sealed 一字涨停不能买入，一字跌停不能卖出
+func sealed(direction config.OrderDirection, indicate dataframe.StreamingRecord, lower, upper float64) bool {
+	open := indicate.ConvertToFloat("Open")
+	high, ok := indicate.Get("High")
+	if !ok {
+		high = open
+	}
+	low, ok := indicate.Get("Low")
+	if !ok {
+		low = open
+	}
+
+	return (direction == config.OrderBuy && low >= upper) || (direction == config.OrderSell && high <= lower)
+}
+
+// quoteState 标的行情状态，用于计算昨收价
+type quoteState struct {
+	date      string  // 最新行情所属交易日
+	close     float64 // 最新收盘价
+	prevClose float64 // 上一交易日收盘价
+}
+
+// closes 撮合器记录的各合约收盘价，key: instID
+type closes map[string]*quoteState
+
+// prevClose 昨收价，优先使用行情中的昨收价
+func (q closes) prevClose(instID string, indicate dataframe.StreamingRecord, matchTime time.Time) (float64, bool) {
+	if prevClose, ok := indicate.Get("PreClose"); ok {
+		return prevClose, true
+	}
+
+	s, ok := q[instID]
+	if !ok {
+		return 0, false
+	}
+
+	// 撮合发生在观察本根K线之前，跨日的第一根K线使用上一交易日最后的收盘价
+	if s.date != matchTime.Format(time.DateOnly) {
+		return s.close, s.close > 0
+	}
+
+	return s.prevClose, s.prevClose > 0
+}
+
+// observe 记录每根K线的收盘价
+func (q closes) observe(tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord]) {
+	date := tm.Format(time.DateOnly)
+	for pair := indicators.Oldest(); pair != nil; pair = pair.Next() {
+		closePrice, ok := pair.Value.Get("Close")
+		if !ok {
+			continue
+		}
+
+		s, ok := q[pair.Key]
+		if !ok {
+			s = &quoteState{date: date}
+			q[pair.Key] = s
+		}
+
+		if s.date != date {
+			s.prevClose = s.close
+			s.date = date
+		}
+
+		s.close = closePrice
+	}
+}
+
+// tradable 本根K线能否撮合，没有昨收价时只使用行情中的涨跌停价
+func tradable(
+	c contract.Contract, direction config.OrderDirection, indicate dataframe.StreamingRecord, matchTime time.Time,
+	quotes closes,
+) bool {
+	if suspended(indicate) {
+		return false
+	}
+
+	lower, upper, ok := quoteLimit(indicate)
+	if !ok {
+		if prevClose, okPrev := quotes.prevClose(c.GetInstID(), indicate, matchTime); okPrev {
+			lower, upper, ok = ruleLimit(c, indicate, matchTime, prevClose)
+		}
+	}
+
+	return !ok || !sealed(direction, indicate, lower, upper)
+}
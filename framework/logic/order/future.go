@@ -1,174 +1,380 @@
 package order
 
-// import (
-// 	"time"
-
-// 	"github.com/wonderstone/QuantKit/framework/entity/account"
-// 	"github.com/wonderstone/QuantKit/framework/entity/contract"
-// 	"github.com/wonderstone/QuantKit/config"
-// 	"github.com/wonderstone/QuantKit/tools/recorder"
-// )
-
-
-// type FutureOrder struct {
-// 	contract       contract.Contract
-// 	isEligible     bool
-// 	isExecuted     bool
-// 	orderTime      string
-// 	orderPrice     float64
-// 	orderNum       float64
-// 	orderDirection string
-// 	orderType      string
-// }
-
-// func (f *FutureOrder) DoRTInsert() error {
-// 	//TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoRTCancel() error {
-// 	//TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoResume(record OrderRecord) {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) CommissionFrozen() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoReject(rejectTime time.Time, err error) {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) Contract() contract.Contract {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) PositionDirection() config.PositionDirection {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) TransactionType() config.TransactionType {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoTradeUpdate(
-// 	tradePrice float64, tradeQty float64, tradeTime time.Time, recorder recorder.Handler,
-// ) {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoCancelUpdate(cancelTime time.Time) {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) ID() int64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) Account() account.Account {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) IsEligible() bool {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) IsExecuted() bool {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderTime() time.Time {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderPrice() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderQty() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderAmt() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderDirection() config.OrderDirection {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderType() config.OrderType {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) OrderStatus() config.OrderStatus {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) Margin() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) MarketValue() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) TradePrice() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) TradeQty() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) TradeAmt() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) TradeTime() time.Time {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) InstID() string {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) Commission() float64 {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
-
-// func (f *FutureOrder) DoSettle(tm time.Time, recorder recorder.Handler) {
-// 	// TODO implement me
-// 	panic("implement me")
-// }
+import (
+	"strconv"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + FutureOrder期货订单结构体：
+// + 实现了entity/account.Order接口
+// + 实现了entity/handler.Order接口
+// + 实现了tunnel.Order接口
+// 与股票订单的区别:
+// + 需要开平标志，持仓方向由买卖方向和开平标志共同决定
+// + 手续费由账户在成交时按平今、平昨拆分计算，通过ModifyOrder("commission")回写
+// 融资融券账户同样使用该订单: 买开=担保品买入/融资买入, 卖平=卖券还款, 卖开=融券卖出, 买平=买券还券
+
+// 买开=开多 ｜ 买平=平空
+// 卖开=开空 ｜ 卖平=平多
+func futurePositionDirection(
+	direction config.OrderDirection, transaction config.TransactionType,
+) config.PositionDirection {
+	if (direction == config.OrderBuy) == (transaction == config.OffsetOpen) {
+		return config.PositionLong
+	}
+
+	return config.PositionShort
+}
+
+type FutureOrder struct {
+	id                int64                    // 订单ID
+	orderTime         time.Time                // 订单时间
+	orderPrice        float64                  // 订单价格
+	orderQty          float64                  // 订单数量
+	orderDirection    config.OrderDirection    // 订单方向
+	positionDirection config.PositionDirection // 持仓方向
+	transactionType   config.TransactionType   // 开平标志
+	orderType         config.OrderType         // 订单类型
+	orderStatus       config.OrderStatus       // 订单状态
+
+	sysOrderId string           // 系统订单ID
+	account    handler.Account2 // 账户
+
+	tradePrice float64           // 成交价格
+	tradeQty   float64           // 成交数量
+	tradeTime  time.Time         // 成交时间
+	contract   contract.Contract // 合约
+
+	frozenCommission float64 // 冻结手续费
+	commission       float64 // 手续费
+
+	reject error // 拒单原因
+
+	trigger // 条件单触发状态
+}
+
+func newFutureOrder(id int64, c contract.Contract, qty float64, op *account.OrderOp) *FutureOrder {
+	transaction := op.Transaction
+	if transaction == "" {
+		transaction = config.OffsetOpen
+	}
+
+	t := newTrigger(op)
+	o := &FutureOrder{
+		id:                id,
+		contract:          c,
+		orderTime:         *op.OrderTime,
+		orderPrice:        t.pendingPrice(op.OrderPrice),
+		orderType:         op.OrderType,
+		orderQty:          qty,
+		orderDirection:    op.OrderDirection,
+		transactionType:   transaction,
+		positionDirection: futurePositionDirection(op.OrderDirection, transaction),
+		orderStatus:       config.OrderStatusNew,
+		trigger:           t,
+	}
+
+	o.freezeCommission()
+
+	if op.Account != nil {
+		o.account = op.Account.(handler.Account2)
+	}
+
+	return o
+}
+
+// freezeCommission 按委托价计算冻结手续费
+func (f *FutureOrder) freezeCommission() {
+	if c, ok := f.contract.(contract.Future); ok {
+		f.frozenCommission = c.CalcCommOffset(f.orderTime, f.orderQty, f.orderPrice, f.transactionType)
+	} else {
+		f.frozenCommission = f.contract.CalcComm(f.orderTime, f.orderQty, f.orderPrice, f.orderDirection)
+	}
+}
+
+// & FutureOrder 实现了 account.Order 全部接口
+
+func (f *FutureOrder) ID() int64 {
+	return f.id
+}
+
+func (f *FutureOrder) Contract() contract.Contract {
+	return f.contract
+}
+
+func (f *FutureOrder) Account() account.Account {
+	return f.account.(account.Account)
+}
+
+func (f *FutureOrder) IsExecuted() bool {
+	switch f.orderStatus {
+	case config.OrderStatusDone, config.OrderStatusPartDonePartCancel,
+		config.OrderStatusCanceled, config.OrderStatusExpired, config.OrderStatusRejected:
+		return true
+	}
+
+	return false
+}
+
+func (f *FutureOrder) OrderTime() time.Time {
+	return f.orderTime
+}
+
+func (f *FutureOrder) OrderPrice() float64 {
+	return f.orderPrice
+}
+
+func (f *FutureOrder) OrderQty() float64 {
+	return f.orderQty
+}
+
+// OrderAmt 期货下单金额为合约名义价值
+func (f *FutureOrder) OrderAmt() float64 {
+	return f.contract.CalcMarketValue(f.orderQty, f.orderPrice, f.positionDirection)
+}
+
+func (f *FutureOrder) OrderDirection() config.OrderDirection {
+	return f.orderDirection
+}
+
+func (f *FutureOrder) PositionDirection() config.PositionDirection {
+	return f.positionDirection
+}
+
+func (f *FutureOrder) TransactionType() config.TransactionType {
+	return f.transactionType
+}
+
+func (f *FutureOrder) OrderType() config.OrderType {
+	return f.orderType
+}
+
+func (f *FutureOrder) OrderStatus() config.OrderStatus {
+	return f.orderStatus
+}
+
+// Margin 开仓订单需要冻结的保证金，平仓订单不需要保证金
+func (f *FutureOrder) Margin() float64 {
+	if f.transactionType != config.OffsetOpen {
+		return 0
+	}
+
+	return f.contract.CalcMargin(f.orderQty, f.orderPrice, f.positionDirection)
+}
+
+func (f *FutureOrder) MarketValue() float64 {
+	return f.contract.CalcMarketValue(f.orderQty, f.orderPrice, f.positionDirection)
+}
+
+func (f *FutureOrder) TradePrice() float64 {
+	return f.tradePrice
+}
+
+func (f *FutureOrder) TradeQty() float64 {
+	return f.tradeQty
+}
+
+func (f *FutureOrder) TradeAmt() float64 {
+	return f.contract.CalcMarketValue(f.tradeQty, f.tradePrice, f.positionDirection)
+}
+
+func (f *FutureOrder) TradeTime() time.Time {
+	return f.tradeTime
+}
+
+func (f *FutureOrder) InstID() string {
+	return f.contract.GetInstID()
+}
+
+func (f *FutureOrder) Commission() float64 {
+	return f.commission
+}
+
+func (f *FutureOrder) CommissionFrozen() float64 {
+	return f.frozenCommission
+}
+
+// & FutureOrder 实现了 account.Order 全部接口 完毕
+
+// & FutureOrder 实现了 handler.Order 超额接口
+
+// DoTradeUpdate 更新订单, 期货每笔成交都需要更新账户(保证金、手续费、持仓)
+func (f *FutureOrder) DoTradeUpdate(
+	tradePrice float64, tradeQty float64, tradeTime time.Time, recorder recorder.Handler,
+) {
+	prevTradeQty := f.tradeQty
+	f.tradeQty += tradeQty
+	f.tradePrice = (f.tradePrice*prevTradeQty + tradePrice*tradeQty) / f.tradeQty
+	f.tradeTime = tradeTime
+
+	if f.tradeQty >= f.orderQty {
+		f.orderStatus = config.OrderStatusDone
+	} else {
+		f.orderStatus = config.OrderStatusPartDone
+	}
+
+	f.account.DoTradeUpdate(tradeQty, tradePrice, f)
+	if f.orderStatus == config.OrderStatusDone {
+		f.account.DoOrderUpdate(f)
+	}
+
+	if recorder != nil {
+		recorder.GetChannel() <- f.makeRecord(tradeTime)
+	}
+}
+
+// DoCancelUpdate 撤单更新
+func (f *FutureOrder) DoCancelUpdate(cancelTime time.Time) {
+	switch f.orderStatus {
+	case config.OrderStatusNew:
+		f.orderStatus = config.OrderStatusCanceled
+	case config.OrderStatusPartDone:
+		f.orderStatus = config.OrderStatusPartDonePartCancel
+	default:
+		return
+	}
+
+	f.tradeTime = cancelTime
+	f.account.DoOrderUpdate(f)
+}
+
+// DoReject 拒单更新
+func (f *FutureOrder) DoReject(rejectTime time.Time, err error) {
+	f.orderStatus = config.OrderStatusRejected
+	f.tradeTime = rejectTime
+	f.reject = err
+}
+
+// RejectReason 拒单原因
+func (f *FutureOrder) RejectReason() error {
+	return f.reject
+}
+
+// DoSettle 闭市结算更新, 未完成订单过期，冻结资金由账户结算统一释放
+func (f *FutureOrder) DoSettle(tm time.Time, recorder recorder.Handler) {
+	switch f.orderStatus {
+	case config.OrderStatusNew:
+		f.orderStatus = config.OrderStatusExpired
+		f.tradeTime = tm
+	case config.OrderStatusPartDone:
+		f.orderStatus = config.OrderStatusPartDonePartCancel
+		f.tradeTime = tm
+	}
+
+	if recorder != nil {
+		recorder.GetChannel() <- f.makeRecord(f.orderTime)
+	}
+}
+
+// DoResume 恢复订单，有成交记录时逐笔重放成交
+func (f *FutureOrder) DoResume(record *recorder.OrderRecord, trades ...recorder.TradeRecord) {
+	for _, t := range trades {
+		f.DoTradeUpdate(t.Price, t.Qty, tradeTime(t), nil)
+	}
+
+	if len(trades) != 0 || record.TradeQty == 0 {
+		return
+	}
+
+	f.DoTradeUpdate(record.TradePrice, record.TradeQty, f.orderTime, nil)
+}
+
+func (f *FutureOrder) DoRTInsert() error {
+	orderID, err := f.account.DoRTInsert(f)
+	if err != nil {
+		return err
+	}
+
+	f.sysOrderId = orderID
+	return nil
+}
+
+func (f *FutureOrder) DoRTCancel() error {
+	return f.account.DoRTCancel(f.sysOrderId)
+}
+
+// ModifyOrder 修改订单
+func (f *FutureOrder) ModifyOrder(tag string, value interface{}) {
+	switch tag {
+	case "orderStatus":
+		f.orderStatus = value.(config.OrderStatus)
+	case "sysOrderId":
+		f.sysOrderId = value.(string)
+	case "commission":
+		f.commission = value.(float64)
+	}
+}
+
+func (f *FutureOrder) makeRecord(tm time.Time) *recorder.OrderRecord {
+	record := setting.MakeOrderRecord(
+		f.id, tm, f.contract.GetInstID(),
+		f.orderDirection, f.positionDirection, f.transactionType,
+		f.orderPrice, f.orderQty, f.tradePrice, f.tradeQty,
+		f.commission, f.orderStatus, f.reject,
+	)
+	record.Account = f.account.AccountID()
+	f.trigger.fill(record)
+
+	return record
+}
+
+// DoTrigger 条件单触发检查，触发后转为市价单或限价单，按新的委托价冻结手续费
+func (f *FutureOrder) DoTrigger(indicate dataframe.StreamingRecord, triggerTime time.Time) bool {
+	price, ok := f.trigger.check(f.orderDirection, indicate)
+	if !ok {
+		return false
+	}
+
+	f.orderType, f.orderPrice = f.trigger.fire(price, triggerTime)
+	f.freezeCommission()
+	return true
+}
+
+// DoTriggerCancel 撤销未触发的条件单，条件单触发前没有冻结资金
+func (f *FutureOrder) DoTriggerCancel(cancelTime time.Time) {
+	f.trigger.state = config.TriggerCanceled
+	f.orderStatus = config.OrderStatusCanceled
+	f.tradeTime = cancelTime
+}
+
+// & FutureOrder 实现了 handler.Order 超额接口 完毕
+
+// & FutureOrder 实现了 tunnel.Order 接口
+
+func (f *FutureOrder) RequestID() string {
+	return f.account.GetRunId() + "-" + strconv.FormatInt(f.id, 10)
+}
+
+func (f *FutureOrder) OrderID() string {
+	return f.sysOrderId
+}
+
+func (f *FutureOrder) Direction() string {
+	return string(f.orderDirection)
+}
+
+func (f *FutureOrder) Volume() float64 {
+	return f.orderQty
+}
+
+func (f *FutureOrder) Price() float64 {
+	return f.orderPrice
+}
+
+func (f *FutureOrder) AccountID() string {
+	return f.account.AccountID()
+}
+
+func (f *FutureOrder) CashAccount() string {
+	return f.account.CashAccount()
+}
+
+// & FutureOrder 实现了 tunnel.Order 接口 完毕
@@ -1,46 +1,54 @@
 package order
 
 import (
+	"time"
+
 	"github.com/wonderstone/QuantKit/config"
 	"github.com/wonderstone/QuantKit/framework/entity/account"
 	"github.com/wonderstone/QuantKit/framework/entity/contract"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
 func NewOrder(id int64, contract contract.Contract, qty float64, op *account.OrderOp) handler.Order {
 	switch contract.GetAccountType() {
 	case config.AccountTypeStockSimple:
+		t := newTrigger(op)
+		price := t.pendingPrice(op.OrderPrice)
 		newOrder := StockOrder{
 			id:               id,
 			contract:         contract,
 			orderTime:        *op.OrderTime,
-			orderPrice:       op.OrderPrice,
+			orderPrice:       price,
 			orderType:        op.OrderType,
 			orderQty:         qty,
 			orderDirection:   op.OrderDirection,
 			account:          op.Account.(handler.Account2),
 
 			orderStatus:      config.OrderStatusNew,
-			frozenCommission: contract.CalcComm(qty, op.OrderPrice, op.OrderDirection),
+			frozenCommission: contract.CalcComm(*op.OrderTime, qty, price, op.OrderDirection),
+			trigger:          t,
 		}
 
 		return &newOrder
-		// 	! 期货未实现
-		// case config.AccountTypeFuture:
-		// 	newOrder := FutureOrder{
-		// 		// TODO
-		// 	}
-		// 	return &newOrder
+	case config.AccountTypeFuture:
+		return newFutureOrder(id, contract, qty, op)
 	}
 
 	return nil
 }
 
+// NewCreditOrder 融资融券订单，与期货订单一样由买卖方向和开平标志确定持仓方向
+func NewCreditOrder(id int64, contract contract.Contract, qty float64, op *account.OrderOp) handler.Order {
+	return newFutureOrder(id, contract, qty, op)
+}
+
 func ResumeOrder(orderId int64, contract contract.Contract, qty float64, op *account.OrderOp) handler.Order {
 	switch contract.GetAccountType() {
 	case config.AccountTypeStockSimple:
 		newOrder := StockOrder{
 			id:               orderId,
+			account:          op.Account.(handler.Account2),
 			contract:         contract,
 			orderTime:        *op.OrderTime,
 			orderPrice:       op.OrderPrice,
@@ -48,17 +56,24 @@ func ResumeOrder(orderId int64, contract contract.Contract, qty float64, op *acc
 			orderQty:         qty,
 			orderDirection:   op.OrderDirection,
 			orderStatus:      config.OrderStatusNew,
-			frozenCommission: contract.CalcComm(qty, op.OrderPrice, op.OrderDirection),
+			frozenCommission: contract.CalcComm(*op.OrderTime, qty, op.OrderPrice, op.OrderDirection),
+			trigger:          newTrigger(op),
 		}
 
 		return &newOrder
-		// 	! 期货未实现
-		// case config.AccountTypeFuture:
-		// 	newOrder := FutureOrder{
-		// 		// TODO
-		// 	}
-		// 	return &newOrder
+	case config.AccountTypeFuture:
+		return newFutureOrder(orderId, contract, qty, op)
 	}
 
 	return nil
 }
+
+// tradeTime 成交记录的成交时间
+func tradeTime(t recorder.TradeRecord) time.Time {
+	tm, err := time.ParseInLocation(config.TimeFormatDate2+" "+config.TimeFormatTime2, t.TradeDate+" "+t.TradeTime, time.Local)
+	if err != nil {
+		config.ErrorF("恢复成交失败: %+v\n", t)
+	}
+
+	return tm
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/wonderstone/QuantKit/framework/entity/contract"
 	"github.com/wonderstone/QuantKit/framework/entity/handler"
 	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
 	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
@@ -43,6 +44,8 @@ type StockOrder struct {
 	commission       float64 // 手续费
 
 	reject error // 拒单原因
+
+	trigger // 条件单触发状态
 }
 
 // & StockOrder 实现了 Order 全部接口
@@ -179,7 +182,7 @@ func (s *StockOrder) DoTradeUpdate(
 	// 更新订单状态
 	if s.tradeQty == s.orderQty {
 		s.orderStatus = config.OrderStatusDone
-		s.commission = s.contract.CalcComm(s.tradeQty, s.orderPrice, s.orderDirection)
+		s.commission = s.contract.CalcComm(s.orderTime, s.tradeQty, s.orderPrice, s.orderDirection)
 		// ! 请考虑禁止order层面更新账户，应该在account层面更新
 		s.account.DoTradeUpdate(tradeQty, tradePrice, s)
 		s.account.DoOrderUpdate(s)
@@ -191,13 +194,15 @@ func (s *StockOrder) DoTradeUpdate(
 
 	// 记录订单
 	if recorder != nil {
-		recorder.GetChannel() <- setting.MakeOrderRecord(
+		record := setting.MakeOrderRecord(
 			s.id, tradeTime, s.contract.GetInstID(), 
 			s.orderDirection, config.PositionLong, 
 			config.OffsetOpen, s.orderPrice,
 			s.orderQty, s.tradePrice, s.tradeQty,
 			s.Commission(), s.orderStatus, s.reject,
 		)
+		s.trigger.fill(record)
+		recorder.GetChannel() <- record
 	}
 }
 
@@ -211,7 +216,7 @@ func (s *StockOrder) DoCancelUpdate(cancelTime time.Time) {
 		s.tradeTime = cancelTime
 	case config.OrderStatusPartDone:
 		s.orderStatus = config.OrderStatusPartDonePartCancel
-		s.commission = s.contract.CalcComm(s.tradeQty, s.orderPrice, s.orderDirection)
+		s.commission = s.contract.CalcComm(s.orderTime, s.tradeQty, s.orderPrice, s.orderDirection)
 		s.tradeTime = cancelTime
 	}
 
@@ -231,6 +236,11 @@ func (s *StockOrder) DoReject(rejectTime time.Time, err error) {
 	// s.account.DoOrderUpdate(s)
 }
 
+// RejectReason 拒单原因
+func (s *StockOrder) RejectReason() error {
+	return s.reject
+}
+
 // DoSettle 闭市结算更新
 // DoSettle(time time.Time, recorder recorder.Handler)
 func (s *StockOrder) DoSettle(tm time.Time, recorder recorder.Handler) {
@@ -242,23 +252,53 @@ func (s *StockOrder) DoSettle(tm time.Time, recorder recorder.Handler) {
 	case config.OrderStatusPartDone:
 		s.orderStatus = config.OrderStatusPartDonePartCancel
 		s.tradeTime = tm
-		s.commission = s.contract.CalcComm(s.tradeQty, s.orderPrice, s.orderDirection)
+		s.commission = s.contract.CalcComm(s.orderTime, s.tradeQty, s.orderPrice, s.orderDirection)
+		// 部分成交需要结算已成交部分的手续费
+		s.account.DoOrderUpdate(s)
 	}
 
 	// 记录订单
 	if recorder != nil {
-		recorder.GetChannel() <- setting.MakeOrderRecord(
+		record := setting.MakeOrderRecord(
 			s.id, 
 			s.orderTime, s.contract.GetInstID(), s.orderDirection, config.PositionLong, config.OffsetOpen, s.orderPrice,
 			s.orderQty, s.tradePrice, s.tradeQty, s.commission, s.orderStatus, s.reject,
 		)
+		s.trigger.fill(record)
+		recorder.GetChannel() <- record
 	}
 }
 
+// DoTrigger 条件单触发检查，触发后转为市价单或限价单，按新的委托价冻结手续费
+func (s *StockOrder) DoTrigger(indicate dataframe.StreamingRecord, triggerTime time.Time) bool {
+	price, ok := s.trigger.check(s.orderDirection, indicate)
+	if !ok {
+		return false
+	}
+
+	s.orderType, s.orderPrice = s.trigger.fire(price, triggerTime)
+	s.frozenCommission = s.contract.CalcComm(s.orderTime, s.orderQty, s.orderPrice, s.orderDirection)
+	return true
+}
+
+// DoTriggerCancel 撤销未触发的条件单，条件单触发前没有冻结资金
+func (s *StockOrder) DoTriggerCancel(cancelTime time.Time) {
+	s.trigger.state = config.TriggerCanceled
+	s.orderStatus = config.OrderStatusCanceled
+	s.tradeTime = cancelTime
+}
+
 // 恢复订单：出现异常情况时，恢复订单
-// DoResume(record *OrderRecord)
-func (s *StockOrder) DoResume(record * recorder.OrderRecord) {
-	s.DoTradeUpdate(record.TradePrice, record.TradeQty, s.orderTime, nil)
+// DoResume(record *OrderRecord, trades ...TradeRecord)
+func (s *StockOrder) DoResume(record * recorder.OrderRecord, trades ...recorder.TradeRecord) {
+	if len(trades) == 0 {
+		s.DoTradeUpdate(record.TradePrice, record.TradeQty, s.orderTime, nil)
+		return
+	}
+
+	for _, t := range trades {
+		s.DoTradeUpdate(t.Price, t.Qty, tradeTime(t), nil)
+	}
 }
 
 
@@ -0,0 +1,194 @@
+package order
+
+import (
+	"math"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + trigger 条件单触发状态，StockOrder和FutureOrder共用
+// + 止损单、跟踪止损单触发后转为市价单，委托价为触发价，用于冻结资金
+// + 止损限价单触发后转为限价单，委托价为限价；止盈单触发后以止盈价限价委托
+// + 跟踪止损先用此前的最优价计算触发价检查本根K线，未触发时再用本根K线更新最优价
+
+type trigger struct {
+	orderType    config.OrderType    // 原始委托类型
+	state        config.TriggerState // 触发状态
+	stopPrice    float64             // 触发价
+	limitPrice   float64             // 止损限价单触发后的限价
+	trailPercent float64             // 跟踪止损比例
+	trailPrice   float64             // 跟踪止损价差
+	best         float64             // 跟踪止损的最优价: 卖出为最高价, 买入为最低价
+	triggerPrice float64             // 触发时的参考成交价
+	triggerTime  time.Time           // 触发时间
+	takeProfit   float64             // 附带止盈价
+	stopLoss     float64             // 附带止损价
+}
+
+func newTrigger(op *account.OrderOp) trigger {
+	t := trigger{
+		orderType:    op.OrderType,
+		stopPrice:    op.StopPrice,
+		trailPercent: op.TrailPercent,
+		trailPrice:   op.TrailPrice,
+		takeProfit:   op.TakeProfit,
+		stopLoss:     op.StopLoss,
+	}
+
+	if !op.OrderType.Conditional() {
+		return t
+	}
+
+	t.state = config.TriggerPending
+	switch op.OrderType {
+	case config.OrderTypeStopLimit:
+		t.limitPrice = op.OrderPrice
+	case config.OrderTypeTrailingStop:
+		// 委托价作为初始最优价，未指定时使用第一根K线的开盘价
+		t.best = op.OrderPrice
+	}
+
+	return t
+}
+
+// pendingPrice 条件单触发前用于估算冻结的委托价
+func (t *trigger) pendingPrice(price float64) float64 {
+	if t.state != config.TriggerPending || t.orderType == config.OrderTypeStopLimit || price != 0 {
+		return price
+	}
+
+	return t.stopPrice
+}
+
+func (t *trigger) TriggerState() config.TriggerState {
+	return t.state
+}
+
+func (t *trigger) StopPrice() float64 {
+	return t.stopPrice
+}
+
+func (t *trigger) TriggerPrice() float64 {
+	return t.triggerPrice
+}
+
+func (t *trigger) TriggerTime() time.Time {
+	return t.triggerTime
+}
+
+func (t *trigger) Bracket() (takeProfit, stopLoss float64) {
+	return t.takeProfit, t.stopLoss
+}
+
+// trailStop 由最优价计算跟踪止损的触发价
+func (t *trigger) trailStop(direction config.OrderDirection) float64 {
+	sign := -1.0
+	if direction == config.OrderBuy {
+		sign = 1.0
+	}
+
+	if t.trailPercent > 0 {
+		return t.best * (1 + sign*t.trailPercent)
+	}
+
+	return t.best + sign*t.trailPrice
+}
+
+// check 使用K线检查是否触发，触发时返回参考成交价
+func (t *trigger) check(direction config.OrderDirection, indicate dataframe.StreamingRecord) (float64, bool) {
+	if t.state != config.TriggerPending {
+		return 0, false
+	}
+
+	open, ok := indicate.Get("Open")
+	if !ok {
+		if open, ok = indicate.Get("Close"); !ok {
+			return 0, false
+		}
+	}
+
+	high, ok := indicate.Get("High")
+	if !ok {
+		high = open
+	}
+
+	low, ok := indicate.Get("Low")
+	if !ok {
+		low = open
+	}
+
+	buy := direction == config.OrderBuy
+	if t.orderType == config.OrderTypeTrailingStop {
+		if t.best == 0 {
+			t.best = open
+		}
+
+		t.stopPrice = t.trailStop(direction)
+		if (buy && high < t.stopPrice) || (!buy && low > t.stopPrice) {
+			if buy {
+				t.best = math.Min(t.best, low)
+			} else {
+				t.best = math.Max(t.best, high)
+			}
+
+			t.stopPrice = t.trailStop(direction)
+			return 0, false
+		}
+	}
+
+	// 止盈单方向与止损相反: 卖出止盈等待价格上涨，买入止盈等待价格下跌
+	up := buy
+	if t.orderType == config.OrderTypeTakeProfit {
+		up = !buy
+	}
+
+	if up {
+		if high < t.stopPrice {
+			return 0, false
+		}
+
+		return math.Max(open, t.stopPrice), true
+	}
+
+	if low > t.stopPrice {
+		return 0, false
+	}
+
+	return math.Min(open, t.stopPrice), true
+}
+
+// fire 触发后的委托类型和委托价
+func (t *trigger) fire(price float64, tm time.Time) (config.OrderType, float64) {
+	t.state = config.TriggerFired
+	t.triggerPrice = price
+	t.triggerTime = tm
+
+	switch t.orderType {
+	case config.OrderTypeStopLimit:
+		return config.OrderTypeLimit, t.limitPrice
+	case config.OrderTypeTakeProfit:
+		return config.OrderTypeLimit, t.stopPrice
+	}
+
+	return config.OrderTypeMarket, price
+}
+
+// fill 在订单记录中补充条件单信息
+func (t *trigger) fill(record *recorder.OrderRecord) {
+	record.OrderType = string(t.orderType)
+	record.StopPrice = t.stopPrice
+	record.TriggerState = string(t.state)
+	if !t.triggerTime.IsZero() {
+		record.TriggerTime = t.triggerTime.Format(config.TimeFormatDefault)
+	}
+}
+
+var (
+	_ handler.ConditionalOrder = (*StockOrder)(nil)
+	_ handler.ConditionalOrder = (*FutureOrder)(nil)
+)
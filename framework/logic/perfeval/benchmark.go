@@ -0,0 +1,50 @@
+package perfeval
+
+import (
+	"github.com/wonderstone/QuantKit/config"
+)
+
+// BenchmarkRecord 基准序列文件的一行，日期格式与资产记录一致(2006-01-02)
+type BenchmarkRecord struct {
+	Date  string  `csv:"date"`
+	Close float64 `csv:"close"`
+}
+
+// LoadBenchmark 从csv文件读取基准序列，返回 日期 -> 基准收盘价
+func LoadBenchmark(file string) (map[string]float64, error) {
+	var records []BenchmarkRecord
+	if err := config.ReadCsvFile(file, &records); err != nil {
+		return nil, err
+	}
+
+	benchmark := make(map[string]float64, len(records))
+	for _, r := range records {
+		benchmark[r.Date] = r.Close
+	}
+
+	return benchmark, nil
+}
+
+// pairedReturns 按日期对齐策略与基准的收益率，只保留前后两日基准都存在的区间
+func (p *PerfEval) pairedReturns(benchmark map[string]float64) (rors, bench []float64) {
+	if benchmark == nil {
+		config.ErrorF("未配置基准序列(performance.benchmark)，无法计算相对基准的指标")
+	}
+
+	if !p.sorted {
+		p.Sort()
+	}
+
+	for i := 1; i < p.Len(); i++ {
+		prev, ok1 := benchmark[p.Records[i-1].Date]
+		curr, ok2 := benchmark[p.Records[i].Date]
+		if !ok1 || !ok2 || prev == 0 || p.Records[i-1].TotalAsset == 0 {
+			continue
+		}
+
+		rors = append(rors, p.Records[i].TotalAsset/p.Records[i-1].TotalAsset-1)
+		bench = append(bench, curr/prev-1)
+	}
+
+	return
+}
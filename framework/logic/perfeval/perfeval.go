@@ -17,6 +17,9 @@ type PerfEval struct {
 type Op struct {
 	Tag          perf.IndicateType
 	RiskFreeRate float64
+	Benchmark    map[string]float64         // 基准序列 日期 -> 基准收盘价
+	Orders       []recorder.OrderRecord     // 订单记录，用于计算交易类指标
+	RoundTrips   []recorder.RoundTripRecord // 完整交易记录，有记录时交易类指标优先使用
 }
 
 type WithOption func(*Op)
@@ -33,6 +36,24 @@ func WithRiskFreeRate(rf float64) WithOption {
 	}
 }
 
+func WithBenchmark(benchmark map[string]float64) WithOption {
+	return func(op *Op) {
+		op.Benchmark = benchmark
+	}
+}
+
+func WithOrderRecords(orders []recorder.OrderRecord) WithOption {
+	return func(op *Op) {
+		op.Orders = orders
+	}
+}
+
+func WithRoundTripRecords(trips []recorder.RoundTripRecord) WithOption {
+	return func(op *Op) {
+		op.RoundTrips = trips
+	}
+}
+
 func NewOp(options ...WithOption) *Op {
 	op := &Op{}
 	for _, opt := range options {
@@ -70,10 +91,44 @@ func (p *PerfEval) CalcPerfEvalResult(options ...WithOption) float64 {
 		return p.AnnualizedReturn()
 	case perf.MaxDrawdown:
 		return p.MaxDrawDown()
-	// case perf.InformationRatio:
-	// 	return p.AnnualizedReturn() / p.MaxDrawDown()
 	case perf.SharpeRatio:
 		return p.SharpeRatio(op.RiskFreeRate)
+	case perf.SortinoRatio:
+		return p.SortinoRatio(op.RiskFreeRate)
+	case perf.CalmarRatio:
+		return p.CalmarRatio()
+	case perf.SterlingRatio:
+		return p.SterlingRatio(op.RiskFreeRate)
+	case perf.Volatility:
+		return p.Volatility()
+	case perf.DownsideRisk:
+		return p.DownsideRisk(op.RiskFreeRate)
+	case perf.UpsidePotential:
+		return p.UpsidePotential(op.RiskFreeRate)
+	case perf.Alpha:
+		return p.Alpha(op.Benchmark, op.RiskFreeRate)
+	case perf.Beta:
+		return p.Beta(op.Benchmark)
+	case perf.AlphaBetaRatio:
+		return p.AlphaBetaRatio(op.Benchmark, op.RiskFreeRate)
+	case perf.InformationRatio:
+		return p.InformationRatio(op.Benchmark)
+	case perf.TrackingError:
+		return p.TrackingError(op.Benchmark)
+	case perf.TreynorRatio:
+		return p.TreynorRatio(op.Benchmark, op.RiskFreeRate)
+	case perf.R2:
+		return p.R2(op.Benchmark)
+	case perf.WinRate:
+		return p.WinRate(p.tripPnL(op))
+	case perf.ProfitFactor:
+		return p.ProfitFactor(p.tripPnL(op))
+	case perf.GainLossRatio:
+		return p.GainLossRatio(p.tripPnL(op))
+	case perf.AvgHoldingDays:
+		return p.AvgHoldingDays(op.RoundTrips)
+	case perf.Turnover:
+		return p.Turnover(op.Orders)
 	default:
 		config.ErrorF("未知的性能指标: %s", op.Tag)
 	}
@@ -103,7 +158,7 @@ func (p *PerfEval) TotalReturn() (TR float64) {
 
 func (p *PerfEval) AnnualizedReturn() (AR float64) {
 	// 默认了日线级别 偷懒做法  后期有空精细化吧
-	return math.Pow(p.TotalReturn(), float64(252/p.Len()))
+	return math.Pow(p.TotalReturn(), 252.0/float64(p.Len()))
 }
 
 func (p *PerfEval) MaxDrawDown() (maxDrawDown float64) {
@@ -130,7 +185,175 @@ func (p *PerfEval) SharpeRatio(Rf float64) (SR float64) {
 	if std == 0 {
 		return 0
 	}
-	return (p.AnnualizedReturn() - Rf) / (math.Sqrt(252) * std)
+	return (p.AnnualizedReturn() - 1 - Rf) / (math.Sqrt(252) * std)
+}
+
+// ~ 以下指标默认为日线级别，按每年252个交易日年化
+// ~ AnnualizedReturn 为年化的资产倍数，年化收益率为 AnnualizedReturn() - 1
+
+func (p *PerfEval) excessReturns(Rf float64) []float64 {
+	rors := p.RateOfReturns()
+	for i := range rors {
+		rors[i] -= Rf / 252
+	}
+	return rors
+}
+
+// Volatility 年化波动率
+func (p *PerfEval) Volatility() float64 {
+	if p.Len() < 3 {
+		return 0
+	}
+	return Std(p.RateOfReturns(), 1) * math.Sqrt(252)
+}
+
+// DownsideRisk 年化下行风险，只统计低于无风险收益的部分
+func (p *PerfEval) DownsideRisk(Rf float64) float64 {
+	if p.Len() < 2 {
+		return 0
+	}
+
+	ss := 0.0
+	rors := p.excessReturns(Rf)
+	for _, r := range rors {
+		if r < 0 {
+			ss += r * r
+		}
+	}
+	return math.Sqrt(ss/float64(len(rors))) * math.Sqrt(252)
+}
+
+// UpsidePotential 年化上行潜力，只统计高于无风险收益的部分
+func (p *PerfEval) UpsidePotential(Rf float64) float64 {
+	if p.Len() < 2 {
+		return 0
+	}
+
+	sum := 0.0
+	rors := p.excessReturns(Rf)
+	for _, r := range rors {
+		if r > 0 {
+			sum += r
+		}
+	}
+	return sum / float64(len(rors)) * 252
+}
+
+func (p *PerfEval) SortinoRatio(Rf float64) float64 {
+	dr := p.DownsideRisk(Rf)
+	if dr == 0 {
+		return 0
+	}
+	return (p.AnnualizedReturn() - 1 - Rf) / dr
+}
+
+func (p *PerfEval) CalmarRatio() float64 {
+	md := p.MaxDrawDown()
+	if md == 0 {
+		return 0
+	}
+	return (p.AnnualizedReturn() - 1) / md
+}
+
+// SterlingRatio 斯特林比率 = 年化超额收益 / 各年度最大回撤的均值
+func (p *PerfEval) SterlingRatio(Rf float64) float64 {
+	if !p.sorted {
+		p.Sort()
+	}
+
+	var drawDowns []float64
+	for begin := 0; begin < p.Len(); {
+		end := begin
+		for end < p.Len() && p.Records[end].Date[:4] == p.Records[begin].Date[:4] {
+			end++
+		}
+
+		year := PerfEval{Records: p.Records[begin:end], sorted: true}
+		drawDowns = append(drawDowns, year.MaxDrawDown())
+		begin = end
+	}
+
+	avg := Mean(drawDowns)
+	if len(drawDowns) == 0 || avg == 0 {
+		return 0
+	}
+	return (p.AnnualizedReturn() - 1 - Rf) / avg
+}
+
+// Beta 策略收益相对基准收益的敏感度
+func (p *PerfEval) Beta(benchmark map[string]float64) float64 {
+	rors, bench := p.pairedReturns(benchmark)
+	v := Std(bench, 1)
+	if len(bench) < 2 || v == 0 {
+		return 0
+	}
+	return Covariance(rors, bench, 1) / (v * v)
+}
+
+// Alpha 年化詹森alpha
+func (p *PerfEval) Alpha(benchmark map[string]float64, Rf float64) float64 {
+	rors, bench := p.pairedReturns(benchmark)
+	if len(bench) < 2 {
+		return 0
+	}
+
+	rf := Rf / 252
+	return (Mean(rors) - rf - p.Beta(benchmark)*(Mean(bench)-rf)) * 252
+}
+
+func (p *PerfEval) AlphaBetaRatio(benchmark map[string]float64, Rf float64) float64 {
+	beta := p.Beta(benchmark)
+	if beta == 0 {
+		return 0
+	}
+	return p.Alpha(benchmark, Rf) / beta
+}
+
+// TrackingError 年化跟踪误差
+func (p *PerfEval) TrackingError(benchmark map[string]float64) float64 {
+	rors, bench := p.pairedReturns(benchmark)
+	if len(bench) < 2 {
+		return 0
+	}
+
+	diff := make([]float64, len(rors))
+	for i := range rors {
+		diff[i] = rors[i] - bench[i]
+	}
+	return Std(diff, 1) * math.Sqrt(252)
+}
+
+// InformationRatio 信息比率 = 年化超额基准收益 / 跟踪误差
+func (p *PerfEval) InformationRatio(benchmark map[string]float64) float64 {
+	te := p.TrackingError(benchmark)
+	if te == 0 {
+		return 0
+	}
+
+	rors, bench := p.pairedReturns(benchmark)
+	return (Mean(rors) - Mean(bench)) * 252 / te
+}
+
+// TreynorRatio 特雷诺比率 = 年化超额收益 / beta
+func (p *PerfEval) TreynorRatio(benchmark map[string]float64, Rf float64) float64 {
+	beta := p.Beta(benchmark)
+	if beta == 0 {
+		return 0
+	}
+
+	rors, _ := p.pairedReturns(benchmark)
+	return (Mean(rors)*252 - Rf) / beta
+}
+
+// R2 策略收益与基准收益相关系数的平方
+func (p *PerfEval) R2(benchmark map[string]float64) float64 {
+	rors, bench := p.pairedReturns(benchmark)
+	if len(bench) < 2 {
+		return 0
+	}
+
+	c := Correlation(rors, bench)
+	return c * c
 }
 
 func (p *PerfEval) Len() int {
@@ -1,11 +1,16 @@
 package perfeval
 
 import (
+	"math"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gocarina/gocsv"
 	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/perf"
 	"github.com/wonderstone/QuantKit/tools/recorder"
 )
 
@@ -63,3 +68,130 @@ func TestSplit(t *testing.T) {
 		t.Error("Split failed")
 	}
 }
+
+func TestPerfEvalIndicators(t *testing.T) {
+	var records []recorder.AssetRecord
+	benchmark := make(map[string]float64)
+	assets := []float64{100, 102, 101, 104, 103, 106}
+	bench := []float64{10, 10.1, 10.05, 10.2, 10.1, 10.3}
+	for i := range assets {
+		date := "2023-01-0" + strconv.Itoa(i+1)
+		records = append(records, recorder.AssetRecord{Date: date, TotalAsset: assets[i]})
+		benchmark[date] = bench[i]
+	}
+
+	PE := NewPerfEval(records)
+
+	// 策略收益率与基准收益率完全线性相关
+	require.InDelta(t, 1.0, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.R2), WithBenchmark(benchmark)), 0.05)
+	require.Greater(t, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.Beta), WithBenchmark(benchmark)), 1.0)
+	require.InDelta(t, 0.3188038872790944, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.Volatility)), 1e-12)
+	require.InDelta(t, 0.09748873766260822, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.DownsideRisk)), 1e-12)
+	require.InDelta(t, 1.0/102, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.MaxDrawdown)), 1e-9)
+
+	// 买卖各成交50，单边换手50，平均资产616/6，按6个交易日年化
+	orders := []recorder.OrderRecord{
+		{OrderId: 1, OrderDirection: "B", TradePrice: 10, TradeQty: 5},
+		{OrderId: 2, OrderDirection: "S", TradePrice: 10, TradeQty: 5},
+	}
+	require.InDelta(
+		t, 50/(616.0/6)*252/6,
+		PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.Turnover), WithOrderRecords(orders)), 1e-9,
+	)
+
+	// 相对基准的指标没有基准时报错
+	require.Panics(t, func() { PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.Alpha)) })
+}
+
+// 测试超过一年的序列按252个交易日年化，比率类指标不为0
+func TestAnnualized(t *testing.T) {
+	var records []recorder.AssetRecord
+	asset := 100.0
+	day := time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 505; i++ {
+		if i == 300 {
+			asset *= 0.95
+		} else if i > 0 {
+			asset *= 1.001
+		}
+		records = append(records, recorder.AssetRecord{Date: day.AddDate(0, 0, i).Format("2006-01-02"), TotalAsset: asset})
+	}
+
+	PE := NewPerfEval(records)
+	ar := math.Pow(asset/100, 252.0/505)
+	require.InDelta(t, ar, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.AnnualizedReturn)), 1e-12)
+	require.InDelta(t, (ar-1)/0.05, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.CalmarRatio)), 1e-9)
+
+	dr := PE.DownsideRisk(0)
+	require.InDelta(t, 0.05/math.Sqrt(504)*math.Sqrt(252), dr, 1e-12)
+	require.InDelta(t, (ar-1)/dr, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.SortinoRatio)), 1e-9)
+	require.Greater(t, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.SterlingRatio)), 0.0)
+	require.InDelta(t, (ar-1)/(math.Sqrt(252)*Std(PE.RateOfReturns(), 1)), PE.SharpeRatio(0), 1e-9)
+}
+
+func TestRoundTrips(t *testing.T) {
+	orders := []recorder.OrderRecord{
+		{OrderId: 1, InstId: "A", OrderDirection: "B", PositionDirection: "L", TradePrice: 10, TradeQty: 200},
+		{OrderId: 1, InstId: "A", OrderDirection: "B", PositionDirection: "L", TradePrice: 10, TradeQty: 200}, // 结算重复记录
+		{OrderId: 2, InstId: "A", OrderDirection: "S", PositionDirection: "L", TradePrice: 12, TradeQty: 100},
+		{OrderId: 3, InstId: "A", OrderDirection: "S", PositionDirection: "L", TradePrice: 9, TradeQty: 100},
+		{OrderId: 4, InstId: "IF", OrderDirection: "S", PositionDirection: "S", TradePrice: 4000, TradeQty: 1},
+		{OrderId: 5, InstId: "IF", OrderDirection: "B", PositionDirection: "S", TradePrice: 3900, TradeQty: 1},
+		{OrderId: 6, InstId: "A", OrderDirection: "S", PositionDirection: "L", TradeQty: 0},
+	}
+
+	trips := RoundTrips(orders)
+	require.Len(t, trips, 3)
+	require.Equal(t, []float64{200, -100, 100}, []float64{trips[0].PnL, trips[1].PnL, trips[2].PnL})
+	require.Equal(t, 10.0, trips[0].OpenPrice)
+
+	PE := NewPerfEval(nil)
+	require.InDelta(t, 2.0/3, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.WinRate), WithOrderRecords(orders)), 1e-9)
+	require.InDelta(t, 3.0, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.ProfitFactor), WithOrderRecords(orders)), 1e-9)
+	require.InDelta(t, 1.5, PE.CalcPerfEvalResult(WithPerformanceIndicateType(perf.GainLossRatio), WithOrderRecords(orders)), 1e-9)
+
+	// 有完整交易记录时交易类指标使用完整交易日志
+	journal := []recorder.RoundTripRecord{{PnL: 300, HoldingDays: 2}, {PnL: -100, HoldingDays: 5}}
+	options := []WithOption{WithOrderRecords(orders), WithRoundTripRecords(journal)}
+	require.InDelta(t, 0.5, PE.CalcPerfEvalResult(append(options, WithPerformanceIndicateType(perf.WinRate))...), 1e-9)
+	require.InDelta(t, 3.0, PE.CalcPerfEvalResult(append(options, WithPerformanceIndicateType(perf.ProfitFactor))...), 1e-9)
+	require.InDelta(t, 3.5, PE.CalcPerfEvalResult(append(options, WithPerformanceIndicateType(perf.AvgHoldingDays))...), 1e-9)
+}
+
+// 测试训练记录: 按评估指标创建记录器，交易类指标记录订单，相对基准的指标必须配置基准序列
+func TestTrainRecords(t *testing.T) {
+	var r TrainRecords
+	recorders, err := r.Recorders(
+		config.Performance{
+			PerformanceType: string(perf.TotalReturn),
+			Objectives:      []config.Objective{{Type: string(perf.TotalReturn)}, {Type: string(perf.WinRate)}},
+		}, "",
+	)
+	require.NoError(t, err)
+	require.NotNil(t, recorders[config.RecordTypeAsset])
+	require.NotNil(t, recorders[config.RecordTypeOrder])
+	require.NotNil(t, recorders[config.RecordTypeRoundTrip])
+
+	assets := recorders[config.RecordTypeAsset]
+	done := make(chan struct{})
+	go func() {
+		_ = assets.RecordChan()
+		close(done)
+	}()
+	assets.GetChannel() <- &recorder.AssetRecord{Date: "2024-01-02", TotalAsset: 100}
+	assets.GetChannel() <- &recorder.AssetRecord{Date: "2024-01-03", TotalAsset: 110}
+	assets.Release()
+	<-done
+
+	require.InDelta(t, 1.1, r.Performance(), 1e-9)
+	require.Len(t, r.Objectives(), 2)
+	require.InDelta(t, 1.1, r.Objectives()[0], 1e-9)
+
+	// 只记录资产
+	recorders, err = (&TrainRecords{}).Recorders(config.Performance{PerformanceType: string(perf.TotalReturn)}, "")
+	require.NoError(t, err)
+	require.Len(t, recorders, 1)
+
+	_, err = (&TrainRecords{}).Recorders(config.Performance{PerformanceType: string(perf.Beta)}, "")
+	require.Error(t, err)
+}
@@ -34,3 +34,27 @@ func Std(values []float64, ddof int) float64 {
 
 	return math.Sqrt(ss / float64(len(values)-ddof))
 }
+
+// Covariance (协方差)
+// 两个序列长度需要一致, ddof 含义同 Std
+func Covariance(x, y []float64, ddof int) float64 {
+	if len(x) == 0 || len(x) != len(y) {
+		return math.NaN()
+	}
+	mx, my := Mean(x), Mean(y)
+	ss := 0.
+	for i := range x {
+		ss += (x[i] - mx) * (y[i] - my)
+	}
+
+	return ss / float64(len(x)-ddof)
+}
+
+// Correlation (相关系数)
+func Correlation(x, y []float64) float64 {
+	sx, sy := Std(x, 1), Std(y, 1)
+	if sx == 0 || sy == 0 {
+		return 0
+	}
+	return Covariance(x, y, 1) / (sx * sy)
+}
@@ -0,0 +1,232 @@
+package perfeval
+
+import (
+	"sort"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// ~ 交易类指标(胜率、盈亏比)基于完整交易计算，优先使用账户记录的完整交易日志
+// ~ 没有完整交易记录时由订单记录配对出完整交易(开仓 -> 平仓)
+// ~ 同一订单会多次记录(成交、结算)，只取最后一条记录
+// ~ 按订单ID顺序、先进先出配对，盈亏扣除按数量分摊的开平手续费
+
+type lot struct {
+	qty        float64 // 剩余数量
+	price      float64 // 开仓价格
+	commission float64 // 单位数量手续费
+}
+
+type tradeKey struct {
+	account   string
+	instID    string
+	direction string
+}
+
+// RoundTrip 一笔完整交易(开仓 -> 平仓)
+// 期货记录中没有合约乘数，Gross和PnL为按价格计算的盈亏，需要乘以合约乘数才是金额
+type RoundTrip struct {
+	Account    string  // 账户
+	InstID     string  // 合约
+	Direction  string  // 持仓方向
+	Qty        float64 // 平仓数量
+	OpenPrice  float64 // 开仓均价
+	ClosePrice float64 // 平仓价格
+	Commission float64 // 分摊的开平手续费
+	Gross      float64 // 毛盈亏(不含手续费)
+	PnL        float64 // 净盈亏 = 毛盈亏 - 手续费
+}
+
+// FinalOrders 订单记录去重，同一订单只保留最后一条记录，并按订单ID排序
+func FinalOrders(orders []recorder.OrderRecord) []recorder.OrderRecord {
+	type orderKey struct {
+		account string
+		id      int64
+	}
+
+	last := make(map[orderKey]recorder.OrderRecord)
+	for _, o := range orders {
+		last[orderKey{o.Account, o.OrderId}] = o
+	}
+
+	final := make([]recorder.OrderRecord, 0, len(last))
+	for _, o := range last {
+		final = append(final, o)
+	}
+
+	sort.Slice(final, func(i, j int) bool {
+		if final[i].OrderId != final[j].OrderId {
+			return final[i].OrderId < final[j].OrderId
+		}
+		return final[i].Account < final[j].Account
+	})
+
+	return final
+}
+
+// RoundTrips 由订单记录配对完整交易
+func RoundTrips(orders []recorder.OrderRecord) (trips []RoundTrip) {
+	lots := make(map[tradeKey][]lot)
+	for _, o := range FinalOrders(orders) {
+		if o.TradeQty <= 0 {
+			continue
+		}
+
+		key := tradeKey{o.Account, o.InstId, o.PositionDirection}
+		unitComm := o.Commission / o.TradeQty
+
+		// 买多、卖空为开仓，否则为平仓
+		long := o.PositionDirection != string(config.PositionShort)
+		if (o.OrderDirection == string(config.OrderBuy)) == long {
+			lots[key] = append(lots[key], lot{qty: o.TradeQty, price: o.TradePrice, commission: unitComm})
+			continue
+		}
+
+		sign := 1.0
+		if !long {
+			sign = -1
+		}
+
+		trip := RoundTrip{Account: o.Account, InstID: o.InstId, Direction: o.PositionDirection, ClosePrice: o.TradePrice}
+		openAmt := 0.0
+		for trip.Qty < o.TradeQty && len(lots[key]) > 0 {
+			l := &lots[key][0]
+			qty := min(o.TradeQty-trip.Qty, l.qty)
+			openAmt += l.price * qty
+			trip.Gross += sign * (o.TradePrice - l.price) * qty
+			trip.Commission += (l.commission + unitComm) * qty
+			trip.Qty += qty
+
+			l.qty -= qty
+			if l.qty <= 0 {
+				lots[key] = lots[key][1:]
+			}
+		}
+
+		if trip.Qty > 0 {
+			trip.OpenPrice = openAmt / trip.Qty
+			trip.PnL = trip.Gross - trip.Commission
+			trips = append(trips, trip)
+		}
+	}
+
+	return
+}
+
+// tripPnL 完整交易的净盈亏
+func (p *PerfEval) tripPnL(op *Op) []float64 {
+	if op.RoundTrips != nil {
+		pnl := make([]float64, len(op.RoundTrips))
+		for i, trip := range op.RoundTrips {
+			pnl[i] = trip.PnL
+		}
+
+		return pnl
+	}
+
+	if op.Orders == nil {
+		config.ErrorF("没有订单记录，无法计算交易类指标")
+	}
+
+	trips := RoundTrips(op.Orders)
+	pnl := make([]float64, len(trips))
+	for i, trip := range trips {
+		pnl[i] = trip.PnL
+	}
+
+	return pnl
+}
+
+// WinRate 胜率 = 盈利交易笔数 / 总交易笔数
+func (p *PerfEval) WinRate(trips []float64) float64 {
+	if len(trips) == 0 {
+		return 0
+	}
+
+	win := 0
+	for _, v := range trips {
+		if v > 0 {
+			win++
+		}
+	}
+
+	return float64(win) / float64(len(trips))
+}
+
+// ProfitFactor 盈利因子 = 总盈利 / 总亏损
+func (p *PerfEval) ProfitFactor(trips []float64) float64 {
+	gain, loss := 0.0, 0.0
+	for _, v := range trips {
+		if v > 0 {
+			gain += v
+		} else {
+			loss -= v
+		}
+	}
+
+	if loss == 0 {
+		return 0
+	}
+	return gain / loss
+}
+
+// GainLossRatio 盈亏比 = 平均盈利 / 平均亏损
+func (p *PerfEval) GainLossRatio(trips []float64) float64 {
+	var gains, losses []float64
+	for _, v := range trips {
+		if v > 0 {
+			gains = append(gains, v)
+		} else if v < 0 {
+			losses = append(losses, -v)
+		}
+	}
+
+	if len(gains) == 0 || len(losses) == 0 {
+		return 0
+	}
+	return Mean(gains) / Mean(losses)
+}
+
+// AvgHoldingDays 平均持仓交易日数，只有完整交易记录才有持仓时间
+func (p *PerfEval) AvgHoldingDays(trips []recorder.RoundTripRecord) float64 {
+	if len(trips) == 0 {
+		return 0
+	}
+
+	days := make([]float64, len(trips))
+	for i, trip := range trips {
+		days[i] = float64(trip.HoldingDays)
+	}
+
+	return Mean(days)
+}
+
+// Turnover 年化换手率 = 成交金额 / 2 / 平均总资产 * 252 / 交易日数
+// 成交金额为买卖双边之和，取一半作为单边换手；期货记录中没有合约乘数，按价格乘数量计算
+func (p *PerfEval) Turnover(orders []recorder.OrderRecord) float64 {
+	if orders == nil {
+		config.ErrorF("没有订单记录，无法计算交易类指标")
+	}
+
+	if p.Len() == 0 {
+		return 0
+	}
+
+	amount := 0.0
+	for _, o := range FinalOrders(orders) {
+		amount += o.TradePrice * o.TradeQty
+	}
+
+	assets := make([]float64, p.Len())
+	for i, r := range p.Records {
+		assets[i] = r.TotalAsset
+	}
+
+	mean := Mean(assets)
+	if mean == 0 {
+		return 0
+	}
+
+	return amount / 2 / mean * 252 / float64(p.Len())
+}
@@ -0,0 +1,100 @@
+package perfeval
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/perf"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// TrainRecords 训练模式下在内存中记录的评估数据，回放和实时框架共用
+type TrainRecords struct {
+	conf config.Performance
+
+	assets    *recorder.MemoryRecorder[recorder.AssetRecord]     // 记录训练结果，用于计算最终的结果
+	orders    *recorder.MemoryRecorder[recorder.OrderRecord]     // 训练时记录订单，用于计算交易类指标
+	trips     *recorder.MemoryRecorder[recorder.RoundTripRecord] // 训练时记录完整交易，用于计算交易类指标
+	benchmark map[string]float64                                 // 基准序列，用于计算相对基准的指标
+}
+
+// Recorders 创建训练模式的记录器，只记录资产，交易类指标需要额外记录订单，相对基准的指标需要加载基准序列
+// 基准序列的相对路径基于基础数据目录
+func (r *TrainRecords) Recorders(conf config.Performance, base string) (map[config.RecordType]recorder.Handler, error) {
+	r.conf = conf
+	recorders := make(map[config.RecordType]recorder.Handler)
+	r.assets = recorder.NewMemoryRecorder[recorder.AssetRecord]()
+	recorders[config.RecordTypeAsset] = r.assets
+
+	needOrders, needBenchmark := false, false
+	for _, t := range conf.IndicateTypes() {
+		needOrders = needOrders || perf.IndicateType(t).NeedOrders()
+		needBenchmark = needBenchmark || perf.IndicateType(t).NeedBenchmark()
+	}
+
+	if needOrders {
+		r.orders = recorder.NewMemoryRecorder[recorder.OrderRecord]()
+		recorders[config.RecordTypeOrder] = r.orders
+		r.trips = recorder.NewMemoryRecorder[recorder.RoundTripRecord]()
+		recorders[config.RecordTypeRoundTrip] = r.trips
+	}
+
+	if needBenchmark {
+		file := conf.Benchmark
+		if file == "" {
+			return nil, fmt.Errorf("评估指标 %v 需要配置基准序列 performance.benchmark", conf.IndicateTypes())
+		}
+
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(base, file)
+		}
+
+		benchmark, err := LoadBenchmark(file)
+		if err != nil {
+			return nil, err
+		}
+		r.benchmark = benchmark
+	}
+
+	return recorders, nil
+}
+
+// Performance 计算配置的评估指标
+func (r *TrainRecords) Performance() float64 {
+	pe := NewPerfEval(r.assets.GetRecord(), true)
+	return pe.CalcPerfEvalResult(r.options(perf.IndicateType(r.conf.PerformanceType))...)
+}
+
+// Objectives 按配置的训练目标顺序计算各指标
+func (r *TrainRecords) Objectives() []float64 {
+	if len(r.conf.Objectives) == 0 {
+		return nil
+	}
+
+	pe := NewPerfEval(r.assets.GetRecord(), true)
+	result := make([]float64, len(r.conf.Objectives))
+	for i, o := range r.conf.Objectives {
+		result[i] = pe.CalcPerfEvalResult(r.options(perf.IndicateType(o.Type))...)
+	}
+
+	return result
+}
+
+func (r *TrainRecords) options(tag perf.IndicateType) []WithOption {
+	options := []WithOption{
+		WithPerformanceIndicateType(tag),
+		WithRiskFreeRate(r.conf.RiskFreeRate),
+		WithBenchmark(r.benchmark),
+	}
+
+	if r.orders != nil {
+		options = append(options, WithOrderRecords(r.orders.GetRecord()))
+	}
+
+	if r.trips != nil {
+		options = append(options, WithRoundTripRecords(r.trips.GetRecord()))
+	}
+
+	return options
+}
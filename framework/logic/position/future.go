@@ -0,0 +1,420 @@
+package position
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// ~ 期货持仓按多空两个方向分别记录，每个方向再区分今仓和昨仓
+// ~ 盯市结算: 今仓以开仓价为基准价，昨仓以上一交易日结算价为基准价
+// ~ 浮动盈亏和保证金都以基准价计算，结算后基准价统一更新为当日结算价
+
+type FutureValue struct {
+	openPrice float64 // 开仓均价
+	basePrice float64 // 盯市基准价
+	lastPrice float64 // 最新价格
+	volume    float64 // 持仓数量
+}
+
+func (v *FutureValue) add(qty, price float64) {
+	if qty == 0 {
+		return
+	}
+
+	volume := v.volume + qty
+	v.openPrice = (v.openPrice*v.volume + price*qty) / volume
+	v.basePrice = (v.basePrice*v.volume + price*qty) / volume
+	v.lastPrice = price
+	v.volume = volume
+}
+
+func (v *FutureValue) sub(qty float64) {
+	v.volume -= qty
+	if v.volume <= 0 {
+		*v = FutureValue{lastPrice: v.lastPrice}
+	}
+}
+
+// FutureLeg 单一方向的期货持仓
+type FutureLeg struct {
+	direction config.PositionDirection
+	today     FutureValue // 今仓
+	his       FutureValue // 昨仓
+	frozen    float64     // 平仓冻结数量
+	tplus     int         // T+N，0表示今仓可平
+	locked    []float64   // T+N(N>=2)时最近N-1个交易日开仓、尚不可平的昨仓数量
+}
+
+func (l *FutureLeg) lockedQty() float64 {
+	qty := 0.0
+	for _, v := range l.locked {
+		qty += v
+	}
+
+	return min(qty, l.his.volume)
+}
+
+func (l *FutureLeg) volume() float64 {
+	return l.today.volume + l.his.volume
+}
+
+// closable 可平数量，平昨或T+N(N>=1)只能平已解锁的昨仓
+func (l *FutureLeg) closable(offset config.TransactionType) float64 {
+	if offset == config.OffsetCloseHis || l.tplus > 0 {
+		return l.his.volume - l.lockedQty() - l.frozen
+	}
+
+	return l.volume() - l.frozen
+}
+
+func (l *FutureLeg) sign() float64 {
+	if l.direction == config.PositionShort {
+		return -1
+	}
+
+	return 1
+}
+
+// profit 以基准价计算的盈亏
+func (l *FutureLeg) profit(c contract.Contract, qty, basePrice, price float64) float64 {
+	return l.sign() * (c.CalcMarketValue(qty, price, l.direction) - c.CalcMarketValue(qty, basePrice, l.direction))
+}
+
+// close 平仓，平昨或T+N(N>=1)只平昨仓，否则先平昨仓再平今仓
+func (l *FutureLeg) close(
+	c contract.Contract, qty, price float64, offset config.TransactionType,
+) (todayQty, hisQty, closeProfit float64) {
+	hisQty = min(qty, l.his.volume)
+	if offset != config.OffsetCloseHis && l.tplus == 0 {
+		todayQty = min(qty-hisQty, l.today.volume)
+	}
+
+	closeProfit = l.profit(c, hisQty, l.his.basePrice, price) + l.profit(c, todayQty, l.today.basePrice, price)
+
+	l.his.sub(hisQty)
+	l.today.sub(todayQty)
+	l.frozen = max(l.frozen-todayQty-hisQty, 0)
+
+	return
+}
+
+func (l *FutureLeg) floatProfit(c contract.Contract) float64 {
+	return l.profit(c, l.today.volume, l.today.basePrice, l.today.lastPrice) +
+		l.profit(c, l.his.volume, l.his.basePrice, l.his.lastPrice)
+}
+
+func (l *FutureLeg) positionProfit(c contract.Contract) float64 {
+	return l.profit(c, l.today.volume, l.today.openPrice, l.today.lastPrice) +
+		l.profit(c, l.his.volume, l.his.openPrice, l.his.lastPrice)
+}
+
+func (l *FutureLeg) margin(c contract.Contract) float64 {
+	return c.CalcMargin(l.today.volume, l.today.basePrice, l.direction) +
+		c.CalcMargin(l.his.volume, l.his.basePrice, l.direction)
+}
+
+func (l *FutureLeg) openPrice() float64 {
+	if l.volume() == 0 {
+		return 0
+	}
+
+	return (l.today.openPrice*l.today.volume + l.his.openPrice*l.his.volume) / l.volume()
+}
+
+func (l *FutureLeg) setLastPrice(price float64) {
+	l.today.lastPrice = price
+	l.his.lastPrice = price
+}
+
+// settle 盯市结算，今仓转为昨仓，基准价更新为结算价
+func (l *FutureLeg) settle(price float64) {
+	volume := l.volume()
+	if volume != 0 {
+		l.his.openPrice = l.openPrice()
+	}
+
+	// T+N(N>=2) 今日开仓的数量还需锁定N-1个交易日
+	if l.tplus >= 2 {
+		l.locked = append(l.locked, l.today.volume)
+		if len(l.locked) > l.tplus-1 {
+			l.locked = l.locked[len(l.locked)-l.tplus+1:]
+		}
+	}
+
+	l.his.volume = volume
+	l.his.basePrice = price
+	l.his.lastPrice = price
+	l.today = FutureValue{lastPrice: price}
+	l.frozen = 0
+}
+
+// + FuturePosition
+// + 实现 entity.Position 接口
+// + 实现 handler.Position 接口
+
+type FuturePosition struct {
+	contract  contract.Contract // 合约
+	account   handler.Account2
+	openTime  time.Time // 开仓时间
+	lastPrice float64
+	long      FutureLeg // 多头
+	short     FutureLeg // 空头
+}
+
+func (f *FuturePosition) leg(direction config.PositionDirection) *FutureLeg {
+	if direction == config.PositionShort {
+		return &f.short
+	}
+
+	return &f.long
+}
+
+// legs 根据过滤条件返回持仓方向，总体方向返回多空两个方向
+func (f *FuturePosition) legs(opts ...account.WithOpFilterPos) []*FutureLeg {
+	opt := newFutureOpt(opts...)
+	if opt.Direction == config.PositionOverall {
+		return []*FutureLeg{&f.long, &f.short}
+	}
+
+	return []*FutureLeg{f.leg(opt.Direction)}
+}
+
+// & FuturePosition 实现 entity Position 接口
+
+func (f *FuturePosition) InstID() string {
+	return f.contract.GetInstID()
+}
+
+func (f *FuturePosition) DefaultDirection() config.PositionDirection {
+	return config.PositionLong
+}
+
+func (f *FuturePosition) AvailableDirection() []config.PositionDirection {
+	return []config.PositionDirection{config.PositionLong, config.PositionShort}
+}
+
+// OpenPrice 开仓均价, 总体方向下为多空持仓的加权均价
+func (f *FuturePosition) OpenPrice(opts ...account.WithOpFilterPos) float64 {
+	amt, volume := 0.0, 0.0
+	for _, l := range f.legs(opts...) {
+		amt += l.openPrice() * l.volume()
+		volume += l.volume()
+	}
+
+	if volume == 0 {
+		return 0
+	}
+
+	return amt / volume
+}
+
+func (f *FuturePosition) OpenTime(opts ...account.WithOpFilterPos) time.Time {
+	return f.openTime
+}
+
+// Volume 持仓数量, SellAvailable为可平数量
+func (f *FuturePosition) Volume(opts ...account.WithOpFilterPos) float64 {
+	opt := newFutureOpt(opts...)
+
+	volume := 0.0
+	for _, l := range f.legs(opts...) {
+		if opt.SellAvailable {
+			volume += l.closable(config.OffsetClose)
+		} else {
+			volume += l.volume()
+		}
+	}
+
+	return volume
+}
+
+// Amt 持仓名义价值
+func (f *FuturePosition) Amt(opts ...account.WithOpFilterPos) float64 {
+	amt := 0.0
+	for _, l := range f.legs(opts...) {
+		amt += f.contract.CalcMarketValue(l.volume(), f.lastPrice, l.direction)
+	}
+
+	return amt
+}
+
+// PnL 持仓盈亏，以开仓均价计算
+func (f *FuturePosition) PnL(opts ...account.WithOpFilterPos) account.PnL {
+	pnl := account.PnL{}
+	for _, l := range f.legs(opts...) {
+		pnl.Profit += l.positionProfit(f.contract)
+	}
+
+	return pnl
+}
+
+// & FuturePosition 实现 entity Position 接口 完毕
+
+// FloatProfit 盯市浮动盈亏，以基准价计算
+func (f *FuturePosition) FloatProfit() float64 {
+	return f.long.floatProfit(f.contract) + f.short.floatProfit(f.contract)
+}
+
+// Margin 持仓占用保证金
+func (f *FuturePosition) Margin() float64 {
+	return f.long.margin(f.contract) + f.short.margin(f.contract)
+}
+
+// Closable 某一方向在开平标志下的可平数量
+func (f *FuturePosition) Closable(direction config.PositionDirection, offset config.TransactionType) float64 {
+	return f.leg(direction).closable(offset)
+}
+
+// TradeSplit 成交更新持仓，平仓返回平今、平昨数量以及盯市平仓盈亏，供账户拆分手续费
+func (f *FuturePosition) TradeSplit(qty, price float64, order handler.Order) (todayQty, hisQty, closeProfit float64) {
+	f.lastPrice = price
+	l := f.leg(order.PositionDirection())
+
+	if order.TransactionType() == config.OffsetOpen {
+		if f.long.volume() == 0 && f.short.volume() == 0 {
+			f.openTime = order.OrderTime()
+		}
+
+		l.today.add(qty, price)
+		f.long.setLastPrice(price)
+		f.short.setLastPrice(price)
+		return qty, 0, 0
+	}
+
+	todayQty, hisQty, closeProfit = l.close(f.contract, qty, price, order.TransactionType())
+	f.long.setLastPrice(price)
+	f.short.setLastPrice(price)
+
+	return
+}
+
+// & FuturePosition 实现 handler Position 接口
+
+func (f *FuturePosition) Init(
+	account2 handler.Account2,
+	contract contract.Contract,
+	qty, price float64,
+	direction ...config.PositionDirection,
+) {
+	f.contract = contract
+	f.account = account2
+	f.lastPrice = price
+	f.long = FutureLeg{direction: config.PositionLong, tplus: contract.GetTPlus()}
+	f.short = FutureLeg{direction: config.PositionShort, tplus: contract.GetTPlus()}
+
+	if qty != 0 {
+		f.leg(direction[0]).today.add(qty, price)
+	}
+
+	f.long.setLastPrice(price)
+	f.short.setLastPrice(price)
+}
+
+// DoOrderUpdate 平仓订单冻结、解冻可平数量
+func (f *FuturePosition) DoOrderUpdate(order handler.Order) {
+	if order.TransactionType() == config.OffsetOpen {
+		return
+	}
+
+	l := f.leg(order.PositionDirection())
+	switch order.OrderStatus() {
+	case config.OrderStatusNew:
+		l.frozen += order.OrderQty()
+	case config.OrderStatusCanceled, config.OrderStatusPartDonePartCancel,
+		config.OrderStatusExpired, config.OrderStatusRejected:
+		l.frozen = max(l.frozen-(order.OrderQty()-order.TradeQty()), 0)
+	}
+}
+
+func (f *FuturePosition) DoTradeUpdate(qty, price float64, order handler.Order) (
+	deltaActualPnL float64, deltaFloatingPnL float64,
+) {
+	floating := f.FloatProfit()
+	_, _, deltaActualPnL = f.TradeSplit(qty, price, order)
+	deltaFloatingPnL = f.FloatProfit() - floating
+
+	return
+}
+
+// DoSettle 按结算价盯市，记录多空持仓，返回是否仍有持仓
+func (f *FuturePosition) DoSettle(tm time.Time, price float64, recorder recorder.Handler) bool {
+	if price == 0 {
+		price = f.lastPrice
+	}
+
+	f.lastPrice = price
+	for _, l := range []*FutureLeg{&f.long, &f.short} {
+		l.setLastPrice(price)
+		if l.volume() != 0 && recorder != nil {
+			record := setting.MakePositionRecord(
+				tm, f.contract.GetInstID(), l.direction, l.openPrice(), price,
+				l.volume(), l.positionProfit(f.contract),
+			)
+			record.Amt = f.contract.CalcMarketValue(l.volume(), price, l.direction)
+			if f.account != nil {
+				record.Account = f.account.AccountID()
+			}
+
+			recorder.GetChannel() <- record
+		}
+
+		l.settle(price)
+	}
+
+	return f.long.volume() != 0 || f.short.volume() != 0
+}
+
+// DoResume 恢复持仓，记录中的持仓都是昨仓，基准价为上一交易日结算价
+func (f *FuturePosition) DoResume(
+	acc handler.Account2,
+	contract contract.Contract,
+	record recorder.PositionRecord,
+) {
+	if f.contract == nil {
+		f.Init(acc, contract, 0, record.LastPrice)
+	}
+
+	f.lastPrice = record.LastPrice
+	l := f.leg(config.PositionDirection(record.Direction))
+	l.his = FutureValue{
+		openPrice: record.CostPrice,
+		basePrice: record.LastPrice,
+		lastPrice: record.LastPrice,
+		volume:    record.Volume,
+	}
+}
+
+func (f *FuturePosition) CalcPnL(tm time.Time, price float64) {
+	if price == 0 {
+		return
+	}
+
+	f.lastPrice = price
+	f.long.setLastPrice(price)
+	f.short.setLastPrice(price)
+}
+
+// AddSettle 期货没有除权除息，按多头昨仓处理
+func (f *FuturePosition) AddSettle(settleQty, settlePrice float64) {
+	f.long.his.add(settleQty, settlePrice)
+}
+
+// & FuturePosition 实现 handler Position 接口 完毕
+
+func newFutureOpt(opts ...account.WithOpFilterPos) *account.OpFilterPos {
+	opt := &account.OpFilterPos{
+		Direction:     config.PositionOverall,
+		SellAvailable: false,
+	}
+	for _, v := range opts {
+		v(opt)
+	}
+
+	return opt
+}
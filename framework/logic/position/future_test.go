@@ -0,0 +1,94 @@
+package position
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	_ "github.com/wonderstone/QuantKit/framework/logic/contract"
+	"github.com/wonderstone/QuantKit/framework/logic/order"
+	"github.com/wonderstone/QuantKit/framework/setting"
+)
+
+func newFutureOrder(
+	c contract.Contract, qty float64, direction config.OrderDirection, offset config.TransactionType,
+) handler.Order {
+	return order.NewOrder(
+		1, c, qty, account.NewOrderOp(
+			account.WithOrderTime(time.Now()),
+			account.WithOrderPrice(4000),
+			account.WithOrderDirection(direction),
+			account.WithTransactionType(offset),
+		),
+	)
+}
+
+// 测试期货多空持仓、平今平昨拆分以及盯市结算
+func TestFuturePosition(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("./contract.yaml")
+	require.NoError(t, err)
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+	contr := handle.GetContract("IF2406.CCFX.CF")
+
+	pos := NewPosition(nil, contr, 0, 4000).(*FuturePosition)
+
+	// 买开2手多头，卖开1手空头
+	pos.TradeSplit(2, 4000, newFutureOrder(contr, 2, config.OrderBuy, config.OffsetOpen))
+	pos.TradeSplit(1, 4000, newFutureOrder(contr, 1, config.OrderSell, config.OffsetOpen))
+	require.Equal(t, 2.0, pos.Volume(account.WithDirection(config.PositionLong)))
+	require.Equal(t, 1.0, pos.Volume(account.WithDirection(config.PositionShort)))
+	require.Equal(t, 3.0, pos.Volume())
+
+	// 价格上涨10点, 多头盈利 2*10*300, 空头亏损 1*10*300
+	pos.CalcPnL(time.Now(), 4010)
+	require.InDelta(t, 3000.0, pos.FloatProfit(), 1e-6)
+	require.InDelta(t, -3000.0, pos.PnL(account.WithDirection(config.PositionShort)).Profit, 1e-6)
+
+	// 结算价4020, 今仓转为昨仓, 基准价更新为结算价
+	require.True(t, pos.DoSettle(time.Now(), 4020, nil))
+	require.InDelta(t, 0.0, pos.FloatProfit(), 1e-6)
+	require.InDelta(t, 12000.0, pos.PnL(account.WithDirection(config.PositionLong)).Profit, 1e-6)
+	require.InDelta(t, 3*4020*300*0.12, pos.Margin(), 1e-6)
+
+	// 次日再买开1手, 然后卖平2手: 先平昨仓2手
+	pos.TradeSplit(1, 4030, newFutureOrder(contr, 1, config.OrderBuy, config.OffsetOpen))
+	today, his, profit := pos.TradeSplit(2, 4030, newFutureOrder(contr, 2, config.OrderSell, config.OffsetClose))
+	require.Equal(t, 0.0, today)
+	require.Equal(t, 2.0, his)
+	require.InDelta(t, 2*10*300.0, profit, 1e-6)
+
+	// 平昨只能平昨仓, 多头只剩今仓
+	require.Equal(t, 0.0, pos.Closable(config.PositionLong, config.OffsetCloseHis))
+	require.Equal(t, 1.0, pos.Closable(config.PositionLong, config.OffsetClose))
+
+	// 买平空头1手
+	today, his, profit = pos.TradeSplit(1, 4000, newFutureOrder(contr, 1, config.OrderBuy, config.OffsetClose))
+	require.Equal(t, 1.0, his)
+	require.Equal(t, 0.0, today)
+	require.InDelta(t, 20*300.0, profit, 1e-6)
+	require.Equal(t, 0.0, pos.Volume(account.WithDirection(config.PositionShort)))
+}
+
+// 测试T+1的期货合约: 今仓不可平，结算后才能平仓
+func TestFutureTPlus(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("./contract.yaml")
+	require.NoError(t, err)
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+	contr := tplusContract{Contract: handle.GetContract("IF2406.CCFX.CF"), tplus: 1}
+
+	pos := NewPosition(nil, contr, 0, 4000).(*FuturePosition)
+	pos.TradeSplit(2, 4000, newFutureOrder(contr, 2, config.OrderBuy, config.OffsetOpen))
+	require.Equal(t, 0.0, pos.Closable(config.PositionLong, config.OffsetClose))
+
+	today, his, _ := pos.TradeSplit(2, 4000, newFutureOrder(contr, 2, config.OrderSell, config.OffsetClose))
+	require.Equal(t, 0.0, today+his)
+
+	pos.DoSettle(time.Now(), 4000, nil)
+	require.Equal(t, 2.0, pos.Volume(account.WithDirection(config.PositionLong), account.WithSellAvailable(true)))
+}
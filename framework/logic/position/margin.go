@@ -0,0 +1,91 @@
+package position
+
+import (
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// ~ 融资融券持仓与期货持仓一样按多空两个方向、今仓昨仓分别记录
+// ~ 多头为担保品买入或融资买入的持仓，按合约的T+N卖出
+// ~ 空头为融券卖出的持仓，买券还券不受T+N限制
+// ~ 股票市值和保证金由合约按数量 * 价格计算
+// ~ 除权除息在结算后由账户按方向计算，空头以负数量计算，应付的分红为负
+
+type MarginPosition struct {
+	FuturePosition
+}
+
+func NewMarginPosition(acc handler.Account2, c contract.Contract, price float64) *MarginPosition {
+	p := &MarginPosition{}
+	p.Init(acc, c, 0, price, config.PositionLong)
+	p.short.tplus = 0
+
+	return p
+}
+
+// Closable 某一方向的可平数量
+func (m *MarginPosition) Closable(direction config.PositionDirection) float64 {
+	return m.FuturePosition.Closable(direction, config.OffsetClose)
+}
+
+// Volume 持仓数量, SellAvailable为可卖(可还)数量
+func (m *MarginPosition) Volume(opts ...account.WithOpFilterPos) float64 {
+	opt := newFutureOpt(opts...)
+	if !opt.SellAvailable {
+		return m.FuturePosition.Volume(opts...)
+	}
+
+	volume := 0.0
+	for _, l := range m.legs(opts...) {
+		volume += m.Closable(l.direction)
+	}
+
+	return volume
+}
+
+// DoSettle 盯市结算后按除权除息调整多空持仓，分红和应付的分红计入账户
+func (m *MarginPosition) DoSettle(tm time.Time, price float64, recorder recorder.Handler) bool {
+	hold := m.FuturePosition.DoSettle(tm, price, recorder)
+	if m.account == nil {
+		return hold
+	}
+
+	dividend, tax := 0.0, 0.0
+	for _, l := range []*FutureLeg{&m.long, &m.short} {
+		if l.his.volume == 0 {
+			continue
+		}
+
+		qty, openPrice, lastPrice, d, t := m.account.CalcSettleInfo(
+			m.contract.GetInstID(), l.sign()*l.his.volume, l.his.openPrice, l.his.lastPrice,
+		)
+
+		// 送转股后锁定数量按比例调整
+		volume := l.sign() * qty
+		for i := range l.locked {
+			l.locked[i] *= volume / l.his.volume
+		}
+
+		l.his.volume = volume
+		l.his.openPrice = openPrice
+		if lastPrice > 0 {
+			l.his.basePrice = lastPrice
+			l.setLastPrice(lastPrice)
+			m.lastPrice = lastPrice
+		}
+
+		dividend += d
+		tax += t
+	}
+
+	if dividend != 0 || tax != 0 {
+		m.account.AddDividend(dividend, tax)
+	}
+
+	return hold
+}
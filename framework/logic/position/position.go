@@ -35,4 +35,5 @@ func NewPosition(
 
 func init() {
 	setting.Register(new(StockPosition), config.AccountTypeStockSimple)
+	setting.Register(new(FuturePosition), config.AccountTypeFuture)
 }
\ No newline at end of file
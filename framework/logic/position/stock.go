@@ -95,7 +95,6 @@ func (s *StockValue) AddTrade(qty, price float64) (deltaDynamicPnL float64, delt
 		s.volume = matchVol
 		s.lastPrice = price
 		s.pnl.Profit = pnlNow
-		s.available = matchVol
 	}
 
 	return
@@ -142,6 +141,18 @@ type StockPosition struct {
 	lastPrice float64
 	valToday  StockValue // 今仓
 	valHis    StockValue // 历史仓
+	tplus     int        // T+N，0表示当日买入即可卖出
+	locked    []float64  // T+N(N>=2)时最近N-1个交易日买入、尚不可卖的数量
+}
+
+// lockedQty 尚不可卖的历史仓数量
+func (s *StockPosition) lockedQty() float64 {
+	qty := 0.0
+	for _, v := range s.locked {
+		qty += v
+	}
+
+	return qty
 }
 
 // & StockPosition 实现 entity Position 接口
@@ -225,6 +236,14 @@ func (s *StockPosition) Init(
 	}
 
 	s.valHis = StockValue{}
+	s.tplus = contract.GetTPlus()
+	s.locked = nil
+
+	// T+0 买入即可卖出，直接记入历史仓
+	if s.tplus == 0 {
+		s.valHis, s.valToday = s.valToday, StockValue{lastPrice: price}
+		s.valHis.available = qty
+	}
 }
 
 // ! 这里有问题
@@ -239,6 +258,15 @@ func (s *StockPosition) DoSettle(tm time.Time, price float64, recorder recorder.
 		return false
 	}
 
+	// T+N(N>=2) 今日买入的数量还需锁定N-1个交易日
+	bought := s.valToday.volume
+	if s.tplus >= 2 {
+		s.locked = append(s.locked, bought)
+		if len(s.locked) > s.tplus-1 {
+			s.locked = s.locked[len(s.locked)-s.tplus+1:]
+		}
+	}
+
 	// !请考虑账户盈亏 这个不用position做，由account来做
 	pnl := s.valHis.Add(s.valToday, price)
 	s.account.AddDynamicPnL(pnl)
@@ -254,14 +282,24 @@ func (s *StockPosition) DoSettle(tm time.Time, price float64, recorder recorder.
 	dividend := 0.0
 	tax := 0.0
 	if s.account.Base() != nil {
+		volume := s.valHis.volume
 		s.valHis.volume, s.valHis.openPrice, s.valHis.lastPrice, dividend, tax = s.account.CalcSettleInfo(
 			s.contract.GetInstID(), s.valHis.volume, s.valHis.openPrice, s.valHis.lastPrice,
 		)
 
+		// 送转股后锁定数量按比例调整
+		if volume != 0 && s.valHis.volume != volume {
+			for i := range s.locked {
+				s.locked[i] *= s.valHis.volume / volume
+			}
+		}
+
 		pnl = s.valHis.repair()
 		s.account.AddDynamicPnL(pnl)
 	}
 
+	s.valHis.available = max(s.valHis.volume-s.lockedQty(), 0)
+
 	if s.valHis.volume == 0 {
 		return true
 	}
@@ -293,6 +331,8 @@ func (s *StockPosition) DoResume(
 	// 恢复的数据都是历史
 	s.contract = contract
 	s.account = acc
+	s.tplus = contract.GetTPlus()
+	s.locked = nil
 
 	s.lastPrice = record.LastPrice
 	s.valToday = StockValue{
@@ -348,9 +388,9 @@ func (s *StockPosition) DoOrderUpdate(order handler.Order) {
 	switch order.OrderStatus() {
 	case config.OrderStatusNew:
 		s.valHis.addSellOrder(order.OrderQty())
-	case config.OrderStatusCanceled:
+	case config.OrderStatusCanceled, config.OrderStatusRejected:
 		s.valHis.addSellOrder(-order.OrderQty())
-	case config.OrderStatusPartDonePartCancel, config.OrderStatusDone:
+	case config.OrderStatusPartDonePartCancel, config.OrderStatusDone, config.OrderStatusExpired:
 		s.valHis.addSellOrder(-order.OrderQty() + order.TradeQty())
 	}
 }
@@ -374,6 +414,15 @@ func (s *StockPosition) DoTradeUpdate(qty, price float64, order handler.Order) (
 			s.openTime = order.OrderTime()
 		}
 
+		// T+0 当日买入即可卖出
+		if s.tplus == 0 {
+			deltaPnL += s.valHis.calcPnL(price)
+			s.valHis.AddTrade(qty, price)
+			s.valHis.available += qty
+			deltaPnL += s.valToday.calcPnL(price)
+			return
+		}
+
 		s.valToday.AddTrade(qty, price)
 		deltaPnL += s.valHis.calcPnL(price)
 	} else {
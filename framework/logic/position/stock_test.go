@@ -3,11 +3,15 @@ package position
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
 	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
 	_ "github.com/wonderstone/QuantKit/framework/logic/contract"
+	"github.com/wonderstone/QuantKit/framework/logic/order"
 	"github.com/wonderstone/QuantKit/framework/setting"
 )
 
@@ -28,4 +32,75 @@ func TestNewPosition(t *testing.T) {
 
 	// 如果position不为空，说明生成成功
 	require.NotNil(t, position)
-}
\ No newline at end of file
+}
+// testAccount 持仓结算只需要的账户方法，没有除权除息
+type testAccount struct {
+	handler.Account2
+}
+
+func (a testAccount) AddDynamicPnL(...float64)      {}
+func (a testAccount) AddDividend(float64, float64) {}
+func (a testAccount) Base() handler.Basic           { return nil }
+
+// tplusContract 指定T+N的合约
+type tplusContract struct {
+	contract.Contract
+	tplus int
+}
+
+func (c tplusContract) GetTPlus() int { return c.tplus }
+
+func newStockOrder(c contract.Contract, qty float64, direction config.OrderDirection) handler.Order {
+	return order.NewOrder(
+		1, c, qty, account.NewOrderOp(
+			account.WithOrderTime(time.Now()), account.WithOrderPrice(10), account.WithOrderDirection(direction),
+			account.WithAccount(testAccount{}),
+		),
+	)
+}
+
+// 测试T+0当日买入即可卖出，T+2买入后第二个交易日才能卖出
+func TestStockTPlus(t *testing.T) {
+	conf, err := config.NewContractPropertyConfig("./contract.yaml")
+	require.NoError(t, err)
+
+	handle, _ := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(conf))
+
+	// 债券ETF T+0
+	etf := handle.GetContract("511010.XSHG.CS")
+	require.Equal(t, 0, etf.GetTPlus())
+
+	pos := NewPosition(testAccount{}, etf, 1000, 10)
+	require.Equal(t, 1000.0, pos.Volume(account.WithSellAvailable(true)))
+
+	sell := newStockOrder(etf, 400, config.OrderSell)
+	pos.DoOrderUpdate(sell)
+	require.Equal(t, 600.0, pos.Volume(account.WithSellAvailable(true)))
+
+	pos.DoTradeUpdate(400, 10.1, sell)
+	require.Equal(t, 600.0, pos.Volume())
+	require.Equal(t, 600.0, pos.Volume(account.WithSellAvailable(true)))
+
+	pos.DoTradeUpdate(200, 10.2, newStockOrder(etf, 200, config.OrderBuy))
+	require.Equal(t, 800.0, pos.Volume(account.WithSellAvailable(true)))
+
+	// 可转债 T+0，一手10张
+	bond := handle.GetContract("113050.XSHG.CS")
+	require.Equal(t, 0, bond.GetTPlus())
+	require.Equal(t, 10.0, bond.CalcMaxQty(15))
+
+	// T+2
+	c := tplusContract{Contract: handle.GetContract("000001.XSHE.CS"), tplus: 2}
+	pos = NewPosition(testAccount{}, c, 1000, 10)
+	require.Equal(t, 0.0, pos.Volume(account.WithSellAvailable(true)))
+
+	pos.DoSettle(time.Now(), 10, nil)
+	require.Equal(t, 0.0, pos.Volume(account.WithSellAvailable(true)))
+
+	pos.DoTradeUpdate(500, 10, newStockOrder(c, 500, config.OrderBuy))
+	pos.DoSettle(time.Now(), 10, nil)
+	require.Equal(t, 1000.0, pos.Volume(account.WithSellAvailable(true)))
+
+	pos.DoSettle(time.Now(), 10, nil)
+	require.Equal(t, 1500.0, pos.Volume(account.WithSellAvailable(true)))
+}
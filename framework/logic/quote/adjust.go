@@ -0,0 +1,40 @@
+package quote
+
+import (
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+
+var (
+	adjustPriceFields  = []string{"Open", "High", "Low", "Close"} // 前复权时乘以复权因子
+	adjustVolumeFields = []string{"Volume"}                       // 前复权时除以复权因子，成交额不变
+)
+
+// ForwardAdjust 按行情附带的复权因子将价格和成交量调整为前复权数据，没有复权因子时不调整
+func ForwardAdjust(record dataframe.StreamingRecord) {
+	factor, ok := record.Get(config.AdjustFactorField)
+	if !ok {
+		return
+	}
+
+	adjustBar(record, factor)
+}
+
+// adjustBar 价格乘以复权因子，成交量除以复权因子，成交额不变
+func adjustBar(record dataframe.StreamingRecord, factor float64) {
+	if factor == 1 {
+		return
+	}
+
+	for _, field := range adjustPriceFields {
+		if v, ok := record.Get(field); ok {
+			record.Set(field, v*factor)
+		}
+	}
+
+	for _, field := range adjustVolumeFields {
+		if v, ok := record.Get(field); ok && factor != 0 {
+			record.Set(field, v/factor)
+		}
+	}
+}
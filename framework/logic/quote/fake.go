@@ -114,8 +114,9 @@ func (tmpmkt *TmpMarket) Subscribe() chan tunnel.Tick {
 }
 
 func (tmpmkt *TmpMarket) Publish() {
-	tmpmkt.Sim.Run()
+	// 先订阅再推送，Run 只向已订阅的回放推送
 	datach := tmpmkt.Sim.Subscribe()
+	tmpmkt.Sim.Run()
 
 	for d := range datach.DataChan {
 		fmt.Println(d.Key, d.Value.Value(tmpmkt.inst))
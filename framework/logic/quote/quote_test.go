@@ -2,14 +2,18 @@ package quote
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
 	"github.com/wonderstone/QuantKit/framework/entity/quote"
 	// _ "github.com/wonderstone/QuantKit/ksft"
-	// "github.com/wonderstone/QuantKit/tools/container/orderedmap"
-	// "github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
 )
 
 // test tmpmarket Subscribe
@@ -142,3 +146,263 @@ func TestScheduler(t *testing.T) {
 	time.Sleep(20 * time.Second)
 
 }
+
+// test 缺失K线按前收盘补齐并标记停牌
+func TestReplaySuspended(t *testing.T) {
+	header := []string{"Date", "Time", "Open", "Close", "High", "Low", "Volume", "Amount"}
+	headers := make(map[string]int, len(header))
+	for i, h := range header {
+		headers[h] = i
+	}
+
+	df := func(rows ...[]string) *dataframe.DataFrame {
+		d := &dataframe.DataFrame{Header: header, HeaderToIndex: headers}
+		for _, row := range rows {
+			d.FrameRecords = append(d.FrameRecords, dataframe.Record{Data: row})
+		}
+		return d
+	}
+
+	f := &Replay{dfs: orderedmap.New[string, *dataframe.DataFrame](), columns: headers}
+	f.dfs.Set("A", df(
+		[]string{"20230103", "2023.01.03T15:00:00.000", "10", "10", "10", "10", "100", "1000"},
+		[]string{"20230104", "2023.01.04T15:00:00.000", "10", "11", "11", "10", "100", "1000"},
+		[]string{"20230105", "2023.01.05T15:00:00.000", "11", "12", "12", "11", "100", "1000"},
+	))
+	f.dfs.Set("B", df(
+		[]string{"20230103", "2023.01.03T15:00:00.000", "5", "5", "5", "5", "100", "500"},
+		[]string{"20230105", "2023.01.05T15:00:00.000", "6", "6", "6", "6", "100", "600"},
+	))
+	f.dfs.Set("C", df(
+		[]string{"20230104", "2023.01.04T15:00:00.000", "7", "7", "7", "7", "100", "700"},
+	))
+
+	f.buildTimeline()
+
+	day2 := time.Date(2023, 1, 4, 15, 0, 0, 0, time.Local)
+	tick, ok := f.timeline.Get(day2)
+	if !ok {
+		t.Fatal("missing tick")
+	}
+	var keys []string
+	for p := tick.Oldest(); p != nil; p = p.Next() {
+		keys = append(keys, p.Key)
+	}
+	if len(keys) != 3 || keys[0] != "A" || keys[1] != "B" || keys[2] != "C" {
+		t.Fatalf("tick should keep instrument order, got %v", keys)
+	}
+
+	b := tick.Value("B")
+	if !b.Suspended() || b.Float("Open") != 5 || b.Float("Close") != 5 || b.Float("Volume") != 0 {
+		t.Errorf("missing bar should be filled with last close and flagged, got %v", b.Data)
+	}
+	if b.Val("Time") != "2023.01.04T15:00:00.000" || b.Val("Date") != "20230104" {
+		t.Errorf("filled bar should carry its own time, got %v", b.Data)
+	}
+	if a := tick.Value("A"); a.Suspended() {
+		t.Errorf("real bar should not be flagged, got %v", a.Data)
+	}
+
+	// 上市前和最后一根K线之后不补齐
+	if tick, _ := f.timeline.Get(day2.AddDate(0, 0, -1)); tick.Len() != 2 {
+		t.Error("bars before listing should not be filled")
+	}
+	if tick, _ := f.timeline.Get(day2.AddDate(0, 0, 1)); tick.Len() != 2 {
+		t.Error("bars after the last one should not be filled")
+	}
+}
+
+// test 流式回放与全量回放输出一致
+func TestStreamReplay(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"A": "20230103,2023.01.03T15:00:00.000,10,10,10,10,100,1000\n" +
+			"20230104,2023.01.04T15:00:00.000,10,11,11,10,100,1000\n" +
+			"20230105,2023.01.05T15:00:00.000,11,12,12,11,100,1000\n",
+		"B": "20230103,2023.01.03T15:00:00.000,5,5,5,5,100,500\n" +
+			"20230105,2023.01.05T15:00:00.000,6,6,6,6,100,600\n",
+		"C": "20230104,2023.01.04T15:00:00.000,7,7,7,7,100,700\n",
+	}
+	for name, rows := range files {
+		err := os.WriteFile(filepath.Join(dir, name+".csv"), []byte("Date,Time,Open,Close,High,Low,Volume,Amount\n"+rows), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conf := config.Runtime{Path: &config.Path{Download: dir}}
+	conf.Framework.Instrument = []string{"A", "B", "C"}
+
+	collect := func(q handler.Quote) []string {
+		if err := q.Init(quote.WithConfig(conf)); err != nil {
+			t.Fatal(err)
+		}
+		q.LoadData()
+
+		sub := q.Subscribe()
+		q.Run()
+
+		var ticks []string
+		for d := range sub.DataChan {
+			for p := d.Value.Oldest(); p != nil; p = p.Next() {
+				ticks = append(ticks, fmt.Sprintf("%s %s %v", d.Key.Format(config.TimeFormatDefault), p.Key, p.Value.Data))
+			}
+		}
+		q.WaitForShutdown()
+
+		return ticks
+	}
+
+	want := collect(&Replay{})
+	got := collect(&StreamReplay{})
+	if len(want) != 7 {
+		t.Fatalf("unexpected replay output: %v", want)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stream replay differs:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// test 所需频率的目录不存在时由小周期行情合成，流式回放与全量回放一致
+func TestReplayResample(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "30min"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := "Date,Time,Open,Close,High,Low,Volume,Amount\n" +
+		"20230103,2023.01.03T10:00:00.000,10,11,12,9,100,1000\n" +
+		"20230103,2023.01.03T10:30:00.000,11,12,13,10,100,1000\n" +
+		"20230103,2023.01.03T11:00:00.000,12,13,14,11,100,1000\n" +
+		"20230103,2023.01.03T11:30:00.000,13,14,15,12,100,1000\n" +
+		"20230103,2023.01.03T13:30:00.000,14,15,16,13,100,1000\n"
+	if err := os.WriteFile(filepath.Join(dir, "30min", "000001.XSHE.CS.csv"), []byte(rows), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Runtime{Path: &config.Path{Download: dir}}
+	conf.Framework.Instrument = []string{"000001.XSHE.CS"}
+	conf.Framework.Frequency = config.Frequency60Min
+
+	collect := func(q handler.Quote) []string {
+		if err := q.Init(quote.WithConfig(conf)); err != nil {
+			t.Fatal(err)
+		}
+		q.LoadData()
+
+		sub := q.Subscribe()
+		q.Run()
+
+		var ticks []string
+		for d := range sub.DataChan {
+			for p := d.Value.Oldest(); p != nil; p = p.Next() {
+				ticks = append(ticks, fmt.Sprintf("%s %v", d.Key.Format(config.TimeFormatDefault), p.Value.Data))
+			}
+		}
+		q.WaitForShutdown()
+
+		return ticks
+	}
+
+	want := []string{
+		"2023.01.03T10:30:00.000 [20230103 2023.01.03T10:30:00.000 10 12 13 9 200 2000 0]",
+		"2023.01.03T11:30:00.000 [20230103 2023.01.03T11:30:00.000 12 14 15 11 200 2000 0]",
+		"2023.01.03T14:00:00.000 [20230103 2023.01.03T14:00:00.000 14 15 16 13 100 1000 0]",
+	}
+	for _, q := range []handler.Quote{&Replay{}, &StreamReplay{}} {
+		if got := collect(q); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%T resample output:\n%s\nwant:\n%s", q, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+// test 辅助频率只提供已完成的K线，日线在收盘时才可见，各次回放的游标互不影响
+func TestSecondary(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "30min"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := "Date,Time,Open,Close,High,Low,Volume,Amount\n" +
+		"20230103,2023.01.03T14:30:00.000,10,11,12,9,100,1000\n" +
+		"20230103,2023.01.03T15:00:00.000,11,12,13,10,100,1000\n" +
+		"20230104,2023.01.04T10:00:00.000,12,13,14,11,100,1000\n" +
+		"20230104,2023.01.04T15:00:00.000,13,14,15,12,100,1000\n"
+	if err := os.WriteFile(filepath.Join(dir, "30min", "000001.XSHE.CS.csv"), []byte(rows), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := config.Runtime{Path: &config.Path{Download: dir}}
+	conf.Framework.Instrument = []string{"000001.XSHE.CS"}
+	conf.Framework.Frequency = config.Frequency30Min
+	conf.Framework.Secondary = []config.Frequency{config.Frequency1Day}
+
+	q := &Replay{}
+	if err := q.Init(quote.WithConfig(conf)); err != nil {
+		t.Fatal(err)
+	}
+	q.LoadData()
+
+	s := q.Secondary()
+	closeAt := func(tm time.Time) (float64, bool) {
+		s.Advance(tm)
+		bars, ok := s.Bars(config.Frequency1Day)
+		if !ok {
+			t.Fatal("secondary frequency not found")
+		}
+
+		bar, ok := bars.Get("000001.XSHE.CS")
+		if !ok {
+			return 0, false
+		}
+
+		return bar.Float("Close"), true
+	}
+
+	day := time.Date(2023, 1, 3, 0, 0, 0, 0, time.Local)
+	if _, ok := closeAt(day.Add(14*time.Hour + 30*time.Minute)); ok {
+		t.Error("daily bar should not be visible before the close")
+	}
+	if v, ok := closeAt(day.Add(15 * time.Hour)); !ok || v != 12 {
+		t.Errorf("daily close at 15:00 = %v, %v, want 12", v, ok)
+	}
+	if v, _ := closeAt(day.AddDate(0, 0, 1).Add(10 * time.Hour)); v != 12 {
+		t.Errorf("daily close during the next day = %v, want 12", v)
+	}
+
+	// 写入指标
+	headers := map[string]int{"MA": 0, config.Frequency1Day.Field("Close"): 1, config.Frequency1Day.Field("High"): 2}
+	indicators := orderedmap.New[string, dataframe.StreamingRecord]()
+	indicators.Set("000001.XSHE.CS", dataframe.NewEmptyStreamingRecord(headers))
+	s.Merge(*indicators)
+	if record, _ := indicators.Get("000001.XSHE.CS"); record.Float("1day.Close") != 12 || record.Float("1day.High") != 13 {
+		t.Errorf("merged record = %v", record.Values)
+	}
+
+	// 新的游标从头开始
+	if bars, _ := q.Secondary().Bars(config.Frequency1Day); bars.Len() != 0 {
+		t.Error("new cursor should not see any bar")
+	}
+}
+
+// 测试前复权: 价格乘以复权因子，成交量除以复权因子，成交额不变
+func TestForwardAdjust(t *testing.T) {
+	record := dataframe.NewStreamingRecord(
+		[]string{"10", "11", "9", "10.5", "1000", "10500", "0.5"},
+		map[string]int{"Open": 0, "High": 1, "Low": 2, "Close": 3, "Volume": 4, "Amount": 5, config.AdjustFactorField: 6},
+	)
+	ForwardAdjust(record)
+	if record.Float("Open") != 5 || record.Float("Close") != 5.25 {
+		t.Errorf("adjusted prices = %v", record.Values)
+	}
+	if record.Float("Volume") != 2000 || record.Float("Amount") != 10500 {
+		t.Errorf("adjusted volume and amount = %v", record.Values)
+	}
+
+	// 没有复权因子列时不调整
+	raw := dataframe.NewStreamingRecord([]string{"10.5"}, map[string]int{"Close": 0})
+	ForwardAdjust(raw)
+	if raw.Float("Close") != 10.5 {
+		t.Errorf("record without factor = %v, want 10.5", raw.Float("Close"))
+	}
+}
@@ -46,10 +46,7 @@ func (i *InstTicks) Sum(tm time.Time) *dataframe.StreamingRecord {
 	if i.Ticks.Len() == 0 {
 		return nil
 	}
-	bar := dataframe.StreamingRecord{
-		Headers: i.Columns,
-		Data:    make([]string, len(i.Columns)),
-	}
+	bar := dataframe.NewEmptyStreamingRecord(i.Columns)
 
 	var high, low, open, close_, volume, amount float64
 	if e, ok := i.Ticks.Dequeue(); ok {
@@ -70,12 +67,12 @@ func (i *InstTicks) Sum(tm time.Time) *dataframe.StreamingRecord {
 
 	bar.Update("Date", tm.Format(config.TimeFormatDate))
 	bar.Update("Time", tm.Format(config.TimeFormatDefault))
-	bar.Update("High", fmt.Sprintf("%f", high))
-	bar.Update("Low", fmt.Sprintf("%f", low))
-	bar.Update("Open", fmt.Sprintf("%f", open))
-	bar.Update("Close", fmt.Sprintf("%f", close_))
-	bar.Update("Volume", fmt.Sprintf("%f", volume))
-	bar.Update("Amount", fmt.Sprintf("%f", amount))
+	bar.Set("High", high)
+	bar.Set("Low", low)
+	bar.Set("Open", open)
+	bar.Set("Close", close_)
+	bar.Set("Volume", volume)
+	bar.Set("Amount", amount)
 
 	// fmt.Printf(
 	// 	"Bar: %s %s %f %f %f %f %f %f\n", tm.Format(config.TimeFormatDefault), i.InstID, high, low, open, close_,
@@ -3,6 +3,7 @@ package quote
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,19 +15,35 @@ import (
 	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
 	"github.com/wonderstone/QuantKit/tools/dataframe"
 	"github.com/wonderstone/QuantKit/tools/idgen"
+	"github.com/wonderstone/QuantKit/tools/resample"
 )
 
 type Replay struct {
 	dataPath string
 
+	// 所需频率的行情目录不存在时，由小周期行情合成，resampleTo 为合成的频率
+	resampleTo config.Frequency
+
 	instID []string // 合约ID
 
 	dfs *orderedmap.OrderedMap[string, *dataframe.DataFrame] 	// + key: instID  value:dataframe
 
 	columns map[string]int
 
+	// 前复权模式: 行情保持原始价格，每行附带复权因子列，供指标计算使用
+	adjust *config.AdjustFactor
+
+	// 辅助频率行情，只加载一次，每次回放新建游标
+	download       string
+	secondaryFreqs []config.Frequency
+	secondary      *secondarySeries
+
 	subs map[int64]*handler.Channel
 
+	// 按时间合并后的行情只读，只构建一次，多次回放(训练的每次评估)共享
+	timeline     *btree.MapG[time.Time, *orderedmap.OrderedMap[string, dataframe.StreamingRecord]]
+	timelineOnce sync.Once
+
 	gen *idgen.AutoInc
 
 	mutex sync.RWMutex
@@ -53,8 +70,17 @@ func (f *Replay) Init(option ...quote.WithOption) error {
 		withOption(op)
 	}
 
-	f.dataPath = filepath.Join(op.Config.Path.Download, string(op.Config.Framework.Frequency))
+	freq := op.Config.Framework.Frequency
+	f.dataPath = filepath.Join(op.Config.Path.Download, string(freq))
+	if dir, from, err := resample.Locate(op.Config.Path.Download, freq); err == nil && from != freq {
+		config.InfoF("行情目录 %s 不存在, 由 %s 行情合成 %s 行情", f.dataPath, from, freq)
+		f.dataPath = dir
+		f.resampleTo = freq
+	}
+
 	f.instID = op.Config.Framework.Instrument
+	f.download = op.Config.Path.Download
+	f.secondaryFreqs = op.Config.Framework.Secondary
 
 	if len(op.Config.Framework.GroupInstrument) != 0 {
 		var filters []WithFilter
@@ -112,6 +138,15 @@ func (f *Replay) Init(option ...quote.WithOption) error {
 
 	f.dfs = orderedmap.New[string, *dataframe.DataFrame]()
 
+	if op.Config.System.DataType == config.HandlerTypePreXrxdMode {
+		adjust, err := config.LoadAdjustFactor(op.Config.Path.XrxdFile, f.instID...)
+		if err != nil {
+			config.WarnF("读取除权除息数据失败, 不进行前复权: %v", err)
+		} else {
+			f.adjust = adjust
+		}
+	}
+
 	return nil
 }
 
@@ -134,6 +169,15 @@ func (f *Replay) LoadData() {
 	}
 
 	for i := range dfs {
+		if f.resampleTo != "" {
+			df, err := resample.Frame(dfs[i], f.resampleTo, f.instID[i])
+			if err != nil {
+				config.ErrorF("合成 %s 的 %s 行情失败: %v", f.instID[i], f.resampleTo, err)
+			}
+
+			dfs[i] = df
+		}
+
 		f.dfs.Set(f.instID[i], &dfs[i])
 	}
 
@@ -142,6 +186,30 @@ func (f *Replay) LoadData() {
 	}
 
 	f.columns = f.dfs.Value(f.instID[0]).HeaderToIndex
+	f.loadSecondary()
+}
+
+// loadSecondary 加载配置的辅助频率行情
+func (f *Replay) loadSecondary() {
+	if len(f.secondaryFreqs) == 0 {
+		return
+	}
+
+	s, err := loadSecondary(f.download, f.secondaryFreqs, f.instID, f.adjust)
+	if err != nil {
+		config.ErrorF("加载辅助频率行情失败: %v", err)
+	}
+
+	f.secondary = s
+}
+
+// Secondary 新建按主频率推进的辅助频率行情，未配置辅助频率时返回nil
+func (f *Replay) Secondary() *Secondary {
+	if f.secondary == nil {
+		return nil
+	}
+
+	return f.secondary.cursor()
 }
 
 func (f *Replay) Subscribe() *handler.Channel {
@@ -150,8 +218,10 @@ func (f *Replay) Subscribe() *handler.Channel {
 		StopChan: make(chan struct{}),
 	}
 
-	id := f.gen.Id()
-	f.subs[id] = ch
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.subs[f.gen.Id()] = ch
 	return ch
 }
 
@@ -164,7 +234,10 @@ func (f *Replay) GetRecord(instId string, tm time.Time, xrxd config.Xrxd) {
 	// return nil
 }
 
-func (f *Replay) Run() {
+// buildTimeline 将各合约的行情按时间合并
+// + 合约在首根和最后一根K线之间缺失的时间点视为停牌，用前一根K线的收盘价补齐并标记停牌
+// + 补齐的K线开高低收均为前收盘价，成交量和成交额为0，持仓按前收盘估值，撮合时拒绝该合约的订单
+func (f *Replay) buildTimeline() {
 	all := btree.NewMapG[time.Time, *orderedmap.OrderedMap[string, dataframe.StreamingRecord]](
 		2,
 		func(a, b time.Time) int {
@@ -172,65 +245,177 @@ func (f *Replay) Run() {
 		},
 	)
 
+	columns, hasSuspended := f.tickColumns()
+
+	type bar struct {
+		tm   time.Time
+		data []string
+	}
+
+	// 各合约的K线按时间排列，同时收集全部时间点
+	bars := make([][]bar, 0, f.dfs.Len())
 	for pair := f.dfs.Oldest(); pair != nil; pair = pair.Next() {
+		rows := make([]bar, 0, len(pair.Value.FrameRecords))
 		for _, record := range pair.Value.FrameRecords {
 			tm := record.ConvertToTime("Time", f.columns)
-			if v, ok := all.Get(tm); !ok {
-				all.Set(
-					tm, orderedmap.New[string, dataframe.StreamingRecord](
-						orderedmap.WithInitialData(
-							orderedmap.Pair[string, dataframe.StreamingRecord]{
-								Key:   pair.Key,
-								Value: dataframe.StreamingRecord{Data: record.Data, Headers: f.columns},
-							},
-						),
-					),
-				)
-			} else {
-				v.Set(pair.Key, dataframe.StreamingRecord{Data: record.Data, Headers: f.columns})
+			data := append(make([]string, 0, len(columns)), record.Data...)
+			if !hasSuspended {
+				data = append(data, "0")
 			}
+
+			rows = append(rows, bar{tm: tm, data: data})
+			if _, ok := all.Get(tm); !ok {
+				all.Set(tm, orderedmap.New[string, dataframe.StreamingRecord]())
+			}
+		}
+
+		bars = append(bars, rows)
+	}
+
+	next := make([]int, len(bars))
+	iter := all.Iter()
+	for ok := iter.First(); ok; ok = iter.Next() {
+		tm := iter.Key()
+		tick := iter.Value()
+
+		i := 0
+		for pair := f.dfs.Oldest(); pair != nil; pair, i = pair.Next(), i+1 {
+			rows := bars[i]
+			n := next[i]
+
+			var data []string
+			switch {
+			case n < len(rows) && rows[n].tm.Equal(tm):
+				data = rows[n].data
+				next[i]++
+			case n > 0 && n < len(rows):
+				data = suspendedBar(rows[n-1].data, columns, tm)
+			default:
+				continue
+			}
+
+			// 前复权以该合约最后一根K线为基准
+			if f.adjust != nil {
+				factor := f.adjust.Factor(pair.Key, tm, rows[len(rows)-1].tm)
+				data = append(data, strconv.FormatFloat(factor, 'f', -1, 64))
+			}
+
+			// 数值在此处解析一次，之后各环节直接读取 float64
+			tick.Set(pair.Key, dataframe.NewStreamingRecord(data, columns))
 		}
 	}
 
+	f.timeline = all
+}
+
+// tickColumns 回放行情的列，文件中没有停牌列时追加，前复权模式追加复权因子列
+func (f *Replay) tickColumns() (columns map[string]int, hasSuspended bool) {
+	columns = make(map[string]int, len(f.columns)+2)
+	for k, v := range f.columns {
+		columns[k] = v
+	}
+
+	_, hasSuspended = f.columns[config.SuspendedField]
+	if !hasSuspended {
+		columns[config.SuspendedField] = len(columns)
+	}
+	if f.adjust != nil {
+		columns[config.AdjustFactorField] = len(columns)
+	}
+
+	return
+}
+
+// suspendedBar 由前一根K线生成停牌K线
+func suspendedBar(prev []string, columns map[string]int, tm time.Time) []string {
+	data := append(make([]string, 0, len(columns)), prev...)
+	set := func(name, value string) {
+		if i, ok := columns[name]; ok && i < len(data) {
+			data[i] = value
+		}
+	}
+
+	if i, ok := columns["Close"]; ok {
+		for _, name := range []string{"Open", "High", "Low"} {
+			set(name, prev[i])
+		}
+	}
+	set("Volume", "0")
+	set("Amount", "0")
+	set("Time", tm.Format(config.TimeFormatDefault))
+	set("Date", tm.Format("20060102"))
+	set(config.SuspendedField, "1")
+
+	return data
+}
+
+// Run 向调用时已订阅的回放推送一次完整行情，之后的订阅由下一次 Run 推送
+// 每次 Run 的订阅者互不影响，多次 Run 可以并行(训练的并行评估)
+func (f *Replay) Run() {
+	f.timelineOnce.Do(f.buildTimeline)
+	all := f.timeline
+	subs := f.takeSubs()
+
 	f.wg.Add(1)
 	go func() {
 		defer f.wg.Done()
+		defer closeSubs(subs)
+
 		iter := all.Iter()
 		for ok := iter.First(); ok; ok = iter.Next() {
-			p := orderedmap.Pair[time.Time, *orderedmap.OrderedMap[string, dataframe.StreamingRecord]]{
-				Key:   iter.Key(),
-				Value: iter.Value(),
+			if !publish(subs, iter.Key(), iter.Value()) {
+				return
 			}
+		}
+	}()
 
-			if len(f.subs) == 0 {
-				break
-			}
+}
 
-			for i, ch := range f.subs {
-				select {
-				case <-ch.StopChan:
-					close(ch.DataChan)
-					delete(f.subs, i)
-				case ch.DataChan <- p:
-				}
-			}
-		}
+// takeSubs 取出当前的全部订阅
+func (f *Replay) takeSubs() map[int64]*handler.Channel {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-		if len(f.subs) == 0 {
-			return
-		}
+	subs := f.subs
+	f.subs = make(map[int64]*handler.Channel)
+
+	return subs
+}
+
+// publish 向全部订阅者发布一个时间点的行情，订阅者全部退出时返回false
+func publish(
+	subs map[int64]*handler.Channel, tm time.Time, tick *orderedmap.OrderedMap[string, dataframe.StreamingRecord],
+) bool {
+	p := orderedmap.Pair[time.Time, *orderedmap.OrderedMap[string, dataframe.StreamingRecord]]{
+		Key:   tm,
+		Value: tick,
+	}
+
+	if len(subs) == 0 {
+		return false
+	}
 
-		for i, ch := range f.subs {
-			ch.CloseFlag.Do(
-				func() {
-					close(f.subs[i].DataChan)
-				},
-			)
+	for i, ch := range subs {
+		select {
+		case <-ch.StopChan:
+			close(ch.DataChan)
+			delete(subs, i)
+		case ch.DataChan <- p:
 		}
+	}
 
-		f.subs = make(map[int64]*handler.Channel)
-	}()
+	return true
+}
 
+// closeSubs 回放结束，关闭全部订阅
+func closeSubs(subs map[int64]*handler.Channel) {
+	for _, ch := range subs {
+		ch.CloseFlag.Do(
+			func() {
+				close(ch.DataChan)
+			},
+		)
+	}
 }
 
 func (f *Replay) WaitForShutdown() {
@@ -0,0 +1,161 @@
+package quote
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/resample"
+)
+
+// + 辅助频率行情:
+// + 回放时除主频率外，按 framework->secondary 加载大周期的K线，目录不存在时由小周期行情合成
+// + K线按结束时间标记，主频率推进到某一时间时，只有结束时间不晚于该时间的K线可见，没有未来数据
+// + 前复权模式下价格和成交量按复权因子调整，与指标计算使用的主频率行情一致
+// + K线只加载一次，多次回放(训练的每次评估)各自新建游标
+
+// SecondaryProvider 提供辅助频率行情的回放行情，未配置辅助频率时返回nil
+type SecondaryProvider interface {
+	Secondary() *Secondary
+}
+
+type secondaryBar struct {
+	tm     time.Time
+	record dataframe.StreamingRecord
+}
+
+// secondarySeries 辅助频率的全部K线，加载后只读
+type secondarySeries struct {
+	instID []string
+	freqs  []config.Frequency
+	bars   map[config.Frequency]map[string][]secondaryBar // key: 频率, instID
+}
+
+// loadSecondary 加载辅助频率行情，adjust 不为nil时进行前复权
+func loadSecondary(
+	download string, freqs []config.Frequency, instIDs []string, adjust *config.AdjustFactor,
+) (*secondarySeries, error) {
+	s := &secondarySeries{
+		instID: instIDs,
+		freqs:  freqs,
+		bars:   make(map[config.Frequency]map[string][]secondaryBar, len(freqs)),
+	}
+
+	for _, freq := range freqs {
+		dir, _, err := resample.Locate(download, freq)
+		if err != nil {
+			return nil, err
+		}
+
+		dfs, err := dataframe.LoadFrames(dir, instIDs)
+		if err != nil {
+			return nil, fmt.Errorf("加载 %s 行情失败: %w", freq, err)
+		}
+
+		s.bars[freq] = make(map[string][]secondaryBar, len(instIDs))
+		for i, instID := range instIDs {
+			// 统一按结束时间重新标记，源目录为小周期时同时完成合成
+			df, err := resample.Frame(dfs[i], freq, instID)
+			if err != nil {
+				return nil, fmt.Errorf("合成 %s 的 %s 行情失败: %w", instID, freq, err)
+			}
+
+			bars := make([]secondaryBar, 0, len(df.FrameRecords))
+			for _, record := range df.FrameRecords {
+				bars = append(
+					bars, secondaryBar{
+						tm:     record.ConvertToTime("Time", df.HeaderToIndex),
+						record: dataframe.NewStreamingRecord(record.Data, df.HeaderToIndex),
+					},
+				)
+			}
+
+			if adjust != nil && len(bars) > 0 {
+				end := bars[len(bars)-1].tm
+				for _, bar := range bars {
+					adjustBar(bar.record, adjust.Factor(instID, bar.tm, end))
+				}
+			}
+
+			s.bars[freq][instID] = bars
+		}
+	}
+
+	return s, nil
+}
+
+// Secondary 按主频率的时间推进的辅助频率行情
+type Secondary struct {
+	*secondarySeries
+
+	next    map[config.Frequency]map[string]int // 各合约下一根尚不可见的K线
+	current map[config.Frequency]*orderedmap.OrderedMap[string, dataframe.StreamingRecord]
+}
+
+func (s *secondarySeries) cursor() *Secondary {
+	c := &Secondary{
+		secondarySeries: s,
+		next:            make(map[config.Frequency]map[string]int, len(s.freqs)),
+		current:         make(map[config.Frequency]*orderedmap.OrderedMap[string, dataframe.StreamingRecord], len(s.freqs)),
+	}
+
+	for _, freq := range s.freqs {
+		c.next[freq] = make(map[string]int, len(s.instID))
+		c.current[freq] = orderedmap.New[string, dataframe.StreamingRecord]()
+	}
+
+	return c
+}
+
+// Advance 推进到主频率的时间tm，结束时间不晚于tm的K线变为可见
+func (s *Secondary) Advance(tm time.Time) {
+	for _, freq := range s.freqs {
+		next, current := s.next[freq], s.current[freq]
+		for _, instID := range s.instID {
+			bars, n := s.bars[freq][instID], next[instID]
+			for n < len(bars) && !bars[n].tm.After(tm) {
+				n++
+			}
+
+			if n != next[instID] {
+				next[instID] = n
+				current.Set(instID, bars[n-1].record)
+			}
+		}
+	}
+}
+
+// Bars 辅助频率各合约最近一根已完成的K线，key: instID
+func (s *Secondary) Bars(freq config.Frequency) (orderedmap.OrderedMap[string, dataframe.StreamingRecord], bool) {
+	current, ok := s.current[freq]
+	if !ok {
+		return *orderedmap.New[string, dataframe.StreamingRecord](), false
+	}
+
+	return *current, true
+}
+
+// Merge 将各辅助频率最近一根已完成的K线写入指标，列名为 频率.字段，例如 1day.Close
+func (s *Secondary) Merge(indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord]) {
+	for pair := indicators.Oldest(); pair != nil; pair = pair.Next() {
+		for _, freq := range s.freqs {
+			bar, ok := s.current[freq].Get(pair.Key)
+			if !ok {
+				continue
+			}
+
+			for _, field := range config.SecondaryFields {
+				name := freq.Field(field)
+				if _, ok := pair.Value.Headers[name]; !ok {
+					continue
+				}
+
+				if v, ok := bar.Get(field); ok {
+					pair.Value.Set(name, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package quote
+
+import (
+	"container/heap"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/resample"
+)
+
+// + 流式回放:
+// + 每个合约保持一个打开的文件游标，按时间用最小堆归并，逐个时间点生成行情并发布，不全量加载行情
+// + 内存只与合约数量和订阅通道的缓冲有关，适用于全市场分钟线回测
+// + 停牌补齐、前复权因子与 Replay 一致，前复权的基准时间取文件最后一行的时间
+// + 每次 Run 重新打开文件，只向调用时已订阅的回放推送，多次 Run 可以并行
+// + 需要合成行情时，游标读取源K线并逐根合成
+
+type StreamReplay struct {
+	Replay
+}
+
+// cursor 单个合约文件的读取游标
+type cursor struct {
+	instID string
+	order  int // 合约顺序，同一时间点按合约顺序输出
+
+	file   *os.File
+	reader *csv.Reader
+	timeAt int // 时间列位置
+
+	resampler *resample.Resampler // 合成行情，nil表示不合成
+
+	tm   time.Time // 待输出K线的时间
+	next []string  // 待输出的K线，nil表示已读完
+	prev []string  // 最近输出的K线，nil表示尚未上市
+	end  time.Time // 最后一根K线时间
+}
+
+// advance 读取下一根K线
+func (c *cursor) advance() {
+	record := c.read()
+	if record == nil {
+		c.next = nil
+		return
+	}
+
+	tm, err := time.ParseInLocation(config.TimeFormatDefault, record[c.timeAt], time.Local)
+	if err != nil {
+		config.ErrorF("行情文件 %s 时间格式错误: %v", c.file.Name(), err)
+	}
+
+	if c.next != nil && !tm.After(c.tm) {
+		config.ErrorF("行情文件 %s 时间未按升序排列: %s", c.file.Name(), record[c.timeAt])
+	}
+
+	c.tm = tm
+	c.next = record
+}
+
+// read 读取下一行，合成行情时读取到一根合成完成的K线为止，读完返回nil
+func (c *cursor) read() []string {
+	for {
+		record, err := c.reader.Read()
+		if err == io.EOF {
+			if c.resampler != nil {
+				return c.resampler.Flush()
+			}
+
+			return nil
+		} else if err != nil {
+			config.ErrorF("读取行情文件 %s 出错: %v", c.file.Name(), err)
+		}
+
+		if c.resampler == nil {
+			return record
+		}
+
+		bar, err := c.resampler.Add(record)
+		if err != nil {
+			config.ErrorF("合成行情文件 %s 出错: %v", c.file.Name(), err)
+		}
+
+		if bar != nil {
+			return bar
+		}
+	}
+}
+
+// cursorHeap 按待输出K线的时间、合约顺序排列
+type cursorHeap []*cursor
+
+func (h cursorHeap) Len() int { return len(h) }
+
+func (h cursorHeap) Less(i, j int) bool {
+	if !h[i].tm.Equal(h[j].tm) {
+		return h[i].tm.Before(h[j].tm)
+	}
+
+	return h[i].order < h[j].order
+}
+
+func (h cursorHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *cursorHeap) Push(x any) { *h = append(*h, x.(*cursor)) }
+
+func (h *cursorHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return c
+}
+
+// readHeader 读取行情文件表头，去除UTF-8 BOM
+func readHeader(reader *csv.Reader) (map[string]int, error) {
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]int, len(header))
+	for i, name := range header {
+		headers[strings.TrimPrefix(name, "\ufeff")] = i
+	}
+
+	return headers, nil
+}
+
+// lastBarTime 读取文件末尾一行的时间
+func lastBarTime(file string, timeAt int) (time.Time, error) {
+	fp, err := os.Open(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer fp.Close()
+
+	info, err := fp.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	const tail = 64 * 1024
+	offset := max(info.Size()-tail, 0)
+	buf := make([]byte, info.Size()-offset)
+	if _, err := fp.ReadAt(buf, offset); err != nil && err != io.EOF {
+		return time.Time{}, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(buf), "\r\n"), "\n")
+	record, err := csv.NewReader(strings.NewReader(lines[len(lines)-1])).Read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.ParseInLocation(config.TimeFormatDefault, record[timeAt], time.Local)
+}
+
+func (f *StreamReplay) file(instID string) string {
+	return filepath.Join(f.dataPath, instID+".csv")
+}
+
+// LoadData 只读取表头确定行情的列，行情在回放时逐行读取
+func (f *StreamReplay) LoadData() {
+	if len(f.instID) == 0 {
+		config.ErrorF("加载的行情数据为空，可能没有正确设置所需合约[framework->instrument]")
+	}
+
+	for _, instID := range f.instID {
+		fp, err := os.Open(f.file(instID))
+		if err != nil {
+			config.ErrorF("打开文件失败: %s，请确认文件路径无误", f.file(instID))
+		}
+
+		headers, err := readHeader(csv.NewReader(fp))
+		_ = fp.Close()
+		if err != nil {
+			config.ErrorF("读取文件 %s 表头失败: %v", f.file(instID), err)
+		}
+
+		if f.columns == nil {
+			f.columns = headers
+		} else if len(headers) != len(f.columns) {
+			config.WarnF("行情文件 %s 的列与其他合约不一致", f.file(instID))
+		}
+	}
+
+	if _, ok := f.columns["Time"]; !ok {
+		config.ErrorF("行情文件缺少 Time 列")
+	}
+
+	f.loadSecondary()
+}
+
+// open 打开全部合约的游标，读取各合约的第一根K线
+func (f *StreamReplay) open() ([]*cursor, cursorHeap) {
+	cursors := make([]*cursor, 0, len(f.instID))
+	h := make(cursorHeap, 0, len(f.instID))
+	for i, instID := range f.instID {
+		fp, err := os.Open(f.file(instID))
+		if err != nil {
+			config.ErrorF("打开文件失败: %s，请确认文件路径无误", f.file(instID))
+		}
+
+		c := &cursor{instID: instID, order: i, file: fp, reader: csv.NewReader(fp)}
+		headers, err := readHeader(c.reader)
+		if err != nil {
+			config.ErrorF("读取文件 %s 表头失败: %v", f.file(instID), err)
+		}
+		c.timeAt = headers["Time"]
+
+		if f.resampleTo != "" {
+			if c.resampler, err = resample.New(f.resampleTo, instID, headers); err != nil {
+				config.ErrorF("合成 %s 的 %s 行情失败: %v", instID, f.resampleTo, err)
+			}
+		}
+
+		if f.adjust != nil {
+			if c.end, err = lastBarTime(f.file(instID), c.timeAt); err != nil {
+				config.ErrorF("读取文件 %s 最后一行失败: %v", f.file(instID), err)
+			}
+
+			if c.resampler != nil {
+				c.end = c.resampler.Label(c.end)
+			}
+		}
+
+		c.advance()
+		cursors = append(cursors, c)
+		if c.next != nil {
+			h = append(h, c)
+		}
+	}
+
+	heap.Init(&h)
+
+	return cursors, h
+}
+
+func (f *StreamReplay) Run() {
+	columns, hasSuspended := f.tickColumns()
+	cursors, h := f.open()
+	subs := f.takeSubs()
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer closeSubs(subs)
+		defer func() {
+			for _, c := range cursors {
+				_ = c.file.Close()
+			}
+		}()
+
+		current := make([]bool, len(cursors))
+		for h.Len() > 0 {
+			// 取出该时间点有K线的全部合约
+			tm := h[0].tm
+			for h.Len() > 0 && h[0].tm.Equal(tm) {
+				current[heap.Pop(&h).(*cursor).order] = true
+			}
+
+			tick := orderedmap.New[string, dataframe.StreamingRecord]()
+			for i, c := range cursors {
+				var data []string
+				switch {
+				case current[i]:
+					data = append(make([]string, 0, len(columns)), c.next...)
+					if !hasSuspended {
+						data = append(data, "0")
+					}
+					c.prev = data
+
+					current[i] = false
+					if c.advance(); c.next != nil {
+						heap.Push(&h, c)
+					}
+				case c.prev != nil && c.next != nil:
+					data = suspendedBar(c.prev, columns, tm)
+				default:
+					continue
+				}
+
+				if f.adjust != nil {
+					factor := f.adjust.Factor(c.instID, tm, c.end)
+					data = append(data, strconv.FormatFloat(factor, 'f', -1, 64))
+				}
+
+				tick.Set(c.instID, dataframe.NewStreamingRecord(data, columns))
+			}
+
+			if !publish(subs, tm, tick) {
+				return
+			}
+		}
+	}()
+}
+
+func init() {
+	setting.RegisterNewQuote(
+		&StreamReplay{},
+		config.HandlerTypeStream,
+	)
+}
@@ -0,0 +1,270 @@
+package rebalance
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+
+// + Rebalancer 目标持仓调仓器，策略给出每个标的的目标权重或目标数量，生成调仓订单:
+// + 目标权重按 账户总资产 * (1 - 现金保留比例) 计算目标市值，再按价格换算为目标数量
+// + 卖出数量不超过可卖数量(T+1)，清仓时可以卖出零股；非清仓的卖出和全部买入按合约最小下单量和每手数量取整
+// + 买入资金 = 可用资金 + 卖出所得(扣除手续费) - 现金保留，按目标顺序依次分配，资金不足时减少买入数量
+// + 调整金额低于最小交易金额的标的不交易(清仓除外)
+// + 返回的订单先卖后买，由策略在OnTick中返回或自行插入账户
+// + 只处理多头持仓，未考虑账户中尚未成交的订单
+
+const defaultPriceField = "Close" // 默认使用收盘价作为委托价和估值价
+
+// Target 目标持仓
+type Target struct {
+	InstID string
+	Weight float64 // 目标市值占可投资资产的比例
+	Qty    float64 // 目标数量
+	byQty  bool
+}
+
+// Weight 按目标权重调仓
+func Weight(instID string, weight float64) Target {
+	return Target{InstID: instID, Weight: weight}
+}
+
+// Qty 按目标数量调仓
+func Qty(instID string, qty float64) Target {
+	return Target{InstID: instID, Qty: qty, byQty: true}
+}
+
+type Op struct {
+	CashBuffer  float64                   // 现金保留比例，占账户总资产
+	MinTradeAmt float64                   // 最小交易金额
+	KeepOthers  bool                      // 保留不在目标中的持仓，默认清仓
+	PriceField  string                    // 价格字段
+	Options     []account.WithOrderOption // 附加下单参数
+}
+
+type WithOption func(*Op)
+
+// WithCashBuffer 现金保留比例，例如0.02表示保留2%的总资产不参与买入
+func WithCashBuffer(ratio float64) WithOption {
+	return func(op *Op) {
+		op.CashBuffer = ratio
+	}
+}
+
+// WithMinTradeAmt 最小交易金额，调整金额低于该值时不交易
+func WithMinTradeAmt(amt float64) WithOption {
+	return func(op *Op) {
+		op.MinTradeAmt = amt
+	}
+}
+
+// WithKeepOthers 保留不在目标中的持仓
+func WithKeepOthers() WithOption {
+	return func(op *Op) {
+		op.KeepOthers = true
+	}
+}
+
+// WithPriceField 委托价和估值使用的行情字段
+func WithPriceField(field string) WithOption {
+	return func(op *Op) {
+		op.PriceField = field
+	}
+}
+
+// WithOrderOptions 附加下单参数，例如市价单
+func WithOrderOptions(options ...account.WithOrderOption) WithOption {
+	return func(op *Op) {
+		op.Options = append(op.Options, options...)
+	}
+}
+
+type Rebalancer struct {
+	acc      account.Account
+	contract handler.Contract
+	op       Op
+}
+
+func New(acc account.Account, c handler.Contract, opts ...WithOption) *Rebalancer {
+	op := Op{PriceField: defaultPriceField}
+	for _, o := range opts {
+		o(&op)
+	}
+
+	return &Rebalancer{acc: acc, contract: c, op: op}
+}
+
+// trade 单个标的的调仓数量
+type trade struct {
+	instID string
+	c      contract.Contract
+	price  float64
+	qty    float64
+}
+
+// Rebalance 按目标持仓生成订单，缺少价格的标的不调整，手续费按tm时生效的费用估算
+func (r *Rebalancer) Rebalance(
+	tm time.Time, indicators orderedmap.OrderedMap[string, dataframe.StreamingRecord], targets ...Target,
+) ([]account.Order, error) {
+	price := func(instID string) (float64, bool) {
+		v, ok := indicators.Get(instID)
+		if !ok {
+			return 0, false
+		}
+
+		p, ok := v.Get(r.op.PriceField)
+		return p, ok && p > 0
+	}
+
+	asset := r.acc.GetAsset()
+	investable := asset.Total * (1 - r.op.CashBuffer)
+
+	var sells, buys []trade
+	targeted := make(map[string]struct{}, len(targets))
+	for _, t := range targets {
+		targeted[t.InstID] = struct{}{}
+
+		p, ok := price(t.InstID)
+		if !ok {
+			continue
+		}
+
+		c := r.contract.GetContract(t.InstID)
+		qty := t.Qty
+		if !t.byQty {
+			qty = investable * t.Weight / c.CalcMarketValue(1, p, config.PositionLong)
+		}
+
+		if s, ok := r.sell(t.InstID, c, p, qty); ok {
+			if s.qty > 0 {
+				sells = append(sells, s)
+			}
+		} else if qty > r.volume(t.InstID) {
+			buys = append(buys, trade{instID: t.InstID, c: c, price: p, qty: qty - r.volume(t.InstID)})
+		}
+	}
+
+	// 不在目标中的持仓清仓
+	if !r.op.KeepOthers {
+		instIDs := r.acc.GetPosInstIDs()
+		sort.Strings(instIDs)
+		for _, instID := range instIDs {
+			if _, ok := targeted[instID]; ok {
+				continue
+			}
+
+			if p, ok := price(instID); ok {
+				if s, ok := r.sell(instID, r.contract.GetContract(instID), p, 0); ok && s.qty > 0 {
+					sells = append(sells, s)
+				}
+			}
+		}
+	}
+
+	// 卖出所得用于买入
+	budget := asset.Available - asset.Total*r.op.CashBuffer
+	for _, s := range sells {
+		budget += s.c.CalcMarketValue(s.qty, s.price, config.PositionLong) -
+			s.c.CalcComm(tm, s.qty, s.price, config.OrderSell)
+	}
+
+	var orders []account.Order
+	for _, s := range sells {
+		o, err := r.acc.NewOrder(
+			s.instID, s.qty,
+			append([]account.WithOrderOption{
+				account.WithOrderPrice(s.price), account.WithOrderDirection(config.OrderSell),
+			}, r.op.Options...)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, o)
+	}
+
+	for _, b := range buys {
+		qty := r.affordable(tm, b, budget)
+		if qty <= 0 {
+			continue
+		}
+
+		budget -= b.c.CalcMarketValue(qty, b.price, config.PositionLong) + b.c.CalcComm(tm, qty, b.price, config.OrderBuy)
+
+		o, err := r.acc.NewOrder(
+			b.instID, qty,
+			append([]account.WithOrderOption{
+				account.WithOrderPrice(b.price), account.WithOrderDirection(config.OrderBuy),
+			}, r.op.Options...)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, o)
+	}
+
+	return orders, nil
+}
+
+// volume 多头持仓数量
+func (r *Rebalancer) volume(instID string) float64 {
+	pos, ok := r.acc.GetPositionByInstID(instID)
+	if !ok {
+		return 0
+	}
+
+	return pos.Volume(account.WithDirection(config.PositionLong))
+}
+
+// sell 计算卖出数量，目标数量不低于持仓时返回false；数量为0表示不满足交易条件
+func (r *Rebalancer) sell(instID string, c contract.Contract, price, target float64) (trade, bool) {
+	pos, ok := r.acc.GetPositionByInstID(instID)
+	if !ok {
+		return trade{}, false
+	}
+
+	volume := pos.Volume(account.WithDirection(config.PositionLong))
+	if target >= volume {
+		return trade{}, false
+	}
+
+	sellable := pos.Volume(account.WithDirection(config.PositionLong), account.WithSellAvailable(true))
+	qty := math.Min(volume-target, sellable)
+
+	// 清仓可以卖出零股，否则按每手数量取整
+	if target > 0 || qty < volume {
+		qty = c.CalcMaxQty(qty)
+	}
+
+	if qty <= 0 {
+		return trade{}, true
+	}
+
+	if target > 0 && c.CalcMarketValue(qty, price, config.PositionLong) < r.op.MinTradeAmt {
+		return trade{}, true
+	}
+
+	return trade{instID: instID, c: c, price: price, qty: qty}, true
+}
+
+// affordable 资金允许的买入数量，按每手数量取整
+func (r *Rebalancer) affordable(tm time.Time, b trade, budget float64) float64 {
+	qty := b.c.CalcMaxQty(math.Min(b.qty, budget/b.c.CalcMarketValue(1, b.price, config.PositionLong)))
+	for qty > 0 && b.c.CalcMarketValue(qty, b.price, config.PositionLong)+b.c.CalcComm(tm, qty, b.price, config.OrderBuy) > budget {
+		qty = b.c.CalcMaxQty(qty - 1)
+	}
+
+	if qty > 0 && b.c.CalcMarketValue(qty, b.price, config.PositionLong) < r.op.MinTradeAmt {
+		return 0
+	}
+
+	return qty
+}
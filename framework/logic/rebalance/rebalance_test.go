@@ -0,0 +1,128 @@
+package rebalance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/account"
+	"github.com/wonderstone/QuantKit/framework/entity/contract"
+	_ "github.com/wonderstone/QuantKit/framework/logic/contract"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/container/orderedmap"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+
+type testPosition struct {
+	account.Position
+	volume, sellable float64
+}
+
+func (p *testPosition) Volume(opts ...account.WithOpFilterPos) float64 {
+	op := &account.OpFilterPos{}
+	for _, o := range opts {
+		o(op)
+	}
+
+	if op.SellAvailable {
+		return p.sellable
+	}
+
+	return p.volume
+}
+
+type testOrder struct {
+	account.Order
+	instID    string
+	qty       float64
+	direction config.OrderDirection
+}
+
+func (o *testOrder) InstID() string                        { return o.instID }
+func (o *testOrder) OrderQty() float64                     { return o.qty }
+func (o *testOrder) OrderDirection() config.OrderDirection { return o.direction }
+
+// testAccount 只提供调仓需要的资产、持仓和下单
+type testAccount struct {
+	account.Account
+	asset     account.Asset
+	positions map[string]*testPosition
+}
+
+func (a *testAccount) GetAsset() account.Asset {
+	return a.asset
+}
+
+func (a *testAccount) GetPosInstIDs() []string {
+	var instIDs []string
+	for instID := range a.positions {
+		instIDs = append(instIDs, instID)
+	}
+
+	return instIDs
+}
+
+func (a *testAccount) GetPositionByInstID(instID string) (account.Position, bool) {
+	p, ok := a.positions[instID]
+	return p, ok
+}
+
+func (a *testAccount) NewOrder(instID string, qty float64, options ...account.WithOrderOption) (account.Order, error) {
+	op := account.NewOrderOp(options...)
+	return &testOrder{instID: instID, qty: qty, direction: op.OrderDirection}, nil
+}
+
+func quotes(prices map[string]float64) orderedmap.OrderedMap[string, dataframe.StreamingRecord] {
+	m := orderedmap.New[string, dataframe.StreamingRecord]()
+	for instID, price := range prices {
+		record := dataframe.NewEmptyStreamingRecord(map[string]int{"Close": 0})
+		record.Set("Close", price)
+		m.Set(instID, record)
+	}
+
+	return *m
+}
+
+func TestRebalance(t *testing.T) {
+	prop, err := config.NewContractPropertyConfig("../position/contract.yaml")
+	require.NoError(t, err)
+
+	c, err := setting.NewContractHandler(config.HandlerTypeConfig, contract.WithProperty(prop))
+	require.NoError(t, err)
+
+	const a, b, x = "000001.XSHE.CS", "000002.XSHE.CS", "600000.XSHG.CS"
+
+	acc := &testAccount{
+		asset: account.Asset{Total: 100000, Available: 20000},
+		positions: map[string]*testPosition{
+			a: {volume: 4000, sellable: 3000}, // 4000股 * 10元, 其中1000股当日买入
+			x: {volume: 1050, sellable: 1050}, // 1050股 * 20元, 不在目标中
+		},
+	}
+
+	r := New(acc, c, WithCashBuffer(0.05), WithMinTradeAmt(1000))
+	orders, err := r.Rebalance(
+		time.Now(), quotes(map[string]float64{a: 10, b: 50, x: 20}),
+		Weight(a, 0), Weight(b, 0.9),
+	)
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+
+	// 先卖后买: 目标为0但只能卖出可卖部分且按手取整，未在目标中的持仓清仓可以卖零股
+	require.Equal(t, config.OrderDirection(config.OrderSell), orders[0].OrderDirection())
+	require.Equal(t, 3000.0, orders[0].OrderQty())
+	require.Equal(t, x, orders[1].InstID())
+	require.Equal(t, 1050.0, orders[1].OrderQty())
+
+	// 目标1710股, 买入资金 = 20000 + 30000 + 21000 - 手续费 - 5000, 按手取整为1300股
+	require.Equal(t, config.OrderDirection(config.OrderBuy), orders[2].OrderDirection())
+	require.Equal(t, b, orders[2].InstID())
+	require.Equal(t, 1300.0, orders[2].OrderQty())
+
+	// 低于最小交易金额不交易, 保留不在目标中的持仓
+	r = New(acc, c, WithMinTradeAmt(2000), WithKeepOthers())
+	orders, err = r.Rebalance(time.Now(), quotes(map[string]float64{a: 10, x: 20}), Qty(a, 3850))
+	require.NoError(t, err)
+	require.Empty(t, orders)
+}
@@ -0,0 +1,183 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wonderstone/QuantKit/tools/perf"
+)
+
+// ~ HTML报告为单个离线文件: 图表使用内联SVG绘制，不依赖任何外部脚本和样式
+
+const (
+	chartWidth  = 960
+	chartHeight = 240
+)
+
+// polyline 将序列转换为SVG折线坐标
+func polyline(points []Point, invert bool) string {
+	if len(points) == 0 {
+		return ""
+	}
+
+	lo, hi := points[0].Value, points[0].Value
+	for _, p := range points {
+		lo, hi = min(lo, p.Value), max(hi, p.Value)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	var b strings.Builder
+	for i, p := range points {
+		x := 0.0
+		if len(points) > 1 {
+			x = float64(i) * chartWidth / float64(len(points)-1)
+		}
+
+		ratio := (p.Value - lo) / (hi - lo)
+		if !invert {
+			ratio = 1 - ratio
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f ", x, ratio*chartHeight)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+type monthRow struct {
+	Year   string
+	Months [12]*float64
+	Total  float64
+}
+
+// monthTable 月度收益按年排列，年度收益为月度收益复合
+func (r *Report) monthTable() []monthRow {
+	var rows []monthRow
+	for _, m := range r.Monthly {
+		year := m.Month[:4]
+		if len(rows) == 0 || rows[len(rows)-1].Year != year {
+			rows = append(rows, monthRow{Year: year, Total: 1})
+		}
+
+		row := &rows[len(rows)-1]
+		var month int
+		_, _ = fmt.Sscanf(m.Month[5:], "%d", &month)
+		ret := m.Return
+		row.Months[month-1] = &ret
+		row.Total *= 1 + ret
+	}
+
+	for i := range rows {
+		rows[i].Total -= 1
+	}
+
+	return rows
+}
+
+type metricRow struct {
+	Name  perf.IndicateType
+	Value float64
+}
+
+func (r *Report) metricRows() []metricRow {
+	var rows []metricRow
+	for _, tag := range perf.IndicateTypes {
+		if v, ok := r.Metrics[tag]; ok {
+			rows = append(rows, metricRow{Name: tag, Value: v})
+		}
+	}
+
+	return rows
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) },
+	"num": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"pctp": func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.2f%%", *v*100)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh">
+<head>
+<meta charset="utf-8">
+<title>回测报告 {{.R.ID}}</title>
+<style>
+body { font-family: sans-serif; margin: 24px; color: #222; }
+h1 { font-size: 22px; } h2 { font-size: 17px; margin-top: 28px; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+svg { border: 1px solid #ddd; background: #fafafa; }
+</style>
+</head>
+<body>
+<h1>回测报告 {{.R.ID}}</h1>
+<table>
+<tr><th>区间</th><td>{{.R.Begin}} ~ {{.R.End}}</td></tr>
+<tr><th>期初权益</th><td>{{num .R.InitialEquity}}</td></tr>
+<tr><th>期末权益</th><td>{{num .R.FinalEquity}}</td></tr>
+<tr><th>总成交额</th><td>{{num .R.TradedAmount}}</td></tr>
+<tr><th>换手率</th><td>{{num .R.Turnover}} (年化 {{num .R.AnnualTurnover}})</td></tr>
+<tr><th>总手续费</th><td>{{num .R.Commission}} (年化拖累 {{pct .R.CommissionDrag}})</td></tr>
+</table>
+
+<h2>权益曲线</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="none">
+<polyline fill="none" stroke="#c0392b" stroke-width="1.5" points="{{.Equity}}"/>
+</svg>
+
+<h2>回撤曲线</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" preserveAspectRatio="none">
+<polyline fill="none" stroke="#2c7fb8" stroke-width="1.5" points="{{.Drawdown}}"/>
+</svg>
+
+<h2>绩效指标</h2>
+<table>
+{{range .Metrics}}<tr><th>{{.Name}}</th><td>{{printf "%.6f" .Value}}</td></tr>
+{{end}}</table>
+
+<h2>月度收益</h2>
+<table>
+<tr><th>年份</th>{{range $i, $m := .MonthNames}}<th>{{$m}}</th>{{end}}<th>全年</th></tr>
+{{range .Months}}<tr><td>{{.Year}}</td>{{range .Months}}<td>{{pctp .}}</td>{{end}}<td>{{pct .Total}}</td></tr>
+{{end}}</table>
+
+<h2>合约盈亏归因</h2>
+<table>
+<tr><th>合约</th><th>平仓笔数</th><th>成交额</th><th>平仓盈亏</th><th>持仓盈亏</th><th>手续费</th><th>合计</th></tr>
+{{range .R.Instruments}}<tr><td>{{.InstID}}</td><td>{{.Trades}}</td><td>{{num .TradedAmount}}</td><td>{{num .Realized}}</td><td>{{num .Unrealized}}</td><td>{{num .Commission}}</td><td>{{num .Total}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML 输出离线HTML报告
+func (r *Report) WriteHTML(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return htmlTemplate.Execute(f, map[string]any{
+		"R":          r,
+		"Width":      chartWidth,
+		"Height":     chartHeight,
+		"Equity":     polyline(r.Equity, false),
+		"Drawdown":   polyline(r.Drawdown, true),
+		"Metrics":    r.metricRows(),
+		"Months":     r.monthTable(),
+		"MonthNames": []string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+	})
+}
@@ -0,0 +1,391 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/perfeval"
+	"github.com/wonderstone/QuantKit/tools/perf"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// + 回测报告: 读取记录器输出的资产、订单、持仓记录，生成可比较的回测结果
+// + 多账户的资产按日期汇总为一条权益曲线
+// + 指标使用 perfeval 计算，相对基准的指标只有配置了基准序列才会输出
+// + 合约盈亏归因 = 已平仓盈亏(先进先出配对) + 最后一日持仓盈亏 - 手续费
+
+type Point struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+type MonthlyReturn struct {
+	Month  string  `json:"month"` // 2006-01
+	Return float64 `json:"return"`
+}
+
+type InstrumentPnL struct {
+	InstID       string  `json:"inst_id"`
+	Trades       int     `json:"trades"`        // 平仓交易笔数
+	TradedAmount float64 `json:"traded_amount"` // 成交额
+	Realized     float64 `json:"realized"`      // 已平仓盈亏(不含手续费)
+	Unrealized   float64 `json:"unrealized"`    // 持仓盈亏
+	Commission   float64 `json:"commission"`    // 手续费
+	Total        float64 `json:"total"`         // 合计
+}
+
+type Report struct {
+	ID            string  `json:"id"`
+	Begin         string  `json:"begin"`
+	End           string  `json:"end"`
+	InitialEquity float64 `json:"initial_equity"`
+	FinalEquity   float64 `json:"final_equity"`
+
+	Metrics map[perf.IndicateType]float64 `json:"metrics"`
+
+	Equity   []Point         `json:"equity"`
+	Drawdown []Point         `json:"drawdown"`
+	Monthly  []MonthlyReturn `json:"monthly"`
+
+	TradedAmount   float64 `json:"traded_amount"`   // 总成交额
+	Turnover       float64 `json:"turnover"`        // 换手率 = 总成交额 / 平均权益
+	AnnualTurnover float64 `json:"annual_turnover"` // 年化换手率
+	Commission     float64 `json:"commission"`      // 总手续费
+	CommissionDrag float64 `json:"commission_drag"` // 年化手续费拖累 = 总手续费 / 平均权益 年化
+
+	Instruments []InstrumentPnL `json:"instruments"`
+}
+
+type Op struct {
+	ID           string
+	RiskFreeRate float64
+	Benchmark    map[string]float64
+	Contract     handler.Contract
+	RoundTrips   []recorder.RoundTripRecord
+}
+
+type WithOption func(*Op)
+
+func WithID(id string) WithOption {
+	return func(op *Op) {
+		op.ID = id
+	}
+}
+
+func WithRiskFreeRate(rf float64) WithOption {
+	return func(op *Op) {
+		op.RiskFreeRate = rf
+	}
+}
+
+func WithBenchmark(benchmark map[string]float64) WithOption {
+	return func(op *Op) {
+		op.Benchmark = benchmark
+	}
+}
+
+// WithContract 合约处理器，用于期货按合约乘数计算金额，不设置时乘数为1
+func WithContract(contract handler.Contract) WithOption {
+	return func(op *Op) {
+		op.Contract = contract
+	}
+}
+
+// WithRoundTrips 完整交易记录，设置后交易类指标使用完整交易日志计算
+func WithRoundTrips(trips []recorder.RoundTripRecord) WithOption {
+	return func(op *Op) {
+		op.RoundTrips = trips
+	}
+}
+
+func NewOp(options ...WithOption) *Op {
+	op := &Op{}
+	for _, opt := range options {
+		opt(op)
+	}
+	return op
+}
+
+func (op *Op) amount(instID string, qty, price float64, direction string) float64 {
+	if op.Contract == nil {
+		return qty * price
+	}
+
+	return op.Contract.GetContract(instID).CalcMarketValue(qty, price, config.PositionDirection(direction))
+}
+
+// equityCurve 按日期汇总多个账户的资产
+func equityCurve(assets []recorder.AssetRecord) []recorder.AssetRecord {
+	date2total := make(map[string]float64)
+	for _, a := range assets {
+		date2total[a.Date] += a.TotalAsset
+	}
+
+	curve := make([]recorder.AssetRecord, 0, len(date2total))
+	for date, total := range date2total {
+		curve = append(curve, recorder.AssetRecord{Date: date, TotalAsset: total})
+	}
+
+	sort.Slice(curve, func(i, j int) bool { return curve[i].Date < curve[j].Date })
+	return curve
+}
+
+// New 生成回测报告
+func New(
+	assets []recorder.AssetRecord, orders []recorder.OrderRecord, positions []recorder.PositionRecord,
+	options ...WithOption,
+) *Report {
+	op := NewOp(options...)
+	curve := equityCurve(assets)
+	if len(curve) == 0 {
+		config.ErrorF("没有资产记录，无法生成回测报告")
+	}
+
+	r := &Report{
+		ID:            op.ID,
+		Begin:         curve[0].Date,
+		End:           curve[len(curve)-1].Date,
+		InitialEquity: curve[0].TotalAsset,
+		FinalEquity:   curve[len(curve)-1].TotalAsset,
+		Metrics:       make(map[perf.IndicateType]float64),
+	}
+
+	r.calcMetrics(curve, orders, op)
+	r.calcCurve(curve)
+	r.calcTrades(curve, orders, positions, op)
+
+	return r
+}
+
+func (r *Report) calcMetrics(curve []recorder.AssetRecord, orders []recorder.OrderRecord, op *Op) {
+	if orders == nil {
+		orders = []recorder.OrderRecord{}
+	}
+
+	pe := perfeval.NewPerfEval(curve, true)
+	for _, tag := range perf.IndicateTypes {
+		if tag.NeedBenchmark() && op.Benchmark == nil {
+			continue
+		}
+
+		v := pe.CalcPerfEvalResult(
+			perfeval.WithPerformanceIndicateType(tag),
+			perfeval.WithRiskFreeRate(op.RiskFreeRate),
+			perfeval.WithBenchmark(op.Benchmark),
+			perfeval.WithOrderRecords(orders),
+			perfeval.WithRoundTripRecords(op.RoundTrips),
+		)
+
+		// 与回测结果输出保持一致，收益率为净收益率
+		if tag == perf.TotalReturn || tag == perf.AnnualizedReturn {
+			v -= 1
+		}
+
+		r.Metrics[tag] = v
+	}
+}
+
+func (r *Report) calcCurve(curve []recorder.AssetRecord) {
+	peak := 0.0
+	// 月度收益以上月最后一日权益为基准，第一个月以首日权益为基准
+	monthBase := r.InitialEquity
+	for i, a := range curve {
+		r.Equity = append(r.Equity, Point{Date: a.Date, Value: a.TotalAsset})
+
+		peak = max(peak, a.TotalAsset)
+		drawdown := 0.0
+		if peak > 0 {
+			drawdown = 1 - a.TotalAsset/peak
+		}
+		r.Drawdown = append(r.Drawdown, Point{Date: a.Date, Value: drawdown})
+
+		month := a.Date[:7]
+		if i == len(curve)-1 || curve[i+1].Date[:7] != month {
+			ret := 0.0
+			if monthBase != 0 {
+				ret = a.TotalAsset/monthBase - 1
+			}
+			r.Monthly = append(r.Monthly, MonthlyReturn{Month: month, Return: ret})
+			monthBase = a.TotalAsset
+		}
+	}
+}
+
+func (r *Report) calcTrades(
+	curve []recorder.AssetRecord, orders []recorder.OrderRecord, positions []recorder.PositionRecord, op *Op,
+) {
+	insts := make(map[string]*InstrumentPnL)
+	inst := func(instID string) *InstrumentPnL {
+		if _, ok := insts[instID]; !ok {
+			insts[instID] = &InstrumentPnL{InstID: instID}
+		}
+		return insts[instID]
+	}
+
+	for _, o := range perfeval.FinalOrders(orders) {
+		if o.TradeQty <= 0 {
+			continue
+		}
+
+		amt := op.amount(o.InstId, o.TradeQty, o.TradePrice, o.PositionDirection)
+		p := inst(o.InstId)
+		p.TradedAmount += amt
+		p.Commission += o.Commission
+
+		r.TradedAmount += amt
+		r.Commission += o.Commission
+	}
+
+	for _, trip := range perfeval.RoundTrips(orders) {
+		p := inst(trip.InstID)
+		p.Trades++
+		// Gross为按价格计算的盈亏，乘以合约乘数转换为金额
+		p.Realized += trip.Gross * op.amount(trip.InstID, 1, 1, trip.Direction)
+	}
+
+	// 持仓盈亏取最后一日的持仓记录
+	lastDate := ""
+	for _, pos := range positions {
+		lastDate = max(lastDate, pos.Date)
+	}
+	for _, pos := range positions {
+		if pos.Date == lastDate {
+			inst(pos.InstID).Unrealized += pos.PnL
+		}
+	}
+
+	for _, p := range insts {
+		p.Total = p.Realized + p.Unrealized - p.Commission
+		r.Instruments = append(r.Instruments, *p)
+	}
+	sort.Slice(r.Instruments, func(i, j int) bool { return r.Instruments[i].Total > r.Instruments[j].Total })
+
+	avgEquity := 0.0
+	for _, a := range curve {
+		avgEquity += a.TotalAsset
+	}
+	avgEquity /= float64(len(curve))
+
+	if avgEquity != 0 {
+		years := float64(len(curve)) / 252
+		r.Turnover = r.TradedAmount / avgEquity
+		r.AnnualTurnover = r.Turnover / years
+		r.CommissionDrag = r.Commission / avgEquity / years
+	}
+}
+
+// jsonField JSON对象的一个字段
+type jsonField struct {
+	name  string
+	value any
+}
+
+// jsonObject 按字段顺序输出的JSON对象
+type jsonObject []jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonSafe 转换为可以JSON编码的值，JSON不支持的NaN、±Inf转换为null
+func jsonSafe(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil
+		}
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return jsonSafe(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = jsonSafe(v.Index(i))
+		}
+
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		out := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			out[fmt.Sprint(iter.Key().Interface())] = jsonSafe(iter.Value())
+		}
+
+		return out
+	case reflect.Struct:
+		out := make(jsonObject, 0, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			out = append(out, jsonField{name: name, value: jsonSafe(v.Field(i))})
+		}
+
+		return out
+	}
+
+	return v.Interface()
+}
+
+// WriteJSON 输出JSON报告，无法计算的指标(NaN、±Inf)输出为null
+func (r *Report) WriteJSON(file string) error {
+	data, err := json.MarshalIndent(jsonSafe(reflect.ValueOf(r)), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, 0666)
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/tools/perf"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+func TestReport(t *testing.T) {
+	assets := []recorder.AssetRecord{
+		{Date: "2023-01-30", TotalAsset: 100000, Account: "stock"},
+		{Date: "2023-01-31", TotalAsset: 101000, Account: "stock"},
+		{Date: "2023-02-01", TotalAsset: 99990, Account: "stock"},
+		{Date: "2023-02-02", TotalAsset: 102000, Account: "stock"},
+	}
+
+	orders := []recorder.OrderRecord{
+		{OrderId: 1, InstId: "600000.XSHG.CS", OrderDirection: "B", PositionDirection: "L", TradePrice: 10, TradeQty: 1000, Commission: 5},
+		{OrderId: 2, InstId: "600000.XSHG.CS", OrderDirection: "S", PositionDirection: "L", TradePrice: 11, TradeQty: 500, Commission: 5},
+	}
+
+	positions := []recorder.PositionRecord{
+		{Date: "2023-02-01", InstID: "600000.XSHG.CS", PnL: 100},
+		{Date: "2023-02-02", InstID: "600000.XSHG.CS", PnL: 300},
+	}
+
+	r := New(assets, orders, positions, WithID("test"))
+
+	require.Equal(t, "2023-01-30", r.Begin)
+	require.Equal(t, "2023-02-02", r.End)
+	require.InDelta(t, 0.02, r.Metrics[perf.TotalReturn], 1e-9)
+	require.InDelta(t, 1.0, r.Metrics[perf.WinRate], 1e-9)
+	_, ok := r.Metrics[perf.Beta]
+	require.False(t, ok, "没有基准时不输出相对基准的指标")
+
+	require.Len(t, r.Monthly, 2)
+	require.InDelta(t, 0.01, r.Monthly[0].Return, 1e-9)
+	require.InDelta(t, 102000.0/101000-1, r.Monthly[1].Return, 1e-9)
+	require.InDelta(t, 1-99990.0/101000, r.Drawdown[2].Value, 1e-9)
+
+	require.InDelta(t, 15500.0, r.TradedAmount, 1e-9)
+	require.InDelta(t, 10.0, r.Commission, 1e-9)
+	require.Len(t, r.Instruments, 1)
+	require.InDelta(t, 500.0, r.Instruments[0].Realized, 1e-9)
+	require.InDelta(t, 300.0, r.Instruments[0].Unrealized, 1e-9)
+	require.InDelta(t, 790.0, r.Instruments[0].Total, 1e-9)
+
+	dir := t.TempDir()
+	require.NoError(t, r.WriteJSON(filepath.Join(dir, "report.json")))
+	require.NoError(t, r.WriteHTML(filepath.Join(dir, "report.html")))
+
+	html, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(html), "600000.XSHG.CS"))
+	require.False(t, strings.Contains(string(html), "<script"), "报告需要离线可用")
+}
+
+// 测试JSON报告中无法计算的指标输出为null
+func TestWriteJSONNonFinite(t *testing.T) {
+	r := &Report{
+		ID:      "test",
+		Metrics: map[perf.IndicateType]float64{perf.SharpeRatio: math.NaN(), perf.SortinoRatio: math.Inf(1), perf.TotalReturn: 0.1},
+		Monthly: []MonthlyReturn{{Month: "2023-01", Return: math.Inf(-1)}},
+	}
+
+	file := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, r.WriteJSON(file))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Less(t, strings.Index(string(data), `"id"`), strings.Index(string(data), `"metrics"`), "字段按结构体顺序输出")
+
+	var out struct {
+		ID      string              `json:"id"`
+		Metrics map[string]*float64 `json:"metrics"`
+		Monthly []struct {
+			Return *float64 `json:"return"`
+		} `json:"monthly"`
+	}
+	require.NoError(t, json.Unmarshal(data, &out))
+	require.Equal(t, "test", out.ID)
+	require.Nil(t, out.Metrics[string(perf.SharpeRatio)])
+	require.Nil(t, out.Metrics[string(perf.SortinoRatio)])
+	require.InDelta(t, 0.1, *out.Metrics[string(perf.TotalReturn)], 1e-9)
+	require.Nil(t, out.Monthly[0].Return)
+}
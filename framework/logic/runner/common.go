@@ -38,9 +38,10 @@ type WithCalculator struct {
 }
 
 type RunOp struct {
-	resource  handler.Resource
-	genome    *genome.Genome
-	genomeSet *genomeset.GenomeSet
+	resource   handler.Resource
+	genome     *genome.Genome
+	genomeSet  *genomeset.GenomeSet
+	replayType config.HandlerType
 }
 
 type WithRunOption func(op *RunOp)
@@ -63,6 +64,13 @@ func WithGenomeSetModel(genomeSet *genomeset.GenomeSet) WithRunOption {
 	}
 }
 
+// WithReplayType 指定回放处理器，未指定时使用配置中的回放处理器
+func WithReplayType(replayType config.HandlerType) WithRunOption {
+	return func(op *RunOp) {
+		op.replayType = replayType
+	}
+}
+
 func NewRunOp(option ...WithRunOption) *RunOp {
 	r := &RunOp{}
 
@@ -81,7 +89,9 @@ func (r *Common) NewReplay(option ...WithRunOption) setting.ReplayFramework {
 	op := NewRunOp(option...)
 	var f setting.ReplayFramework
 	// 如果没有设置回测类型，则根据回测频率设置回测类型
-	if r.Config().System.ReplayHandlerType == config.HandlerTypeDefault {
+	if op.replayType != "" {
+		f = setting.MustNewReplay(op.replayType)
+	} else if r.Config().System.ReplayHandlerType == config.HandlerTypeDefault {
 		f = setting.MustNewReplay(config.HandlerTypeNextMatch)
 	} else {
 		f = setting.MustNewReplay(r.Config().System.ReplayHandlerType)
@@ -171,9 +181,12 @@ func (r *Common) newQuote() {
 		map[string]any{"msg": fmt.Sprintf("初始化指标: %s", r.Config().ID)},
 	)
 
+	// 回放默认全量加载行情，配置 system.quote-handler: stream 时流式回放
 	quoteType := config.HandlerTypeReplay
 	if r.Config().Mode == config.RunMode {
 		quoteType = config.HandlerTypeRealtime
+	} else if r.Config().System.QuoteHandlerType == config.HandlerTypeStream {
+		quoteType = config.HandlerTypeStream
 	}
 
 	setting.WithQuoteHandler(
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/modelgene/gep/model"
+)
+
+// Export 读取训练输出的表达式，生成实现 formula.Formula 接口的指标公式源码
+type Export struct {
+	Common
+}
+
+func (e *Export) RunMode() config.Mode {
+	return config.ExportMode
+}
+
+func (e *Export) Init(creator ...setting.WithResource) error {
+	e.newProcess()
+	e.Resource = setting.NewResource(creator...)
+	config.StatusLog(config.StartingEvent, e.process.GetProgress())
+
+	if e.Config().Model == nil || e.Config().Model.Gep == nil {
+		return fmt.Errorf("未读取到模型配置，无法导出公式")
+	}
+
+	return nil
+}
+
+func (e *Export) Start() error {
+	rec, err := model.ReadRecord(e.Dir().KarvaExpressionFile)
+	if err != nil {
+		return err
+	}
+
+	// 表达式中的 d0, d1, ... 按训练记录中的指标映射读取
+	code, err := model.FormulaSource(rec, *e.Config().Model.Gep)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.Dir().FormulaSourceFile), 0755); err != nil {
+		return fmt.Errorf("创建公式输出目录失败: %w", err)
+	}
+
+	if err := os.WriteFile(e.Dir().FormulaSourceFile, code, 0644); err != nil {
+		return fmt.Errorf("写入公式源码失败: %w", err)
+	}
+
+	config.StatusLog(
+		config.FinishEvent, 100,
+		map[string]any{"msg": fmt.Sprintf("公式源码输出到: %s", e.Dir().FormulaSourceFile)},
+	)
+
+	return nil
+}
+
+func init() {
+	setting.RegisterRunner((*Export)(nil), config.ExportMode)
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/logic/perfeval"
+	"github.com/wonderstone/QuantKit/framework/logic/report"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+)
+
+// Report 读取回测输出的资产、订单、持仓、完整交易记录，生成回测报告(json + html)
+type Report struct {
+	Common
+}
+
+func (r *Report) RunMode() config.Mode {
+	return config.ReportMode
+}
+
+func (r *Report) Init(creator ...setting.WithResource) error {
+	r.newProcess()
+	r.Resource = setting.NewResource(creator...)
+	config.StatusLog(config.StartingEvent, r.process.GetProgress())
+
+	// 期货成交额、盈亏需要合约乘数
+	r.newContract()
+
+	return nil
+}
+
+// readRecord 以追加模式打开记录器，避免清空回测结果
+func readRecord[T any](recordType config.HandlerType, file string) ([]T, error) {
+	var data []T
+	h := recorder.NewRecorder[T](recordType, recorder.WithFilePath(file), recorder.WithPlusMode())
+	defer h.Release()
+
+	if err := h.Read(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *Report) Start() error {
+	recordType := r.Config().System.RecordHandlerType
+
+	assets, err := readRecord[recorder.AssetRecord](recordType, r.Dir().AccountResultFile)
+	if err != nil {
+		return fmt.Errorf("读取资产记录失败: %w", err)
+	}
+
+	orders, err := readRecord[recorder.OrderRecord](recordType, r.Dir().OrderResultFile)
+	if err != nil {
+		return fmt.Errorf("读取订单记录失败: %w", err)
+	}
+
+	positions, err := readRecord[recorder.PositionRecord](recordType, r.Dir().PositionResultFile)
+	if err != nil {
+		return fmt.Errorf("读取持仓记录失败: %w", err)
+	}
+
+	options := []report.WithOption{
+		report.WithID(r.Config().ID),
+		report.WithRiskFreeRate(r.Config().Performance.RiskFreeRate),
+		report.WithContract(r.Contract()),
+	}
+
+	// 完整交易记录为可选输出，没有时交易类指标由订单记录配对计算
+	if trips, err := readRecord[recorder.RoundTripRecord](recordType, r.Dir().RoundTripResultFile); err != nil {
+		config.WarnF("读取完整交易记录失败: %v", err)
+	} else if len(trips) > 0 {
+		options = append(options, report.WithRoundTrips(trips))
+	}
+
+	if file := r.Config().Performance.Benchmark; file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(r.Dir().Base, file)
+		}
+
+		benchmark, err := perfeval.LoadBenchmark(file)
+		if err != nil {
+			return fmt.Errorf("读取基准序列失败: %w", err)
+		}
+		options = append(options, report.WithBenchmark(benchmark))
+	}
+
+	rp := report.New(assets, orders, positions, options...)
+	if err := rp.WriteJSON(r.Dir().ReportJsonFile); err != nil {
+		return err
+	}
+
+	if err := rp.WriteHTML(r.Dir().ReportHtmlFile); err != nil {
+		return err
+	}
+
+	config.StatusLog(
+		config.FinishEvent, 100,
+		map[string]any{"msg": fmt.Sprintf("回测报告输出到: %s", r.Dir().ReportHtmlFile)},
+	)
+
+	return nil
+}
+
+func init() {
+	setting.RegisterRunner((*Report)(nil), config.ReportMode)
+}
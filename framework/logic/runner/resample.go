@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+	"github.com/wonderstone/QuantKit/tools/resample"
+)
+
+// Resample 由下载目录中的小周期行情合成 framework->frequency 频率的行情，写入该频率的目录
+// + 使用可以合成该频率的最大周期行情
+// + 未设置 framework->instrument 时合成源目录中的全部合约
+type Resample struct {
+	Common
+}
+
+func (r *Resample) RunMode() config.Mode {
+	return config.ResampleMode
+}
+
+func (r *Resample) Init(creator ...setting.WithResource) error {
+	r.newProcess()
+	r.Resource = setting.NewResource(creator...)
+	config.StatusLog(config.StartingEvent, r.process.GetProgress())
+
+	return nil
+}
+
+// source 查找可以合成目标频率的最大周期行情目录
+func (r *Resample) source(to config.Frequency) (string, config.Frequency, error) {
+	for _, from := range resample.Sources(to) {
+		dir := filepath.Join(r.Dir().Download, string(from))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, from, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("下载目录 %s 中没有可以合成 %s 行情的小周期行情", r.Dir().Download, to)
+}
+
+func (r *Resample) Start() error {
+	to := r.Config().Framework.Frequency
+	src, from, err := r.source(to)
+	if err != nil {
+		return err
+	}
+
+	instIDs := r.Config().Framework.Instrument
+	if len(instIDs) == 0 {
+		files, err := filepath.Glob(filepath.Join(src, "*.csv"))
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			instIDs = append(instIDs, strings.TrimSuffix(filepath.Base(file), ".csv"))
+		}
+	}
+
+	dst := filepath.Join(r.Dir().Download, string(to))
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return fmt.Errorf("创建行情目录失败: %w", err)
+	}
+
+	config.InfoF("由 %s 行情合成 %s 行情, 合约数: %d", from, to, len(instIDs))
+	for i, instID := range instIDs {
+		df, err := resample.Frame(dataframe.CreateDataFrame(src, instID), to, instID)
+		if err != nil {
+			return fmt.Errorf("合成 %s 行情失败: %w", instID, err)
+		}
+
+		df.SaveDataFrame(dst, instID)
+		config.StatusLog(config.RunningEvent, float64(i+1)/float64(len(instIDs))*95)
+	}
+
+	config.StatusLog(
+		config.FinishEvent, 100,
+		map[string]any{"msg": fmt.Sprintf("%s 行情输出到: %s", to, dst)},
+	)
+
+	return nil
+}
+
+func init() {
+	setting.RegisterRunner((*Resample)(nil), config.ResampleMode)
+}
@@ -1,10 +1,7 @@
 package runner
 
 import (
-	"context"
 	"fmt"
-	"os"
-	"os/signal"
 	"sync"
 
 	"github.com/wonderstone/QuantKit/config"
@@ -18,6 +15,8 @@ import (
 type Train struct {
 	Common
 	WithCalculator
+
+	mutex sync.Mutex
 }
 
 func (t *Train) RunMode() config.Mode {
@@ -41,141 +40,36 @@ func (t *Train) Init(creator ...setting.WithResource) error {
 	return nil
 }
 
-func (t *Train) validFunc(iterate int, gs []*genome.Genome) (*genome.Genome, bool) {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
-	defer stop()
-	doneChan := make(chan struct{})
-
-	fs := make([]setting.ReplayFramework, len(gs))
-
-	config.StatusLog(
-		config.RunningEvent,
-		t.process.GetProgress(),
-		map[string]any{"msg": fmt.Sprintf("迭代: %d / %d", iterate, t.Config().Model.Gep.Iteration)},
-	)
-
-	wg := sync.WaitGroup{}
-	for i := range gs {
-		fs[i] = t.NewReplay(WithGenomeModel(gs[i]))
-	}
-
+// score 回放一次，返回评估得分和各训练目标的值，由模型的工作池并发调用
+// + 创建回放和推送行情按顺序进行，保证每次推送只包含本个体的订阅
+func (t *Train) score(newReplay func() setting.ReplayFramework) (float64, []float64) {
+	t.mutex.Lock()
+	f := newReplay()
 	t.Quote().Run()
+	t.mutex.Unlock()
 
-	for i := range gs {
-		wg.Add(1)
-		go func(index int) {
-			defer wg.Done()
-			fs[index].Run()
-		}(i)
+	f.Run()
+	if !f.IsFinished() {
+		config.ErrorF("回测失败")
 	}
 
-	go func() {
-		wg.Wait()
-		close(doneChan)
-	}()
-
-	select {
-	case <-doneChan:
-		t.Quote().WaitForShutdown()
-
-		var bestGenome *genome.Genome = nil
-		var bestGenomeIndex = 0
-
-		for i := 0; i < len(gs); i++ {
-			if fs[i].IsFinished() {
-				gs[i].Score = fs[i].GetPerformance()
-			} else {
-				config.ErrorF("回测失败")
-			}
-
-			if bestGenome == nil || gs[i].Score > bestGenome.Score {
-				bestGenome = gs[i]
-				bestGenomeIndex = i
-			}
-		}
-
-		config.InfoF(
-			"第%d代最优基因组序号: %d, 得分: %f, Exp: %s", iterate, bestGenomeIndex+1, bestGenome.Score,
-			bestGenome.StringSlice(),
-		)
-
-		return bestGenome, bestGenome.Score >= t.Config().Performance.ExpectFitness
-	case <-ctx.Done():
-		config.InfoF(
-			"中断，当前最优基因组序号: %d, 得分: %f, Exp: %s", 1, gs[0].Score,
-			gs[0].StringSlice(),
-		)
-
-		return gs[0], true
-	}
+	return f.GetPerformance(), f.GetObjectives()
 }
 
-func (t *Train) validFunc2(iterate int, gs []*genomeset.GenomeSet) (*genomeset.GenomeSet, bool) {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
-	defer stop()
-	doneChan := make(chan struct{})
-
-	fs := make([]setting.ReplayFramework, len(gs))
-
-	config.StatusLog(
-		config.RunningEvent,
-		t.process.GetProgress(),
-		map[string]any{"msg": fmt.Sprintf("迭代: %d / %d", iterate+1, t.Config().Model.Gep.Iteration)},
+func (t *Train) validFunc(g *genome.Genome) (float64, []float64) {
+	return t.score(
+		func() setting.ReplayFramework {
+			return t.NewReplay(WithGenomeModel(g))
+		},
 	)
+}
 
-	wg := sync.WaitGroup{}
-	for i := range gs {
-		fs[i] = t.NewReplay(WithGenomeSetModel(gs[i]))
-	}
-
-	t.Quote().Run()
-
-	for i := range gs {
-		wg.Add(1)
-		go func(index int) {
-			defer wg.Done()
-			fs[index].Run()
-		}(i)
-	}
-
-	go func() {
-		wg.Wait()
-		close(doneChan)
-	}()
-
-	select {
-	case <-doneChan:
-		t.Quote().WaitForShutdown()
-
-		var bestGenome *genomeset.GenomeSet = nil
-		var bestGenomeIndex int = 0
-
-		for i := 0; i < len(gs); i++ {
-			if fs[i].IsFinished() {
-				gs[i].Score = fs[i].GetPerformance()
-			} else {
-				config.ErrorF("回测失败")
-			}
-
-			if bestGenome == nil || gs[i].Score > bestGenome.Score {
-				bestGenome = gs[i]
-				bestGenomeIndex = i
-			}
-		}
-
-		config.InfoF(
-			"第%d代最优基因组序号: %d, 得分: %f, Exp: %s", iterate, bestGenomeIndex+1, bestGenome.Score,
-			bestGenome.StringSlice(),
-		)
-		return bestGenome, bestGenome.Score >= t.Config().Performance.ExpectFitness
-	case <-ctx.Done():
-		config.InfoF(
-			"中断，当前最优基因组序号: %d, 得分: %f, Exp: %s", 1, gs[0].Score,
-			gs[0].StringSlice(),
-		)
-
-		return gs[0], true
-	}
+func (t *Train) validFunc2(gs *genomeset.GenomeSet) (float64, []float64) {
+	return t.score(
+		func() setting.ReplayFramework {
+			return t.NewReplay(WithGenomeSetModel(gs))
+		},
+	)
 }
 
 func (t *Train) Start() error {
@@ -186,13 +80,20 @@ func (t *Train) Start() error {
 			model.WithModelConfig(*t.Config().Model.Gep),
 			model.WithIndicator2FormulaIndex(t.Config().Indicator2FormulaVarIndex),
 			model.WithNumTerminal(len(t.params)),
+			model.WithInputs(t.params),
 			model.WithPerformance(t.validFunc),
 			model.WithPerformanceSet(t.validFunc2),
+			model.WithCheckpoint(t.Dir().CheckpointFile),
+			model.WithObjectives(t.Config().Performance.Objectives),
+			model.WithExpectFitness(t.Config().Performance.ExpectFitness),
 		),
 	); err != nil {
 		config.ErrorF("模型训练失败: %s", err)
 	}
 
+	// 训练中断时工作池已排空，等待推送中的行情结束
+	t.Quote().WaitForShutdown()
+
 	return nil
 }
 
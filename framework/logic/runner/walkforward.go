@@ -0,0 +1,299 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/handler"
+	"github.com/wonderstone/QuantKit/framework/logic/perfeval"
+	"github.com/wonderstone/QuantKit/framework/setting"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genomeset"
+	"github.com/wonderstone/QuantKit/modelgene/gep/model"
+	"github.com/wonderstone/QuantKit/tools/perf"
+	"github.com/wonderstone/QuantKit/tools/recorder"
+	"github.com/wonderstone/QuantKit/tools/times"
+	"gopkg.in/yaml.v3"
+)
+
+// + WalkForward 滚动训练: 将开始、结束日期切分为若干训练段/验证段
+// + 每个分段在训练段上演化基因组(或基因组集合)，在紧随其后的验证段上做样本外回测
+// + 各验证段的资产曲线按上一段期末权益衔接为一条完整的样本外曲线，输出到账户结果文件
+// + 每个分段的区间、得分和表达式输出到滚动训练汇总文件
+
+// stitchCurve 将分段资产按日期汇总，并按上一段的期末权益缩放后衔接
+func stitchCurve(
+	curve []recorder.AssetRecord, records []recorder.AssetRecord, initial float64,
+) []recorder.AssetRecord {
+	date2record := make(map[string]*recorder.AssetRecord)
+	for _, r := range records {
+		d, ok := date2record[r.Date]
+		if !ok {
+			d = &recorder.AssetRecord{Date: r.Date, Time: r.Time, Account: string(config.WalkForwardMode), Mode: r.Mode}
+			date2record[r.Date] = d
+		}
+
+		d.MarketValue += r.MarketValue
+		d.Margin += r.Margin
+		d.FundAvail += r.FundAvail
+		d.TotalAsset += r.TotalAsset
+		d.Profit += r.Profit
+		d.Commission += r.Commission
+	}
+
+	scale := 1.0
+	if len(curve) > 0 && initial > 0 {
+		scale = curve[len(curve)-1].TotalAsset / initial
+	}
+
+	fold := make([]recorder.AssetRecord, 0, len(date2record))
+	for _, d := range date2record {
+		d.MarketValue *= scale
+		d.Margin *= scale
+		d.FundAvail *= scale
+		d.TotalAsset *= scale
+		d.Profit *= scale
+		d.Commission *= scale
+		fold = append(fold, *d)
+	}
+
+	sort.Slice(fold, func(i, j int) bool { return fold[i].Date < fold[j].Date })
+	return append(curve, fold...)
+}
+
+type FoldSummary struct {
+	Fold        int        `yaml:"fold"`
+	TrainBegin  string     `yaml:"train-begin"`
+	TrainEnd    string     `yaml:"train-end"`
+	TestBegin   string     `yaml:"test-begin"`
+	TestEnd     string     `yaml:"test-end"`
+	TrainScore  float64    `yaml:"train-score"`  // 训练段得分
+	TestScore   float64    `yaml:"test-score"`   // 验证段得分(样本外)
+	TotalReturn float64    `yaml:"total-return"` // 验证段收益率
+	MaxDrawdown float64    `yaml:"max-drawdown"` // 验证段最大回撤
+	KES         [][]string `yaml:"kes"`          // 本段训练得到的表达式
+}
+
+type WalkForwardSummary struct {
+	ID              string        `yaml:"id"`
+	PerformanceType string        `yaml:"performance-type"`
+	Anchored        bool          `yaml:"anchored"`
+	TotalReturn     float64       `yaml:"total-return"`  // 样本外整体收益率
+	AnnualReturn    float64       `yaml:"annual-return"` // 样本外整体年化收益率
+	MaxDrawdown     float64       `yaml:"max-drawdown"`  // 样本外整体最大回撤
+	Folds           []FoldSummary `yaml:"folds"`
+}
+
+type WalkForward struct {
+	Train
+}
+
+func (w *WalkForward) RunMode() config.Mode {
+	return config.WalkForwardMode
+}
+
+// test 在当前区间使用DailyMode做一次样本外回测，返回资产记录和评估得分
+// + 指标从训练段开始预热，验证段开始后才交易和记录
+func (w *WalkForward) test(option ...WithRunOption) ([]recorder.AssetRecord, float64) {
+	f := w.NewReplay(append(option, WithReplayType(config.HandlerTypeDailyMode))...)
+	w.Quote().Run()
+	f.Run()
+	w.Quote().WaitForShutdown()
+
+	if !f.IsFinished() {
+		config.ErrorF("样本外回测失败")
+	}
+
+	records := make([]recorder.AssetRecord, 0)
+	if err := f.Account().(handler.Accounts).GetHistoryAssetRecord(&records); err != nil {
+		config.ErrorF("读取样本外回测结果失败: %s", err)
+	}
+
+	return records, f.GetPerformance()
+}
+
+func (w *WalkForward) modelOptions(option ...model.WithOption) []model.WithOption {
+	return append(
+		[]model.WithOption{
+			model.WithModelConfig(*w.Config().Model.Gep),
+			model.WithIndicator2FormulaIndex(w.Config().Indicator2FormulaVarIndex),
+			model.WithNumTerminal(len(w.params)),
+			model.WithInputs(w.params),
+			model.WithObjectives(w.Config().Performance.Objectives),
+			model.WithExpectFitness(w.Config().Performance.ExpectFitness),
+		}, option...,
+	)
+}
+
+func (w *WalkForward) runFold(index int, fold times.Fold) (FoldSummary, []recorder.AssetRecord) {
+	conf := w.Config()
+	summary := FoldSummary{
+		Fold:       index + 1,
+		TrainBegin: fold.TrainBegin.Format(time.DateOnly),
+		TrainEnd:   fold.TrainEnd.Format(time.DateOnly),
+		TestBegin:  fold.TestBegin.Format(time.DateOnly),
+		TestEnd:    fold.TestEnd.Format(time.DateOnly),
+	}
+
+	config.StatusLog(
+		config.RunningEvent, w.process.GetProgress(),
+		map[string]any{
+			"msg": fmt.Sprintf("分段 %d 训练: %s ~ %s", index+1, summary.TrainBegin, summary.TrainEnd),
+		},
+	)
+
+	// 回放器初始化时读取开始、结束日期，行情数据已经按整个区间加载
+	conf.Framework.Begin, conf.Framework.End = fold.TrainBegin, fold.TrainEnd
+	rec := model.NewHandler(
+		conf.Model.Gep.Mode,
+		w.modelOptions(model.WithPerformance(w.validFunc), model.WithPerformanceSet(w.validFunc2))...,
+	).Evolve()
+	w.Quote().WaitForShutdown()
+	summary.TrainScore = rec.Gep.Score
+	summary.KES = rec.Gep.KES
+
+	config.StatusLog(
+		config.RunningEvent, w.process.GetProgress(),
+		map[string]any{
+			"msg": fmt.Sprintf("分段 %d 样本外验证: %s ~ %s", index+1, summary.TestBegin, summary.TestEnd),
+		},
+	)
+
+	conf.Framework.Begin, conf.Framework.End = fold.TestBegin, fold.TestEnd
+	conf.Framework.Warmup = fold.TrainBegin
+	defer func() {
+		conf.Framework.Warmup = time.Time{}
+	}()
+
+	var records []recorder.AssetRecord
+	model.NewHandler(
+		conf.Model.Gep.Mode,
+		w.modelOptions(
+			model.WithRecord(*rec),
+			model.WithGenome(
+				func(g *genome.Genome) {
+					records, summary.TestScore = w.test(WithGenomeModel(g))
+				},
+			),
+			model.WithGenomeSet(
+				func(gs *genomeset.GenomeSet) {
+					records, summary.TestScore = w.test(WithGenomeSetModel(gs))
+				},
+			),
+		)...,
+	).RunOnce()
+
+	if curve := stitchCurve(nil, records, 0); len(curve) > 0 {
+		pe := perfeval.NewPerfEval(curve, true)
+		summary.TotalReturn = pe.CalcPerfEvalResult(perfeval.WithPerformanceIndicateType(perf.TotalReturn)) - 1
+		summary.MaxDrawdown = pe.CalcPerfEvalResult(perfeval.WithPerformanceIndicateType(perf.MaxDrawdown))
+	}
+
+	return summary, records
+}
+
+func (w *WalkForward) writeCurve(curve []recorder.AssetRecord) {
+	h := recorder.NewRecorder[recorder.AssetRecord](
+		w.Config().System.RecordHandlerType,
+		recorder.WithFilePath(w.Dir().AccountResultFile),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := h.RecordChan(); err != nil {
+			config.InfoF("滚动训练资产记录写入结束")
+		}
+	}()
+
+	for i := range curve {
+		h.GetChannel() <- &curve[i]
+	}
+
+	h.Release()
+	<-done
+}
+
+func (w *WalkForward) writeSummary(summary WalkForwardSummary) {
+	data, err := yaml.Marshal(summary)
+	if err != nil {
+		config.ErrorF("序列化滚动训练汇总失败: %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.Dir().WalkForwardFile), 0755); err != nil {
+		config.ErrorF("创建滚动训练汇总目录失败: %s", err)
+	}
+
+	if err := os.WriteFile(w.Dir().WalkForwardFile, data, 0644); err != nil {
+		config.ErrorF("写入滚动训练汇总失败: %s", err)
+	}
+}
+
+func (w *WalkForward) Start() error {
+	conf := w.Config()
+	if t := conf.System.ModelHandlerType; t != config.HandlerTypeDefault && t != config.HandlerTypeGepMode {
+		return fmt.Errorf("滚动训练只支持GEP模型, 当前模型处理器: %s", t)
+	}
+
+	if conf.Model == nil || conf.Model.Gep == nil {
+		return fmt.Errorf("滚动训练未配置GEP模型")
+	}
+
+	begin, end := conf.Framework.Begin, conf.Framework.End
+	defer func() {
+		conf.Framework.Begin, conf.Framework.End = begin, end
+	}()
+
+	wf := conf.WalkForward
+	if wf.TrainMonths <= 0 || wf.TestMonths <= 0 {
+		return fmt.Errorf("滚动训练的训练段和验证段长度必须大于0: %+v", wf)
+	}
+
+	folds := times.SplitFolds(begin, end, wf.TrainMonths, wf.TestMonths, wf.Anchored)
+	if len(folds) == 0 {
+		return fmt.Errorf("区间 %s ~ %s 不足以切分出一个训练段和验证段", begin.Format(time.DateOnly), end.Format(time.DateOnly))
+	}
+
+	summary := WalkForwardSummary{
+		ID:              conf.ID,
+		PerformanceType: conf.Performance.PerformanceType,
+		Anchored:        conf.WalkForward.Anchored,
+	}
+
+	initial := conf.Framework.Stock.Cash + conf.Framework.Future.Cash
+	curve := make([]recorder.AssetRecord, 0)
+	for i, fold := range folds {
+		foldSummary, records := w.runFold(i, fold)
+		summary.Folds = append(summary.Folds, foldSummary)
+		curve = stitchCurve(curve, records, initial)
+	}
+
+	if len(curve) > 0 {
+		pe := perfeval.NewPerfEval(curve, true)
+		summary.TotalReturn = pe.CalcPerfEvalResult(perfeval.WithPerformanceIndicateType(perf.TotalReturn)) - 1
+		summary.AnnualReturn = pe.CalcPerfEvalResult(perfeval.WithPerformanceIndicateType(perf.AnnualizedReturn)) - 1
+		summary.MaxDrawdown = pe.CalcPerfEvalResult(perfeval.WithPerformanceIndicateType(perf.MaxDrawdown))
+	}
+
+	w.writeCurve(curve)
+	w.writeSummary(summary)
+
+	config.StatusLog(
+		config.FinishEvent, 100, map[string]any{
+			"msg":           fmt.Sprintf("滚动训练完成, 分段汇总输出到: %s", w.Dir().WalkForwardFile),
+			"total_return":  summary.TotalReturn,
+			"annual_return": summary.AnnualReturn,
+			"max_drawdown":  summary.MaxDrawdown,
+		},
+	)
+
+	return nil
+}
+
+func init() {
+	setting.RegisterRunner((*WalkForward)(nil), config.WalkForwardMode)
+}
@@ -35,6 +35,9 @@ type ReplayFramework interface {
 
 	// GetPerformance 获取性能指标结果
 	GetPerformance() float64
+
+	// GetObjectives 按配置的训练目标顺序获取各指标结果，未配置训练目标时返回nil
+	GetObjectives() []float64
 }
 
 var replayCreator = make(map[config.HandlerType]reflect.Type)
@@ -89,6 +92,9 @@ type RTFramework interface {
 
 	// GetPerformance 获取性能指标结果
 	GetPerformance() float64
+
+	// GetObjectives 按配置的训练目标顺序获取各指标结果，未配置训练目标时返回nil
+	GetObjectives() []float64
 }
 
 func RegisterRTExecutor(elem interface{}, name config.HandlerType) {
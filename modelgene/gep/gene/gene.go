@@ -91,7 +91,8 @@ func New(x string, funcType functions.FuncType) *Gene {
 // algorithm. The headSize, tailSize, numTerminals, and numConstants determine the respective
 // properties of the gene, and functions provide the available functions and
 // their respective weights to be used in the creation of the gene.
-func RandomNew(headSize, tailSize, numTerminals, numConstants int, functions []FuncWeight, funcType functions.FuncType) *Gene {
+// The rng drives all random choices so that a run can be reproduced from its seed.
+func RandomNew(rng *rand.Rand, headSize, tailSize, numTerminals, numConstants int, functions []FuncWeight, funcType functions.FuncType) *Gene {
 	totalWeight := numTerminals + numConstants
 	for _, f := range functions {
 		totalWeight += f.Weight
@@ -103,14 +104,14 @@ func RandomNew(headSize, tailSize, numTerminals, numConstants int, functions []F
 	var constants []float64
 	for i := 0; i < numConstants; i++ {
 		choiceSlice = append(choiceSlice, fmt.Sprintf("c%v", i))
-		constants = append(constants, rand.Float64())
+		constants = append(constants, rng.Float64())
 	}
 	for _, f := range functions {
 		for i := 0; i < f.Weight; i++ {
 			choiceSlice = append(choiceSlice, f.Symbol)
 		}
 	}
-	choices := rand.Perm(totalWeight)
+	choices := rng.Perm(totalWeight)
 	r := &Gene{
 		Symbols:      make([]string, 0, headSize+tailSize),
 		Constants:    constants,
@@ -171,8 +172,8 @@ func (g *Gene) SymbolCount(sym string) int {
 }
 
 // Mutate mutates a gene by performing a single random symbol exchange within the gene.
-func (g *Gene) Mutate() {
-	position := rand.Intn(len(g.Symbols))
+func (g *Gene) Mutate(rng *rand.Rand) {
+	position := rng.Intn(len(g.Symbols))
 	if g.numTerminals < 2 {
 		position %= g.HeadSize // Force choice to be within the head
 	}
@@ -183,7 +184,7 @@ func (g *Gene) Mutate() {
 		}
 		symbol := g.Symbols[position]
 		for symbol == g.Symbols[position] { // Force new symbol to be different from old one
-			n := rand.Intn(len(g.choiceSlice))
+			n := rng.Intn(len(g.choiceSlice))
 			symbol = g.choiceSlice[n]
 		}
 		// fmt.Printf("\nChanging symbol #%v from %q to %q\n", position, g.Symbols[position], symbol)
@@ -191,7 +192,7 @@ func (g *Gene) Mutate() {
 	} else { // Must choose strictly from terminals
 		terminal := g.Symbols[position]
 		for terminal == g.Symbols[position] { // Force new terminal to be different from old one
-			n := rand.Intn(g.numTerminals)
+			n := rng.Intn(g.numTerminals)
 			terminal = g.choiceSlice[n]
 		}
 		// fmt.Printf("\nChanging terminal #%v from %q to %q\n", position, g.Symbols[position], terminal)
@@ -241,6 +242,45 @@ func (g *Gene) Dup() *Gene {
 	return r
 }
 
+// Snapshot is the complete, serializable state of a gene. Unlike the Karva
+// string it also keeps the head size, constants and the weighted choices,
+// so that a restored gene can continue to evolve.
+type Snapshot struct {
+	Symbols      []string  `yaml:"symbols"`
+	Constants    []float64 `yaml:"constants,omitempty"`
+	HeadSize     int       `yaml:"head-size"`
+	Choices      []string  `yaml:"choices"`
+	NumTerminals int       `yaml:"num-terminals"`
+}
+
+// Snapshot returns the complete state of the gene.
+func (g *Gene) Snapshot() Snapshot {
+	d := g.Dup()
+	return Snapshot{
+		Symbols:      d.Symbols,
+		Constants:    d.Constants,
+		HeadSize:     d.HeadSize,
+		Choices:      d.choiceSlice,
+		NumTerminals: d.numTerminals,
+	}
+}
+
+// Restore creates a gene from its snapshot.
+func Restore(s Snapshot, funcType functions.FuncType) *Gene {
+	r := &Gene{
+		Symbols:      make([]string, len(s.Symbols)),
+		Constants:    make([]float64, len(s.Constants)),
+		funcType:     funcType,
+		HeadSize:     s.HeadSize,
+		choiceSlice:  make([]string, len(s.Choices)),
+		numTerminals: s.NumTerminals,
+	}
+	copy(r.Symbols, s.Symbols)
+	copy(r.Constants, s.Constants)
+	copy(r.choiceSlice, s.Choices)
+	return r
+}
+
 // CheckEqual is used for testing purposes only (exported to use in genome_test.go).
 func CheckEqual(g1 *Gene, g2 *Gene) error {
 	if g1 == nil || g2 == nil {
@@ -7,6 +7,7 @@ package gene
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -343,9 +344,10 @@ func TestMutate(t *testing.T) {
 		{"And", 5},
 		{"Or", 5},
 	}
-	g1 := RandomNew(headSize, tailSize, numTerminals, 0, funcs, functions.Bool)
+	rng := rand.New(rand.NewSource(1))
+	g1 := RandomNew(rng, headSize, tailSize, numTerminals, 0, funcs, functions.Bool)
 	gn := g1.Dup()
-	g1.Mutate()
+	g1.Mutate(rng)
 	if err := CheckEqual(gn, g1); err == nil {
 		t.Errorf("TestMutate failed: g1 == mux\n")
 	}
@@ -378,9 +380,10 @@ func BenchmarkMutate(b *testing.B) {
 		{"-", 5},
 		{"*", 5},
 	}
-	g := RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	rng := rand.New(rand.NewSource(1))
+	g := RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
 	for i := 0; i < b.N; i++ {
-		g.Mutate()
+		g.Mutate(rng)
 	}
 }
 
@@ -397,7 +400,8 @@ func BenchmarkDup(b *testing.B) {
 		{"-", 5},
 		{"*", 5},
 	}
-	g := RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	rng := rand.New(rand.NewSource(1))
+	g := RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
 	var v *Gene
 	for i := 0; i < b.N; i++ {
 		v = g.Dup()
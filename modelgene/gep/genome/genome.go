@@ -12,6 +12,7 @@ import (
 	"math/rand"
 	"strings"
 
+	"github.com/wonderstone/QuantKit/modelgene/gep/functions"
 	"github.com/wonderstone/QuantKit/modelgene/gep/gene"
 )
 
@@ -25,6 +26,9 @@ type Genome struct {
 	Index    int          `yaml:"index,omitempty"`
 	Iterate  int          `yaml:"iter,omitempty"`
 
+	// Objectives holds the raw values of each objective when evolving with multiple objectives.
+	Objectives []float64 `yaml:"objectives,omitempty"`
+
 	SymbolMap map[string]int `yaml:"symbolmap,omitempty"` // do not use directly.  Use SymbolCount() instead.
 }
 
@@ -94,33 +98,33 @@ func (g Genome) StringSlice() []string {
 }
 
 // Mutate mutates a genome by performing numMutations random symbol exchanges within the genome.
-func (g *Genome) Mutate(numMutations int) {
+func (g *Genome) Mutate(rng *rand.Rand, numMutations int) {
 	for i := 0; i < numMutations; i++ {
-		n := rand.Intn(len(g.Genes))
+		n := rng.Intn(len(g.Genes))
 		// fmt.Printf("\nMutating gene #%v, before:\n%v\n", n, g.Genes[n])
-		g.Genes[n].Mutate()
+		g.Genes[n].Mutate(rng)
 		// fmt.Printf("after:\n%v\n", g.Genes[n])
 	}
 }
 
 // isTransposition transposes the the IS element(start position) to the target site with the length of no more than gl
-func (g *Genome) IsTransposition(gl int) {
+func (g *Genome) IsTransposition(rng *rand.Rand, gl int) {
 	// randomly choose the sourceGene
-	sourceGene := rand.Intn(len(g.Genes))
+	sourceGene := rng.Intn(len(g.Genes))
 	// randomly choose the gene as the target
-	targetGene := rand.Intn(len(g.Genes))
+	targetGene := rng.Intn(len(g.Genes))
 	if g.Genes[targetGene].HeadSize != 1 {
 		// determine the length of the IS element, the number should be < g.Genes[targetGene].HeadSize
-		isLength := rand.Intn(gl) + 1
+		isLength := rng.Intn(gl) + 1
 		if isLength >= g.Genes[targetGene].HeadSize {
 			isLength = g.Genes[targetGene].HeadSize - 1
 		}
 		// the start position of the IS element which should has at least isLength to the end
-		startSPosition := rand.Intn(len(g.Genes[sourceGene].Symbols) - isLength)
+		startSPosition := rng.Intn(len(g.Genes[sourceGene].Symbols) - isLength)
 		// copy IS element out for overlapping reason
 		isElement := g.Genes[sourceGene].Symbols[startSPosition : startSPosition+isLength]
 		// randomly choose the start position of the IS element which should not be the first position and has at least isLength to the end
-		startTPosition := rand.Intn(g.Genes[targetGene].HeadSize - isLength)
+		startTPosition := rng.Intn(g.Genes[targetGene].HeadSize - isLength)
 		// replace the target with the IS element
 		for i := 0; i < isLength; i++ {
 			g.Genes[targetGene].Symbols[startTPosition+i+1] = isElement[i]
@@ -133,11 +137,13 @@ func (g *Genome) IsTransposition(gl int) {
 }
 
 // risTransposition transposes the the IS function element to the root position with the length of no more than gl
-func (g *Genome) RisTransposition(gl int) {
+func (g *Genome) RisTransposition(rng *rand.Rand, gl int) {
 	// randomly choose the sourceGene
-	sourceGene := rand.Intn(len(g.Genes))
+	sourceGene := rng.Intn(len(g.Genes))
 	// iter the g.Genes[sourceGene].Symbols to find the IS function element and add to a map with Symbol string as key and position int as value
 	isMap := make(map[string]int)
+	// keep the function symbols in order of appearance so that the choice only depends on rng
+	var isSymbols []string
 	// make a tmp slice for head elements
 	head := make([]string, 0)
 	// start from the second position of the gene
@@ -145,19 +151,20 @@ func (g *Genome) RisTransposition(gl int) {
 		head = append(head, g.Genes[sourceGene].Symbols[i])
 		if i != 0 && !g.Genes[sourceGene].IsTerminal(g.Genes[sourceGene].Symbols[i]) &&
 			!g.Genes[sourceGene].IsConstant(g.Genes[sourceGene].Symbols[i]) {
+			if _, ok := isMap[g.Genes[sourceGene].Symbols[i]]; !ok {
+				isSymbols = append(isSymbols, g.Genes[sourceGene].Symbols[i])
+			}
 			isMap[g.Genes[sourceGene].Symbols[i]] = i
 		}
 	}
 
 	// randomly choose the function element from the map
-	// map element is randomly arranged ,so each time get the first element is ok
-	// iter the map to get a random element
+	// map iteration order is not reproducible, so pick with rng from the ordered symbols
 	var tmpElement string
-	for k := range isMap {
-		tmpElement = k
-		break
+	if len(isSymbols) > 0 {
+		tmpElement = isSymbols[rng.Intn(len(isSymbols))]
 	}
-	isLength := rand.Intn(gl) + 1
+	isLength := rng.Intn(gl) + 1
 	if isLength > g.Genes[sourceGene].HeadSize-isMap[tmpElement] {
 		isLength = g.Genes[sourceGene].HeadSize - isMap[tmpElement]
 	}
@@ -178,9 +185,9 @@ func (g *Genome) RisTransposition(gl int) {
 }
 
 // GeneTransposition transposes the entire gene funcitons as a whole to the beginning of the chromosome
-func (g *Genome) GeneTransposition() {
+func (g *Genome) GeneTransposition(rng *rand.Rand) {
 	// randomly choose the sourceGene but not the first one
-	sourceGene := rand.Intn(len(g.Genes)-1) + 1
+	sourceGene := rng.Intn(len(g.Genes)-1) + 1
 	// make a temp genome for overlapping
 	tmpGenome := g.Dup()
 
@@ -198,11 +205,11 @@ func (g *Genome) GeneTransposition() {
 }
 
 // OnePointRecombination crossover two chromosomes by randomly choosing a point in the chromosome and swapping the genes between the two chromosomes.
-func (g *Genome) OnePointRecombination(other *Genome) {
+func (g *Genome) OnePointRecombination(rng *rand.Rand, other *Genome) {
 	// randomly choose the sourceGene
-	sourceGene := rand.Intn(len(g.Genes))
+	sourceGene := rng.Intn(len(g.Genes))
 	// randomly choose the 1 crossoverpoint
-	crossoverPoint := rand.Intn(len(g.Genes[sourceGene].Symbols))
+	crossoverPoint := rng.Intn(len(g.Genes[sourceGene].Symbols))
 	// copy the gene after the crossoverPoint to the tempGeneSlice
 	// tempGeneSlice := make([]string, 0)
 	for i := crossoverPoint; i < len(g.Genes[sourceGene].Symbols); i++ {
@@ -239,11 +246,11 @@ func (g *Genome) OnePointRecombination(other *Genome) {
 
 // the part below  uses different crossover methods（no gene dump process）don't know which one is better
 // TwoPointRecombination crossover two chromosomes by randomly choosing two points in the chromosome and swapping the genes between the two chromosomes.
-func (g *Genome) TwoPointRecombination(other *Genome) {
+func (g *Genome) TwoPointRecombination(rng *rand.Rand, other *Genome) {
 	// randomly choose the 2 sourceGenes index. they can be the same
 	// assign the smaller index to the first one
-	sourceGene1 := rand.Intn(len(g.Genes))
-	sourceGene2 := rand.Intn(len(g.Genes))
+	sourceGene1 := rng.Intn(len(g.Genes))
+	sourceGene2 := rng.Intn(len(g.Genes))
 	if sourceGene1 > sourceGene2 {
 		sourceGene1, sourceGene2 = sourceGene2, sourceGene1
 	}
@@ -251,16 +258,16 @@ func (g *Genome) TwoPointRecombination(other *Genome) {
 	// if 2 sourceGenes are the same, the crossoverpoints must be the different
 	var crossoverPoint1, crossoverPoint2 int
 	if sourceGene1 == sourceGene2 {
-		crossoverPoint1 = rand.Intn(len(g.Genes[sourceGene1].Symbols))
+		crossoverPoint1 = rng.Intn(len(g.Genes[sourceGene1].Symbols))
 		for {
-			crossoverPoint2 = rand.Intn(len(g.Genes[sourceGene2].Symbols))
+			crossoverPoint2 = rng.Intn(len(g.Genes[sourceGene2].Symbols))
 			if crossoverPoint2 != crossoverPoint1 {
 				break
 			}
 		}
 	} else {
-		crossoverPoint1 = rand.Intn(len(g.Genes[sourceGene1].Symbols))
-		crossoverPoint2 = rand.Intn(len(g.Genes[sourceGene2].Symbols))
+		crossoverPoint1 = rng.Intn(len(g.Genes[sourceGene1].Symbols))
+		crossoverPoint2 = rng.Intn(len(g.Genes[sourceGene2].Symbols))
 	}
 	// make crossoverPoint1 smaller than crossoverPoint2
 	if crossoverPoint1 > crossoverPoint2 {
@@ -302,9 +309,9 @@ func (g *Genome) TwoPointRecombination(other *Genome) {
 }
 
 // GeneRecombination crossover two chromosomes by randomly choosing two genes in the chromosome and swapping the genes between the two chromosomes.
-func (g *Genome) GeneRecombination(other *Genome) {
+func (g *Genome) GeneRecombination(rng *rand.Rand, other *Genome) {
 	// randomly choose the genePosition for g and other
-	genePosition := rand.Intn(len(g.Genes))
+	genePosition := rng.Intn(len(g.Genes))
 	// create a temp gene to store the dup
 	tempGene := g.Genes[genePosition].Dup()
 	g.Genes[genePosition] = other.Genes[genePosition].Dup()
@@ -330,12 +337,44 @@ func (g *Genome) Dup() *Genome {
 		Index:    g.Index,
 		Iterate:  g.Iterate,
 	}
+	if g.Objectives != nil {
+		dst.Objectives = append([]float64(nil), g.Objectives...)
+	}
 	for i := range g.Genes {
 		dst.Genes[i] = g.Genes[i].Dup()
 	}
 	return dst
 }
 
+// Snapshot is the complete, serializable state of a genome.
+type Snapshot struct {
+	Genes      []gene.Snapshot `yaml:"genes"`
+	LinkFunc   string          `yaml:"linkfunc"`
+	Score      float64         `yaml:"score"`
+	Objectives []float64       `yaml:"objectives,omitempty"`
+}
+
+// Snapshot returns the complete state of the genome.
+func (g *Genome) Snapshot() Snapshot {
+	s := Snapshot{LinkFunc: g.LinkFunc, Score: g.Score, Objectives: g.Objectives}
+	for _, v := range g.Genes {
+		s.Genes = append(s.Genes, v.Snapshot())
+	}
+	return s
+}
+
+// Restore creates a genome from its snapshot.
+func Restore(s Snapshot, funcType functions.FuncType) *Genome {
+	genes := make([]*gene.Gene, len(s.Genes))
+	for i, v := range s.Genes {
+		genes[i] = gene.Restore(v, funcType)
+	}
+	g := New(genes, s.LinkFunc)
+	g.Score = s.Score
+	g.Objectives = s.Objectives
+	return g
+}
+
 // ScoringFunc is the function that is used to evaluate the fitness of the model.
 // Typically, a return value of 0 means that the function is nowhere close to being
 // a valid solution and a return value of 1000 (or higher) means a perfect solution.
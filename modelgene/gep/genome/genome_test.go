@@ -7,6 +7,7 @@ package genome
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -900,15 +901,16 @@ func TestMutate(t *testing.T) {
 		{Symbol: "And", Weight: 5},
 		{Symbol: "Or", Weight: 5},
 	}
+	rng := rand.New(rand.NewSource(1))
 	mux := New([]*gene.Gene{
-		gene.RandomNew(headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
-		gene.RandomNew(headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
-		gene.RandomNew(headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
-		gene.RandomNew(headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
+		gene.RandomNew(rng, headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
+		gene.RandomNew(rng, headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
+		gene.RandomNew(rng, headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
+		gene.RandomNew(rng, headSize, tailSize, numTerminals, 0, funcs, functions.Bool),
 	},
 		"And")
 	gn := mux.Dup()
-	mux.Mutate(1)
+	mux.Mutate(rng, 1)
 	if err := checkEqual(gn, mux); err == nil {
 		t.Errorf("TestMutate failed: gn == mux\n")
 	}
@@ -925,13 +927,14 @@ func BenchmarkMutate(b *testing.B) {
 		{Symbol: "-", Weight: 5},
 		{Symbol: "*", Weight: 5},
 	}
-	g1 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g2 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g3 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g4 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	rng := rand.New(rand.NewSource(1))
+	g1 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g2 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g3 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g4 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
 	g := New([]*gene.Gene{g1, g2, g3, g4}, "+")
 	for i := 0; i < b.N; i++ {
-		g.Mutate(1)
+		g.Mutate(rng, 1)
 	}
 }
 
@@ -948,10 +951,11 @@ func BenchmarkDup(b *testing.B) {
 		{Symbol: "-", Weight: 5},
 		{Symbol: "*", Weight: 5},
 	}
-	g1 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g2 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g3 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
-	g4 := gene.RandomNew(headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	rng := rand.New(rand.NewSource(1))
+	g1 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g2 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g3 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
+	g4 := gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, funcs, functions.Float64)
 	g := New([]*gene.Gene{g1, g2, g3, g4}, "+")
 	var v *Genome
 	for i := 0; i < b.N; i++ {
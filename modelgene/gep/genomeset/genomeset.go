@@ -4,7 +4,9 @@ package genomeset
 
 import (
 	"log"
+	"math/rand"
 
+	"github.com/wonderstone/QuantKit/modelgene/gep/functions"
 	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
 )
 
@@ -17,6 +19,9 @@ type GenomeSet struct {
 	Score    float64          `yaml:"score,omitempty"`
 	Index    int
 	Iterate  int
+
+	// Objectives holds the raw values of each objective when evolving with multiple objectives.
+	Objectives []float64 `yaml:"objectives,omitempty"`
 }
 
 // NewGenomeSet creates a new GenomeSet from the given genomes.
@@ -33,52 +38,52 @@ func (gs *GenomeSet) StringSlice() [][]string {
 }
 
 // Mutate mutates a genome by performing numMutations random symbol exchanges within the genome.
-func (gs *GenomeSet) Mutate(numMutations int) {
+func (gs *GenomeSet) Mutate(rng *rand.Rand, numMutations int) {
 	// iter gs.Genomes and mutate each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].Mutate(numMutations)
+		gs.Genomes[i].Mutate(rng, numMutations)
 	}
 }
 
-func (gs *GenomeSet) IsTransposition(gl int) {
+func (gs *GenomeSet) IsTransposition(rng *rand.Rand, gl int) {
 	// iter the gs.Genomes and IsTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].IsTransposition(gl)
+		gs.Genomes[i].IsTransposition(rng, gl)
 	}
 }
 
-func (gs *GenomeSet) RisTransposition(gl int) {
+func (gs *GenomeSet) RisTransposition(rng *rand.Rand, gl int) {
 	// iter the gs.Genomes and IsTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].RisTransposition(gl)
+		gs.Genomes[i].RisTransposition(rng, gl)
 	}
 }
 
-func (gs *GenomeSet) GeneTransposition() {
+func (gs *GenomeSet) GeneTransposition(rng *rand.Rand) {
 	// iter the gs.Genomes and GeneTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].GeneTransposition()
+		gs.Genomes[i].GeneTransposition(rng)
 	}
 }
 
-func (gs *GenomeSet) OnePointRecombination(other *GenomeSet) {
+func (gs *GenomeSet) OnePointRecombination(rng *rand.Rand, other *GenomeSet) {
 	// iter the gs.Genomes and GeneTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].OnePointRecombination(other.Genomes[i])
+		gs.Genomes[i].OnePointRecombination(rng, other.Genomes[i])
 	}
 }
 
-func (gs *GenomeSet) TwoPointRecombination(other *GenomeSet) {
+func (gs *GenomeSet) TwoPointRecombination(rng *rand.Rand, other *GenomeSet) {
 	// iter the gs.Genomes and GeneTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].TwoPointRecombination(other.Genomes[i])
+		gs.Genomes[i].TwoPointRecombination(rng, other.Genomes[i])
 	}
 }
 
-func (gs *GenomeSet) GeneRecombination(other *GenomeSet) {
+func (gs *GenomeSet) GeneRecombination(rng *rand.Rand, other *GenomeSet) {
 	// iter the gs.Genomes and GeneTransposition each one
 	for i := range gs.Genomes {
-		gs.Genomes[i].GeneRecombination(other.Genomes[i])
+		gs.Genomes[i].GeneRecombination(rng, other.Genomes[i])
 	}
 }
 
@@ -94,12 +99,44 @@ func (gs *GenomeSet) Dup() *GenomeSet {
 		LinkFunc: gs.LinkFunc,
 		Score:    gs.Score,
 	}
+	if gs.Objectives != nil {
+		dst.Objectives = append([]float64(nil), gs.Objectives...)
+	}
 	for i := range gs.Genomes {
 		dst.Genomes[i] = gs.Genomes[i].Dup()
 	}
 	return dst
 }
 
+// Snapshot is the complete, serializable state of a genomeset.
+type Snapshot struct {
+	Genomes    []genome.Snapshot `yaml:"genomes"`
+	LinkFunc   string            `yaml:"linkfunc"`
+	Score      float64           `yaml:"score"`
+	Objectives []float64         `yaml:"objectives,omitempty"`
+}
+
+// Snapshot returns the complete state of the genomeset.
+func (gs *GenomeSet) Snapshot() Snapshot {
+	s := Snapshot{LinkFunc: gs.LinkFunc, Score: gs.Score, Objectives: gs.Objectives}
+	for _, g := range gs.Genomes {
+		s.Genomes = append(s.Genomes, g.Snapshot())
+	}
+	return s
+}
+
+// Restore creates a genomeset from its snapshot.
+func Restore(s Snapshot, funcType functions.FuncType) *GenomeSet {
+	genomes := make([]*genome.Genome, len(s.Genomes))
+	for i, g := range s.Genomes {
+		genomes[i] = genome.Restore(g, funcType)
+	}
+	gs := New(genomes, s.LinkFunc)
+	gs.Score = s.Score
+	gs.Objectives = s.Objectives
+	return gs
+}
+
 // ScoringFunc is the function that is used to evaluate the fitness of the model.
 // Typically, a return value of 0 means that the function is nowhere close to being
 // a valid solution and a return value of 1000 (or higher) means a perfect solution.
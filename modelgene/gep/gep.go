@@ -1,6 +1,9 @@
 package gep
 
 import (
+	"math/rand"
+	"time"
+
 	"github.com/wonderstone/QuantKit/modelgene/gep/functions"
 	"github.com/wonderstone/QuantKit/modelgene/gep/gene"
 	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
@@ -26,7 +29,11 @@ func Run(config config.Model, expectFitness float64, numTerminals int, validF ge
 
 	switch config.Gep.Mode {
 	case "Genome":
-		m := model.New(fw, functions.Float64, config.Gep.NumGenomes, config.Gep.HeadSize, config.Gep.NumGenesPerGenome, numTerminals, config.Gep.NumConstants, config.Gep.LinkFunc, validF)
+		seed := config.Gep.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		m := model.New(rand.New(rand.NewSource(seed)), fw, functions.Float64, config.Gep.NumGenomes, config.Gep.HeadSize, config.Gep.NumGenesPerGenome, numTerminals, config.Gep.NumConstants, config.Gep.LinkFunc, validF)
 		s := m.Evolve(config.Gep.Iteration, expectFitness, config.Gep.PMutate, config.Gep.Pis, config.Gep.Glis, config.Gep.Pris, config.Gep.Glris, config.Gep.PGene, config.Gep.P1p, config.Gep.P2p, config.Gep.Pr)
 		gr, err := grammars.LoadGoMathGrammar()
 		if err != nil {
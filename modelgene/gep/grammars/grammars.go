@@ -7,6 +7,7 @@
 package grammars
 
 import (
+	"embed"
 	"encoding/xml"
 	"io/ioutil"
 	"log"
@@ -19,6 +20,11 @@ import (
 
 const grammarPath = "GEP-MOD/grammars"
 
+// embedded holds the grammar files compiled into the binary, used when no grammar directory is found on disk.
+//
+//go:embed *.grm.xml
+var embedded embed.FS
+
 // Functions is a collection of Functions available in the language grammar.
 type Functions struct {
 	Count     int        `xml:"count,attr"`
@@ -263,6 +269,9 @@ func loadGrammar(path string) (*Grammar, error) {
 	v := &Grammar{}
 
 	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = embedded.ReadFile(filepath.Base(path))
+	}
 	if err != nil {
 		log.Printf("unable to read file %q: %q", path, err)
 		return nil, err
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genomeset"
+	"gopkg.in/yaml.v3"
+)
+
+// + 训练的随机数按迭代派生: 第 i 代使用 seed + i 作为种子
+// + 这样断点只需要保存种子和迭代次数，恢复后的演化与不中断时完全一致
+// + 断点记录训练配置的摘要，配置变化后不再从断点恢复；全部迭代完成后断点归档为 *.done.yaml
+
+// iterateRand 第 iterate 代使用的随机数生成器
+func iterateRand(seed int64, iterate int) *rand.Rand {
+	return rand.New(rand.NewSource(seed + int64(iterate)))
+}
+
+// Checkpoint 训练断点，保存最近一次完成评估的种群
+type Checkpoint struct {
+	Mode       string               `yaml:"mode"`
+	ConfigHash string               `yaml:"config-hash"` // 训练配置的摘要
+	Seed       int64                `yaml:"seed"`
+	Iterate    int                  `yaml:"iterate"`    // 已完成评估的迭代
+	BestIndex  int                  `yaml:"best-index"` // 最优个体在种群中的序号
+	Best       Record               `yaml:"best"`
+	Genomes    []genome.Snapshot    `yaml:"genomes,omitempty"`
+	GenomeSets []genomeset.Snapshot `yaml:"genomesets,omitempty"`
+}
+
+// needCheckpoint 是否需要在本次迭代后保存断点
+func (op *Op) needCheckpoint(iterate int) bool {
+	if op.CheckpointFile == "" || op.Conf.CheckpointInterval <= 0 {
+		return false
+	}
+
+	return iterate%op.Conf.CheckpointInterval == 0 || iterate == op.Conf.Iteration
+}
+
+// configHash 训练配置的摘要
+// 迭代次数、种子(以断点为准)、并行数、断点间隔和导出配置不影响种群的含义，不参与计算
+func (op *Op) configHash() string {
+	conf := *op.Conf
+	conf.Iteration, conf.Seed, conf.Workers, conf.CheckpointInterval = 0, 0, 0, 0
+	conf.Export = config.FormulaExport{}
+
+	data, err := yaml.Marshal(
+		struct {
+			Conf        config.GepModel    `yaml:"conf"`
+			NumTerminal int                `yaml:"num-terminal"`
+			Indicator   map[string]int     `yaml:"indicator"`
+			Objectives  []config.Objective `yaml:"objectives"`
+		}{conf, op.NumTerminal, op.Indicator2FormulaIndex, op.Objectives},
+	)
+	if err != nil {
+		config.ErrorF("序列化训练配置失败: %s", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (op *Op) saveCheckpoint(cp Checkpoint) {
+	cp.ConfigHash = op.configHash()
+	data, err := yaml.Marshal(cp)
+	if err != nil {
+		config.ErrorF("序列化训练断点失败: %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(op.CheckpointFile), 0755); err != nil {
+		config.ErrorF("创建训练断点目录失败: %s", err)
+	}
+
+	// 先写临时文件再替换，避免写入过程中中断导致断点损坏
+	tmp := op.CheckpointFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		config.ErrorF("写入训练断点失败: %s", err)
+	}
+
+	if err := os.Rename(tmp, op.CheckpointFile); err != nil {
+		config.ErrorF("写入训练断点失败: %s", err)
+	}
+}
+
+// loadCheckpoint 读取训练断点，文件不存在或者与当前模式、训练配置不一致时返回false
+func (op *Op) loadCheckpoint(mode string) (Checkpoint, bool) {
+	var cp Checkpoint
+	if op.CheckpointFile == "" {
+		return cp, false
+	}
+
+	data, err := os.ReadFile(op.CheckpointFile)
+	if os.IsNotExist(err) {
+		return cp, false
+	} else if err != nil {
+		config.ErrorF("读取训练断点失败: %s", err)
+	}
+
+	if err := yaml.Unmarshal(data, &cp); err != nil {
+		config.ErrorF("解析训练断点失败: %s", err)
+	}
+
+	if cp.Mode != mode {
+		config.WarnF("训练断点模式[%s]与当前模式[%s]不一致，重新开始训练", cp.Mode, mode)
+		return cp, false
+	}
+
+	if cp.ConfigHash != op.configHash() {
+		config.WarnF("训练配置与训练断点不一致，重新开始训练")
+		return cp, false
+	}
+
+	config.InfoF("从训练断点恢复: 迭代 %d, 种子 %d, 最优得分 %f", cp.Iterate, cp.Seed, cp.Best.Gep.Score)
+	return cp, true
+}
+
+// archiveCheckpoint 全部迭代完成后归档训练断点，再次训练时重新开始
+func (op *Op) archiveCheckpoint() {
+	if op.CheckpointFile == "" {
+		return
+	}
+
+	ext := filepath.Ext(op.CheckpointFile)
+	archive := strings.TrimSuffix(op.CheckpointFile, ext) + ".done" + ext
+	if err := os.Rename(op.CheckpointFile, archive); err != nil && !os.IsNotExist(err) {
+		config.WarnF("归档训练断点失败: %s", err)
+	}
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
+)
+
+func testGepConfig(iteration int) config.GepModel {
+	return config.GepModel{
+		Function: []config.ModelFunc{
+			{Symbol: "+", Weight: 1},
+			{Symbol: "-", Weight: 1},
+			{Symbol: "*", Weight: 1},
+		},
+		Iteration:          iteration,
+		PMutate:            0.5,
+		Pis:                0.1,
+		Glis:               3,
+		Pris:               0.1,
+		Glris:              3,
+		PGene:              0.1,
+		P1p:                0.3,
+		P2p:                0.3,
+		Pr:                 0.1,
+		NumGenomes:         20,
+		HeadSize:           5,
+		NumGenesPerGenome:  2,
+		LinkFunc:           "+",
+		Mode:               "Genome",
+		Seed:               42,
+		CheckpointInterval: 1,
+	}
+}
+
+func testPerf(g *genome.Genome) (float64, []float64) {
+	r := g.EvalMath([]float64{1.5, 2.5})
+	score := 1000.0 / (1.0 + math.Abs(r-10))
+	if math.IsNaN(score) {
+		score = 0
+	}
+
+	return score, nil
+}
+
+// counter 统计评估的个体数
+func counter(n *atomic.Int64) WithOption {
+	return WithPerformance(
+		func(g *genome.Genome) (float64, []float64) {
+			n.Add(1)
+			return testPerf(g)
+		},
+	)
+}
+
+func evolve(iteration int, option ...WithOption) *Record {
+	h := &GenomeHandler{}
+	_ = h.Init(
+		append(
+			[]WithOption{
+				WithModelConfig(testGepConfig(iteration)),
+				WithNumTerminal(2),
+				WithPerformance(testPerf),
+			}, option...,
+		)...,
+	)
+
+	return h.Evolve()
+}
+
+func TestEvolveSeed(t *testing.T) {
+	r1 := evolve(10)
+	r2 := evolve(10)
+	if !reflect.DeepEqual(r1, r2) {
+		t.Errorf("相同种子的训练结果不一致: %v != %v", r1.Gep, r2.Gep)
+	}
+}
+
+// interrupt 训练在第 iterate 代评估时中断，断点保留上一代的种群
+func interrupt(t *testing.T, iterate int, option ...WithOption) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := 0
+	evolve(
+		10, append(
+			option, WithContext(ctx), WithPerformance(
+				func(g *genome.Genome) (float64, []float64) {
+					if n++; n > iterate*testGepConfig(0).NumGenomes {
+						cancel()
+					}
+					return testPerf(g)
+				},
+			),
+		)...,
+	)
+	require.Error(t, ctx.Err(), "训练没有在第%d代中断", iterate)
+}
+
+func TestEvolveResume(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checkpoint.yaml")
+
+	want := evolve(10)
+	interrupt(t, 5, WithCheckpoint(file))
+
+	// 恢复后应从第5代继续，种子以断点为准
+	var evaluated atomic.Int64
+	got := evolve(
+		10, WithCheckpoint(file),
+		func(op *Op) { op.Conf.Seed = 7 },
+		counter(&evaluated),
+	)
+	require.EqualValues(t, 6*testGepConfig(0).NumGenomes, evaluated.Load(), "断点恢复后重复评估了已完成的代")
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("从断点恢复的训练结果不一致: %v != %v", want.Gep, got.Gep)
+	}
+
+	// 训练完成后断点归档
+	_, err := os.Stat(file)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(filepath.Dir(file), "checkpoint.done.yaml"))
+	require.NoError(t, err)
+}
+
+// 训练配置变化后不从断点恢复
+func TestEvolveConfigChanged(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checkpoint.yaml")
+	interrupt(t, 5, WithCheckpoint(file))
+
+	var evaluated atomic.Int64
+	evolve(
+		10, WithCheckpoint(file),
+		func(op *Op) { op.Conf.PMutate = 0.2 },
+		counter(&evaluated),
+	)
+	require.EqualValues(t, 11*testGepConfig(0).NumGenomes, evaluated.Load())
+}
+
+// 达到满意预期时训练完成，断点归档；中断时断点保留
+func TestEvolveExpectFitness(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checkpoint.yaml")
+	interrupt(t, 5, WithCheckpoint(file))
+	_, err := os.Stat(file)
+	require.NoError(t, err)
+
+	var evaluated atomic.Int64
+	evolve(10, WithCheckpoint(file), WithExpectFitness(0), counter(&evaluated))
+	require.EqualValues(t, testGepConfig(0).NumGenomes, evaluated.Load())
+
+	_, err = os.Stat(file)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(filepath.Dir(file), "checkpoint.done.yaml"))
+	require.NoError(t, err)
+}
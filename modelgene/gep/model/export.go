@@ -0,0 +1,318 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/modelgene/gep/functions"
+	"github.com/wonderstone/QuantKit/modelgene/gep/gene"
+	"github.com/wonderstone/QuantKit/modelgene/gep/grammars"
+)
+
+// + 训练结果导出为指标公式:
+// + 1. 每个基因通过 Go 数学语法渲染为表达式，再按连接函数合并，与 Genome.EvalMath 的计算方式一致
+// + 2. 生成的类型实现 formula.Formula 接口，并在 init 中通过 RegisterNewFormula 注册
+// + 3. 表达式用到的辅助函数以公式名称为前缀，同一个包内可以导出多个公式
+// + 4. 表达式中的 d[0], d[1], ... 按训练记录中的指标映射读取指标
+// + GenomeSet 模式下每个基因组生成一个公式，名称依次追加序号
+
+var terminalPattern = regexp.MustCompile(`d\[(\d+)\]`)
+
+// exportFormula 生成代码中的一个公式
+type exportFormula struct {
+	Name   string         // 类型名和注册名
+	Func   string         // 表达式函数名
+	Var    string         // 输入指标变量名
+	Expr   string         // 表达式
+	Inputs map[int]string // 表达式用到的指标，key为表达式中的下标
+	Size   int            // 输入向量长度
+}
+
+// InputNames 按下标顺序返回用到的指标名称
+func (f exportFormula) InputNames() []string {
+	index := make([]int, 0, len(f.Inputs))
+	for i := range f.Inputs {
+		index = append(index, i)
+	}
+	sort.Ints(index)
+
+	names := make([]string, len(index))
+	for i, v := range index {
+		names[i] = f.Inputs[v]
+	}
+
+	return names
+}
+
+var formulaTemplate = template.Must(template.New("formula").Parse(`// Code generated by QuantKit GEP export. DO NOT EDIT.
+//
+// 训练ID: {{.Record.TrainId}}, 模式: {{.Record.Gep.Mode}}, 得分: {{.Record.Gep.Score}}
+// 在 indicator.yaml 中按如下方式配置后即可在 calc 模式下预先计算，input 用于确定指标计算顺序:
+{{- range .Formulas}}
+//
+//	- name: {{.Name}}
+//	  func: {{.Name}}
+{{- if .Inputs}}
+//	  input:
+{{- range .InputNames}}
+//	    {{.}}: 0
+{{- end}}
+{{- end}}
+{{- end}}
+
+package {{.Package}}
+
+import (
+	"math"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/framework/entity/formula"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+{{range .Formulas}}
+// {{.Var}} 表达式用到的指标，key为表达式中的下标
+var {{.Var}} = map[int]string{
+{{- range $i, $name := .Inputs}}
+	{{$i}}: {{printf "%q" $name}},
+{{- end}}
+}
+
+// {{.Name}} 由GEP训练得到的表达式生成的指标
+type {{.Name}} struct {
+	Name string
+}
+
+func (f *{{.Name}}) DoInit(c config.Formula) {
+	f.Name = c.Name
+}
+
+func (f *{{.Name}}) DoCalculate(tm time.Time, row dataframe.RecordFunc) string {
+	return formula.FormatFloat(f.DoCalculateFloat(tm, row))
+}
+
+func (f *{{.Name}}) DoCalculateFloat(tm time.Time, row dataframe.RecordFunc) float64 {
+	d := make([]float64, {{.Size}})
+	for i, name := range {{.Var}} {
+		v, err := dataframe.TryConvertToFloat(row, name)
+		if err != nil {
+			return math.NaN()
+		}
+		d[i] = v
+	}
+
+	v := {{.Func}}(d)
+	if math.IsInf(v, 0) {
+		return math.NaN()
+	}
+
+	return v
+}
+
+func (f *{{.Name}}) DoReset() {}
+
+func {{.Func}}(d []float64) float64 {
+	return {{.Expr}}
+}
+{{end}}
+func init() {
+{{- range .Formulas}}
+	formula.RegisterNewFormula(new({{.Name}}), "{{.Name}}")
+{{- end}}
+}
+{{.Helper}}
+`))
+
+// FormulaSource 将训练记录中的表达式生成实现 formula.Formula 接口的Go源码
+func FormulaSource(rec Record, conf config.GepModel) ([]byte, error) {
+	if len(rec.Gep.KES) == 0 {
+		return nil, fmt.Errorf("训练记录中没有表达式")
+	}
+
+	inputs := rec.Gep.Inputs
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("训练记录中没有指标映射，请重新训练")
+	}
+
+	grammar, err := grammars.LoadGoMathGrammar()
+	if err != nil {
+		return nil, fmt.Errorf("读取GEP数学语法失败: %w", err)
+	}
+
+	name := conf.Export.Name
+	prefix := strings.ToLower(name[:1]) + name[1:]
+
+	helpers := make(grammars.HelperMap)
+	exprs := make([]string, len(rec.Gep.KES))
+	for i, kes := range rec.Gep.KES {
+		if exprs[i], err = linkExpression(grammar, kes, conf.LinkFunc, helpers); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(helpers))
+	for k := range helpers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	code := make([]string, len(keys))
+	for i, k := range keys {
+		code[i] = expand(helpers[k])
+	}
+
+	renamed, helper, err := renameHelpers(exprs, strings.Join(code, "\n"), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	formulas := make([]exportFormula, len(rec.Gep.KES))
+	for i, expr := range exprs {
+		f := exportFormula{
+			Name:   name,
+			Func:   prefix + "Eval",
+			Var:    prefix + "Inputs",
+			Expr:   renamed[i],
+			Inputs: make(map[int]string),
+			Size:   len(inputs),
+		}
+		if len(rec.Gep.KES) > 1 {
+			f.Name = name + strconv.Itoa(i)
+			f.Func = prefix + "Eval" + strconv.Itoa(i)
+			f.Var = prefix + "Inputs" + strconv.Itoa(i)
+		}
+
+		for _, m := range terminalPattern.FindAllStringSubmatch(expr, -1) {
+			index, _ := strconv.Atoi(m[1])
+			if index >= len(inputs) {
+				return nil, fmt.Errorf("表达式使用了第%d个指标，但训练只有%d个指标", index+1, len(inputs))
+			}
+			f.Inputs[index] = inputs[index]
+		}
+
+		formulas[i] = f
+	}
+
+	var buf bytes.Buffer
+	err = formulaTemplate.Execute(
+		&buf, map[string]any{
+			"Record":   rec,
+			"Package":  conf.Export.Package,
+			"Formulas": formulas,
+			"Helper":   helper,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("生成公式代码失败: %w", err)
+	}
+
+	clean, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("格式化公式代码失败: %w", err)
+	}
+
+	return clean, nil
+}
+
+// linkExpression 渲染一个基因组的表达式，各基因的结果作为连接函数的参数
+func linkExpression(grammar *grammars.Grammar, kes []string, linkFunc string, helpers grammars.HelperMap) (string, error) {
+	s, ok := grammar.Functions.FuncMap[linkFunc]
+	if !ok {
+		return "", fmt.Errorf("连接函数[%s]在GEP数学语法中不存在", linkFunc)
+	}
+	lf, ok := s.(*grammars.Function)
+	if !ok {
+		return "", fmt.Errorf("连接函数[%s]无法转换为语法函数", linkFunc)
+	}
+	if len(kes) < lf.Terminals() {
+		return "", fmt.Errorf("连接函数[%s]需要%d个基因，表达式只有%d个", linkFunc, lf.Terminals(), len(kes))
+	}
+	if v, ok := grammar.Helpers.HelperMap[lf.SymbolName]; ok {
+		helpers[lf.SymbolName] = v
+	}
+
+	expr := lf.Chardata
+	for i, k := range kes[:lf.Terminals()] {
+		e, err := gene.New(k, functions.Float64).Expression(grammar, helpers)
+		if err != nil {
+			return "", fmt.Errorf("渲染表达式[%s]失败: %w", k, err)
+		}
+		expr = strings.ReplaceAll(expr, "x"+strconv.Itoa(i), e)
+	}
+
+	return expand(expr), nil
+}
+
+// renameHelpers 将语法中以 gep 为前缀的辅助函数重命名为以公式名称为前缀
+// + 表达式和辅助函数解析为语法树后只替换辅助函数声明的标识符，指标、变量等其他标识符保持不变
+func renameHelpers(exprs []string, helper string, prefix string) ([]string, string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", "package helper\n"+helper, parser.ParseComments)
+	if err != nil {
+		return nil, "", fmt.Errorf("解析辅助函数失败: %w", err)
+	}
+
+	names := make(map[string]string)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			names[fn.Name.Name] = prefix + strings.TrimPrefix(fn.Name.Name, "gep")
+		}
+	}
+
+	rename := func(node ast.Node) {
+		ast.Inspect(
+			node, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if v, ok := names[id.Name]; ok {
+						id.Name = v
+					}
+				}
+				return true
+			},
+		)
+	}
+
+	renamed := make([]string, len(exprs))
+	for i, expr := range exprs {
+		x, err := parser.ParseExpr(expr)
+		if err != nil {
+			return nil, "", fmt.Errorf("解析表达式[%s]失败: %w", expr, err)
+		}
+		rename(x)
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, token.NewFileSet(), x); err != nil {
+			return nil, "", fmt.Errorf("输出表达式[%s]失败: %w", expr, err)
+		}
+		renamed[i] = buf.String()
+	}
+
+	if len(file.Decls) == 0 {
+		return renamed, "", nil
+	}
+
+	rename(file)
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, "", fmt.Errorf("输出辅助函数失败: %w", err)
+	}
+
+	// 去掉解析时补充的包声明
+	_, code, _ := strings.Cut(buf.String(), "\n")
+	return renamed, code, nil
+}
+
+// expand 展开语法中的占位符
+func expand(s string) string {
+	return strings.NewReplacer("{CHARX}", "x", "{CRLF}", "\n", "{TAB}", "\t").Replace(s)
+}
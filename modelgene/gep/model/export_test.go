@@ -0,0 +1,89 @@
+package model
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestFormulaSource(t *testing.T) {
+	conf := testGepConfig(1)
+	conf.Export.Name = "GepSignal"
+	conf.Export.Package = "indicator"
+
+	rec := Record{TrainId: "test", Gep: GepRecord{Mode: "Genome", KES: [][]string{{"+.d0.d2", "Mod.d3.d0"}}}}
+	rec.Gep.Inputs = []string{"Close", "Open", "MA3", "MA5"}
+	code, err := FormulaSource(rec, conf)
+	if err != nil {
+		t.Fatalf("生成公式代码失败: %v\n%s", err, code)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "formula.go", code, 0); err != nil {
+		t.Fatalf("生成的代码无法解析: %v\n%s", err, code)
+	}
+
+	src := string(code)
+	for _, want := range []string{
+		"package indicator",
+		"return ((d[0] + d[2]) + gepSignalMod(d[3], d[0]))",
+		"func gepSignalMod(x, y float64) float64",
+		`0: "Close",`,
+		`formula.RegisterNewFormula(new(GepSignal), "GepSignal")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("生成的代码缺少 %q:\n%s", want, src)
+		}
+	}
+
+	// 未使用的指标不需要读取
+	if strings.Contains(src, `"Open"`) {
+		t.Errorf("生成的代码读取了未使用的指标:\n%s", src)
+	}
+
+	// GenomeSet 每个基因组生成一个公式
+	rec.Gep.Mode = "GenomeSet"
+	rec.Gep.KES = [][]string{{"d0", "d1"}, {"d2", "d3"}}
+	code, err = FormulaSource(rec, conf)
+	if err != nil {
+		t.Fatalf("生成公式代码失败: %v\n%s", err, code)
+	}
+	for _, want := range []string{"type GepSignal0 struct", "type GepSignal1 struct", "return (d[2] + d[3])"} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("生成的代码缺少 %q:\n%s", want, code)
+		}
+	}
+
+	// 表达式引用的指标超出训练指标
+	rec.Gep.Inputs = []string{"Close"}
+	if _, err := FormulaSource(rec, conf); err == nil {
+		t.Errorf("指标数量不足时应返回错误")
+	}
+
+	// 没有指标映射的训练记录无法导出
+	rec.Gep.Inputs = nil
+	if _, err := FormulaSource(rec, conf); err == nil {
+		t.Errorf("没有指标映射时应返回错误")
+	}
+}
+
+// 只重命名辅助函数的标识符，其他包含 gep 的标识符保持不变
+func TestRenameHelpers(t *testing.T) {
+	exprs, code, err := renameHelpers(
+		[]string{"gepMod(d[0], gepped)"},
+		"func gepMod(x, y float64) float64 {\n\t// gep helper\n\treturn gepMod2(x, y)\n}\n\nfunc gepMod2(x, y float64) float64 {\n\treturn x - y\n}\n",
+		"signal",
+	)
+	if err != nil {
+		t.Fatalf("重命名失败: %v", err)
+	}
+
+	if exprs[0] != "signalMod(d[0], gepped)" {
+		t.Errorf("表达式重命名错误: %s", exprs[0])
+	}
+	for _, want := range []string{"func signalMod(x, y float64)", "// gep helper", "return signalMod2(x, y)", "func signalMod2("} {
+		if !strings.Contains(code, want) {
+			t.Errorf("辅助函数缺少 %q:\n%s", want, code)
+		}
+	}
+}
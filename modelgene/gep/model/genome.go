@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 
@@ -14,6 +15,12 @@ type GenomeHandler struct {
 	Genomes []*genome.Genome
 	Genome  *genome.Genome
 	Funcs   []gene.FuncWeight
+	rng     *rand.Rand
+
+	parents  []*genome.Genome // 多目标训练中本代的父代，子代评估后与其合并选择
+	rank     []int            // 多目标训练中各个体的前沿等级
+	crowding []float64        // 多目标训练中各个体的拥挤距离
+	front    []*genome.Genome // 多目标训练中当前种群的帕累托前沿
 }
 
 func (g *GenomeHandler) Init(option ...WithOption) error {
@@ -42,6 +49,7 @@ func (g *GenomeHandler) Init(option ...WithOption) error {
 		}
 		g.Genome = genome.New(genes, g.Op.Conf.LinkFunc)
 	} else if g.Op.Perf != nil {
+		g.rng = iterateRand(g.Op.Seed, 0)
 		g.Genomes = make([]*genome.Genome, g.Op.Conf.NumGenomes)
 		g.Funcs = functions
 		n := maxArity(functions, g.Op.FuncType)
@@ -54,6 +62,7 @@ func (g *GenomeHandler) Init(option ...WithOption) error {
 			// }
 			for j := range genes {
 				genes[j] = gene.RandomNew(
+					g.rng,
 					g.Op.Conf.HeadSize, tailSize, g.Op.NumTerminal, g.Op.Conf.NumConstants, functions, g.Op.FuncType,
 				)
 			}
@@ -71,13 +80,29 @@ func (g *GenomeHandler) Init(option ...WithOption) error {
 
 func (g *GenomeHandler) makeRecord(gene *genome.Genome) *Record {
 	// 将karva表达式写入文件
-	return &Record{
+	rec := &Record{
 		Gep: GepRecord{
-			Mode:  "Genome",
-			Score: gene.Score,
-			KES:   [][]string{gene.StringSlice()},
+			Mode:   "Genome",
+			Score:  gene.Score,
+			KES:    [][]string{gene.StringSlice()},
+			Seed:   g.Op.Seed,
+			Inputs: g.Op.Inputs,
 		},
 	}
+
+	if len(g.front) > 0 {
+		rec.Gep.Objectives = g.Op.objectiveNames()
+		seen := make(map[string]bool)
+		for _, v := range g.front {
+			kes := [][]string{v.StringSlice()}
+			if key := fmt.Sprint(kes); !seen[key] {
+				seen[key] = true
+				rec.Gep.Front = append(rec.Gep.Front, FrontRecord{Score: v.Score, Objectives: v.Objectives, KES: kes})
+			}
+		}
+	}
+
+	return rec
 }
 
 func (g *GenomeHandler) replication() {
@@ -95,10 +120,10 @@ func (g *GenomeHandler) replication() {
 	maxWeight = maxWeight - minWeight + 1.0
 
 	result := make([]*genome.Genome, 0, len(g.Genomes))
-	index := rand.Intn(len(g.Genomes))
+	index := g.rng.Intn(len(g.Genomes))
 	beta := 0.0
 	for i := 0; i < len(g.Genomes); i++ {
-		beta += rand.Float64() * 2.0 * maxWeight
+		beta += g.rng.Float64() * 2.0 * maxWeight
 		for beta > g.Genomes[index].Score-minWeight+1.0 {
 			beta -= g.Genomes[index].Score - minWeight + 1.0
 			index = (index + 1) % len(g.Genomes)
@@ -114,13 +139,13 @@ func (g *GenomeHandler) mutation(pm float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pm))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Determine the total number of mutations to perform within the genome
 		// Do not change this part, respect the init coder
-		numMutations := 1 + rand.Intn(2)
+		numMutations := 1 + g.rng.Intn(2)
 		// fmt.Printf("\nMutating genome #%v %v times, before:\n%v\n", genomeNum, numMutations, genome)
-		gen.Mutate(numMutations)
+		gen.Mutate(g.rng, numMutations)
 		// fmt.Printf("after:\n%v\n", genome)
 	}
 }
@@ -130,10 +155,10 @@ func (g *GenomeHandler) isTransposition(pis float64, gl int) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pis))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the isTransposition within the genome
-		gen.IsTransposition(gl)
+		gen.IsTransposition(g.rng, gl)
 
 	}
 }
@@ -143,10 +168,10 @@ func (g *GenomeHandler) risTransposition(pris float64, gl int) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pris))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the risTransposition within the genome
-		gen.RisTransposition(gl)
+		gen.RisTransposition(g.rng, gl)
 	}
 }
 
@@ -155,10 +180,10 @@ func (g *GenomeHandler) geneTransposition(pgene float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pgene))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the geneTransposition within the genome
-		gen.GeneTransposition()
+		gen.GeneTransposition(g.rng)
 	}
 }
 
@@ -167,10 +192,10 @@ func (g *GenomeHandler) onePointRecombination(p1p float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * p1p))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -178,7 +203,7 @@ func (g *GenomeHandler) onePointRecombination(p1p float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the onePointRecombination within the genome
-		gen1.OnePointRecombination(gen2)
+		gen1.OnePointRecombination(g.rng, gen2)
 	}
 }
 
@@ -187,10 +212,10 @@ func (g *GenomeHandler) twoPointRecombination(p2p float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * p2p))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -198,7 +223,7 @@ func (g *GenomeHandler) twoPointRecombination(p2p float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the twoPointRecombination within the genome
-		gen1.TwoPointRecombination(gen2)
+		gen1.TwoPointRecombination(g.rng, gen2)
 	}
 }
 
@@ -207,10 +232,10 @@ func (g *GenomeHandler) geneRecombination(pr float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pr))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -218,13 +243,18 @@ func (g *GenomeHandler) geneRecombination(pr float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the geneRecombination within the genome
-		gen1.GeneRecombination(gen2)
+		gen1.GeneRecombination(g.rng, gen2)
 	}
 }
 
 func (g *GenomeHandler) generate(iterate int, bestGenome *genome.Genome) {
+	g.rng = iterateRand(g.Op.Seed, iterate)
 	saveCopy := bestGenome.Dup()
-	g.replication() // Section 3.3.1, book page 75
+	if g.Op.multiObjective() {
+		g.selection()
+	} else {
+		g.replication() // Section 3.3.1, book page 75
+	}
 	if g.Op.Conf.PMutate <= 0 || g.Op.Conf.PMutate >= 1 {
 		config.ErrorF("pmutate 必须是 0 至 1 之间")
 	} else {
@@ -262,7 +292,10 @@ func (g *GenomeHandler) generate(iterate int, bestGenome *genome.Genome) {
 		g.geneRecombination(g.Op.Conf.Pr)
 	}
 	// Now that replication is done, restore the best genome (aka "elitism")
-	g.Genomes[0] = saveCopy
+	// 多目标训练的精英保留在子代评估后与父代合并选择时完成
+	if !g.Op.multiObjective() {
+		g.Genomes[0] = saveCopy
+	}
 
 	for index, v := range g.Genomes {
 		v.Iterate = iterate
@@ -270,20 +303,135 @@ func (g *GenomeHandler) generate(iterate int, bestGenome *genome.Genome) {
 	}
 }
 
+// selection 多目标训练的复制: 按前沿等级和拥挤距离做二元锦标赛，子代需要重新评估
+func (g *GenomeHandler) selection() {
+	g.parents = g.Genomes
+	result := make([]*genome.Genome, 0, len(g.Genomes))
+	for range g.Genomes {
+		child := g.Genomes[tournament(g.rng, g.rank, g.crowding)].Dup()
+		child.Objectives = nil
+		result = append(result, child)
+	}
+	g.Genomes = result
+}
+
+// survive 多目标训练的环境选择: 合并父代与子代，按非支配排序和拥挤距离保留原有数量的个体
+// 返回帕累托前沿中得分最高的个体
+func (g *GenomeHandler) survive() *genome.Genome {
+	population := append(append([]*genome.Genome{}, g.parents...), g.Genomes...)
+	fits := make([][]float64, len(population))
+	for i, v := range population {
+		fits[i] = fitness(v.Objectives, g.Op.Objectives)
+	}
+
+	selected := nsgaSelect(fits, len(g.Genomes))
+	survivors := make([]*genome.Genome, len(selected))
+	survivorFits := make([][]float64, len(selected))
+	for i, index := range selected {
+		survivors[i] = population[index]
+		survivors[i].Index = i
+		survivorFits[i] = fits[index]
+	}
+
+	g.Genomes, g.parents = survivors, nil
+	g.rank, g.crowding = rankPopulation(survivorFits)
+
+	var best *genome.Genome
+	g.front = g.front[:0]
+	for i, v := range g.Genomes {
+		if g.rank[i] != 0 {
+			continue
+		}
+
+		g.front = append(g.front, v)
+		if best == nil || v.Score > best.Score {
+			best = v
+		}
+	}
+
+	return best
+}
+
+// resume 从训练断点恢复种群，返回最优基因组和已完成的迭代次数
+func (g *GenomeHandler) resume() (*genome.Genome, int, bool) {
+	cp, ok := g.Op.loadCheckpoint("Genome")
+	if !ok || len(cp.Genomes) != len(g.Genomes) {
+		return nil, 0, false
+	}
+
+	g.Op.Seed = cp.Seed
+	for i, s := range cp.Genomes {
+		g.Genomes[i] = genome.Restore(s, g.Op.FuncType)
+		g.Genomes[i].Index = i
+		g.Genomes[i].Iterate = cp.Iterate
+	}
+
+	return g.Genomes[cp.BestIndex], cp.Iterate, true
+}
+
+func (g *GenomeHandler) checkpoint(iterate int, best *genome.Genome) {
+	if !g.Op.needCheckpoint(iterate) {
+		return
+	}
+
+	cp := Checkpoint{
+		Mode:    "Genome",
+		Seed:    g.Op.Seed,
+		Iterate: iterate,
+		Best:    *g.makeRecord(best),
+		Genomes: make([]genome.Snapshot, len(g.Genomes)),
+	}
+	for i, v := range g.Genomes {
+		if v == best {
+			cp.BestIndex = i
+		}
+		cp.Genomes[i] = v.Snapshot()
+	}
+
+	g.Op.saveCheckpoint(cp)
+}
+
 func (g *GenomeHandler) Evolve() *Record {
-	best, accomplished := g.Op.Perf(0, g.Genomes) // Preserve the best genome
-	if accomplished {
-		return g.makeRecord(best)
+	best, start, ok := g.resume()
+	if ok && g.Op.multiObjective() {
+		best = g.survive()
+	}
+
+	if !ok {
+		var finished bool
+		best, finished = g.Op.evaluateGenomes(0, g.Genomes) // Preserve the best genome
+		if !finished {
+			return g.makeRecord(best)
+		}
+		if g.Op.multiObjective() {
+			best = g.survive()
+		}
+		if best.Score >= g.Op.ExpectFitness {
+			g.Op.archiveCheckpoint()
+			return g.makeRecord(best)
+		}
+		g.checkpoint(0, best)
 	}
 
-	for i := 1; i <= g.Op.Conf.Iteration; i++ {
+	for i := start + 1; i <= g.Op.Conf.Iteration; i++ {
 		g.generate(i, best)
 
-		best, accomplished = g.Op.Perf(i, g.Genomes) // Preserve the best genome
-		if accomplished {
+		var finished bool
+		best, finished = g.Op.evaluateGenomes(i, g.Genomes) // Preserve the best genome
+		if !finished {
+			// 中断时保留上一代的断点，下次从断点恢复
 			return g.makeRecord(best)
 		}
+		if g.Op.multiObjective() {
+			best = g.survive()
+		}
+		if best.Score >= g.Op.ExpectFitness {
+			// 达到满意预期，训练完成
+			break
+		}
+		g.checkpoint(i, best)
 	}
+	g.Op.archiveCheckpoint()
 
 	return g.makeRecord(best)
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 
@@ -15,6 +16,12 @@ type GenomeSetHandler struct {
 	GenomeSets []*genomeset.GenomeSet
 	GenomeSet  *genomeset.GenomeSet
 	Funcs      []gene.FuncWeight
+	rng        *rand.Rand
+
+	parents  []*genomeset.GenomeSet // 多目标训练中本代的父代，子代评估后与其合并选择
+	rank     []int                  // 多目标训练中各个体的前沿等级
+	crowding []float64              // 多目标训练中各个体的拥挤距离
+	front    []*genomeset.GenomeSet // 多目标训练中当前种群的帕累托前沿
 }
 
 func (g *GenomeSetHandler) Init(option ...WithOption) error {
@@ -50,6 +57,7 @@ func (g *GenomeSetHandler) Init(option ...WithOption) error {
 		}
 
 	} else {
+		g.rng = iterateRand(g.Op.Seed, 0)
 		g.GenomeSets = make([]*genomeset.GenomeSet, g.Op.Conf.NumGenomes)
 		g.Funcs = functions
 		n := maxArity(functions, g.Op.FuncType)
@@ -62,6 +70,7 @@ func (g *GenomeSetHandler) Init(option ...WithOption) error {
 				genes := make([]*gene.Gene, g.Op.Conf.NumGenesPerGenome)
 				for k := range genes {
 					genes[k] = gene.RandomNew(
+						g.rng,
 						g.Op.Conf.HeadSize, tailSize, g.Op.NumTerminal, g.Op.Conf.NumConstants, functions,
 						g.Op.FuncType,
 					)
@@ -76,13 +85,29 @@ func (g *GenomeSetHandler) Init(option ...WithOption) error {
 
 func (g *GenomeSetHandler) makeRecord(gene *genomeset.GenomeSet) *Record {
 	// 将karva表达式写入文件
-	return &Record{
+	rec := &Record{
 		Gep: GepRecord{
-			Mode:  "GenomeSet",
-			Score: gene.Score,
-			KES:   gene.StringSlice(),
+			Mode:   "GenomeSet",
+			Score:  gene.Score,
+			KES:    gene.StringSlice(),
+			Seed:   g.Op.Seed,
+			Inputs: g.Op.Inputs,
 		},
 	}
+
+	if len(g.front) > 0 {
+		rec.Gep.Objectives = g.Op.objectiveNames()
+		seen := make(map[string]bool)
+		for _, v := range g.front {
+			kes := v.StringSlice()
+			if key := fmt.Sprint(kes); !seen[key] {
+				seen[key] = true
+				rec.Gep.Front = append(rec.Gep.Front, FrontRecord{Score: v.Score, Objectives: v.Objectives, KES: kes})
+			}
+		}
+	}
+
+	return rec
 }
 func (gs *GenomeSetHandler) replication() {
 	if len(gs.GenomeSets) == 0 {
@@ -98,10 +123,10 @@ func (gs *GenomeSetHandler) replication() {
 
 	maxWeight = maxWeight - minWeight + 1.0
 	result := make([]*genomeset.GenomeSet, 0, len(gs.GenomeSets))
-	index := rand.Intn(len(gs.GenomeSets))
+	index := gs.rng.Intn(len(gs.GenomeSets))
 	beta := 0.0
 	for i := 0; i < len(gs.GenomeSets); i++ {
-		beta += rand.Float64() * 2.0 * maxWeight
+		beta += gs.rng.Float64() * 2.0 * maxWeight
 		for beta > gs.GenomeSets[index].Score-minWeight+1.0 {
 			beta -= gs.GenomeSets[index].Score - minWeight + 1.0
 			index = (index + 1) % len(gs.GenomeSets)
@@ -117,12 +142,12 @@ func (gs *GenomeSetHandler) mutation(pm float64) {
 
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Determine the total number of mutations to perform within the genome
-		numMutations := 1 + rand.Intn(2)
+		numMutations := 1 + gs.rng.Intn(2)
 		// fmt.Printf("\nMutating genome #%v %v times, before:\n%v\n", genomeNum, numMutations, genome)
-		genSet.Mutate(numMutations)
+		genSet.Mutate(gs.rng, numMutations)
 		// fmt.Printf("after:\n%v\n", genome)
 	}
 }
@@ -132,10 +157,10 @@ func (gs *GenomeSetHandler) isTransposition(pis float64, gl int) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pis))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the isTransposition within the genomeset
-		genSet.IsTransposition(gl)
+		genSet.IsTransposition(gs.rng, gl)
 	}
 }
 
@@ -144,10 +169,10 @@ func (gs *GenomeSetHandler) risTransposition(pris float64, gl int) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pris))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the risTransposition within the genomeset
-		genSet.RisTransposition(gl)
+		genSet.RisTransposition(gs.rng, gl)
 	}
 }
 
@@ -156,10 +181,10 @@ func (gs *GenomeSetHandler) geneTransposition(pgene float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pgene))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the geneTransposition within the genomeset
-		genSet.GeneTransposition()
+		genSet.GeneTransposition(gs.rng)
 	}
 }
 
@@ -168,10 +193,10 @@ func (gs *GenomeSetHandler) onePointRecombination(p1p float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * p1p))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -179,7 +204,7 @@ func (gs *GenomeSetHandler) onePointRecombination(p1p float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the onePointRecombination within the genomeset
-		genSet1.OnePointRecombination(genSet2)
+		genSet1.OnePointRecombination(gs.rng, genSet2)
 	}
 }
 
@@ -188,10 +213,10 @@ func (gs *GenomeSetHandler) twoPointRecombination(p2p float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * p2p))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -199,7 +224,7 @@ func (gs *GenomeSetHandler) twoPointRecombination(p2p float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the twoPointRecombination within the genomeset
-		genSet1.TwoPointRecombination(genSet2)
+		genSet1.TwoPointRecombination(gs.rng, genSet2)
 	}
 }
 
@@ -208,10 +233,10 @@ func (gs *GenomeSetHandler) geneRecombination(pr float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pr))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -219,13 +244,18 @@ func (gs *GenomeSetHandler) geneRecombination(pr float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the geneRecombination within the genomeset
-		genSet1.GeneRecombination(genSet2)
+		genSet1.GeneRecombination(gs.rng, genSet2)
 	}
 }
 
 func (g *GenomeSetHandler) generate(iterate int, best *genomeset.GenomeSet) {
+	g.rng = iterateRand(g.Op.Seed, iterate)
 	saveCopy := best.Dup()
-	g.replication() // Section 3.3.1, book page 75
+	if g.Op.multiObjective() {
+		g.selection()
+	} else {
+		g.replication() // Section 3.3.1, book page 75
+	}
 	if g.Op.Conf.PMutate <= 0 || g.Op.Conf.PMutate >= 1 {
 		config.ErrorF("pmutate 必须是 0 至 1 之间")
 	} else {
@@ -263,7 +293,10 @@ func (g *GenomeSetHandler) generate(iterate int, best *genomeset.GenomeSet) {
 		g.geneRecombination(g.Op.Conf.Pr)
 	}
 	// Now that replication is done, restore the best genome (aka "elitism")
-	g.GenomeSets[0] = saveCopy
+	// 多目标训练的精英保留在子代评估后与父代合并选择时完成
+	if !g.Op.multiObjective() {
+		g.GenomeSets[0] = saveCopy
+	}
 
 	for index, v := range g.GenomeSets {
 		v.Iterate = iterate
@@ -271,20 +304,135 @@ func (g *GenomeSetHandler) generate(iterate int, best *genomeset.GenomeSet) {
 	}
 }
 
+// selection 多目标训练的复制: 按前沿等级和拥挤距离做二元锦标赛，子代需要重新评估
+func (g *GenomeSetHandler) selection() {
+	g.parents = g.GenomeSets
+	result := make([]*genomeset.GenomeSet, 0, len(g.GenomeSets))
+	for range g.GenomeSets {
+		child := g.GenomeSets[tournament(g.rng, g.rank, g.crowding)].Dup()
+		child.Objectives = nil
+		result = append(result, child)
+	}
+	g.GenomeSets = result
+}
+
+// survive 多目标训练的环境选择: 合并父代与子代，按非支配排序和拥挤距离保留原有数量的个体
+// 返回帕累托前沿中得分最高的个体
+func (g *GenomeSetHandler) survive() *genomeset.GenomeSet {
+	population := append(append([]*genomeset.GenomeSet{}, g.parents...), g.GenomeSets...)
+	fits := make([][]float64, len(population))
+	for i, v := range population {
+		fits[i] = fitness(v.Objectives, g.Op.Objectives)
+	}
+
+	selected := nsgaSelect(fits, len(g.GenomeSets))
+	survivors := make([]*genomeset.GenomeSet, len(selected))
+	survivorFits := make([][]float64, len(selected))
+	for i, index := range selected {
+		survivors[i] = population[index]
+		survivors[i].Index = i
+		survivorFits[i] = fits[index]
+	}
+
+	g.GenomeSets, g.parents = survivors, nil
+	g.rank, g.crowding = rankPopulation(survivorFits)
+
+	var best *genomeset.GenomeSet
+	g.front = g.front[:0]
+	for i, v := range g.GenomeSets {
+		if g.rank[i] != 0 {
+			continue
+		}
+
+		g.front = append(g.front, v)
+		if best == nil || v.Score > best.Score {
+			best = v
+		}
+	}
+
+	return best
+}
+
+// resume 从训练断点恢复种群，返回最优基因组集合和已完成的迭代次数
+func (g *GenomeSetHandler) resume() (*genomeset.GenomeSet, int, bool) {
+	cp, ok := g.Op.loadCheckpoint("GenomeSet")
+	if !ok || len(cp.GenomeSets) != len(g.GenomeSets) {
+		return nil, 0, false
+	}
+
+	g.Op.Seed = cp.Seed
+	for i, s := range cp.GenomeSets {
+		g.GenomeSets[i] = genomeset.Restore(s, g.Op.FuncType)
+		g.GenomeSets[i].Index = i
+		g.GenomeSets[i].Iterate = cp.Iterate
+	}
+
+	return g.GenomeSets[cp.BestIndex], cp.Iterate, true
+}
+
+func (g *GenomeSetHandler) checkpoint(iterate int, best *genomeset.GenomeSet) {
+	if !g.Op.needCheckpoint(iterate) {
+		return
+	}
+
+	cp := Checkpoint{
+		Mode:       "GenomeSet",
+		Seed:       g.Op.Seed,
+		Iterate:    iterate,
+		Best:       *g.makeRecord(best),
+		GenomeSets: make([]genomeset.Snapshot, len(g.GenomeSets)),
+	}
+	for i, v := range g.GenomeSets {
+		if v == best {
+			cp.BestIndex = i
+		}
+		cp.GenomeSets[i] = v.Snapshot()
+	}
+
+	g.Op.saveCheckpoint(cp)
+}
+
 func (g *GenomeSetHandler) Evolve() *Record {
-	best, accomplished := g.Op.Perf2(0, g.GenomeSets) // Preserve the best genome
-	if accomplished {
-		return g.makeRecord(best)
+	best, start, ok := g.resume()
+	if ok && g.Op.multiObjective() {
+		best = g.survive()
+	}
+
+	if !ok {
+		var finished bool
+		best, finished = g.Op.evaluateGenomeSets(0, g.GenomeSets) // Preserve the best genome
+		if !finished {
+			return g.makeRecord(best)
+		}
+		if g.Op.multiObjective() {
+			best = g.survive()
+		}
+		if best.Score >= g.Op.ExpectFitness {
+			g.Op.archiveCheckpoint()
+			return g.makeRecord(best)
+		}
+		g.checkpoint(0, best)
 	}
 
-	for i := 1; i <= g.Op.Conf.Iteration; i++ {
+	for i := start + 1; i <= g.Op.Conf.Iteration; i++ {
 		g.generate(i, best)
 
-		best, accomplished = g.Op.Perf2(i, g.GenomeSets) // Preserve the best genome
-		if accomplished {
+		var finished bool
+		best, finished = g.Op.evaluateGenomeSets(i, g.GenomeSets) // Preserve the best genome
+		if !finished {
+			// 中断时保留上一代的断点，下次从断点恢复
 			return g.makeRecord(best)
 		}
+		if g.Op.multiObjective() {
+			best = g.survive()
+		}
+		if best.Score >= g.Op.ExpectFitness {
+			// 达到满意预期，训练完成
+			break
+		}
+		g.checkpoint(i, best)
 	}
+	g.Op.archiveCheckpoint()
 
 	return g.makeRecord(best)
 }
@@ -1,7 +1,11 @@
 package model
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"os"
+	"time"
 
 	functions2 "github.com/wonderstone/QuantKit/modelgene/gep/functions"
 	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
@@ -47,9 +51,20 @@ type TrainInfo struct {
 }
 
 type GepRecord struct {
-	Mode  string     `yaml:"mode"`
-	Score float64    `yaml:"score"`
-	KES   [][]string `yaml:"kes"`
+	Mode       string        `yaml:"mode"`
+	Score      float64       `yaml:"score"`
+	Seed       int64         `yaml:"seed,omitempty"` // 训练使用的随机种子
+	KES        [][]string    `yaml:"kes"`
+	Inputs     []string      `yaml:"inputs,omitempty"`     // 训练使用的指标，顺序与表达式中的 d0, d1, ... 一致
+	Objectives []string      `yaml:"objectives,omitempty"` // 多目标训练的目标，与前沿中目标值的顺序一致
+	Front      []FrontRecord `yaml:"front,omitempty"`      // 多目标训练得到的帕累托前沿
+}
+
+// FrontRecord 帕累托前沿中的一个个体
+type FrontRecord struct {
+	Score      float64    `yaml:"score"`
+	Objectives []float64  `yaml:"objectives"`
+	KES        [][]string `yaml:"kes"`
 }
 
 type Record struct {
@@ -70,14 +85,20 @@ type Op struct {
 	Record                 Record
 	NumTerminal            int
 	FuncType               functions2.FuncType
-	Indicator2FormulaIndex map[string]int // 指标名称到公式索引的映射
+	Indicator2FormulaIndex map[string]int     // 指标名称到公式索引的映射
+	Inputs                 []string           // 训练使用的指标，写入训练记录供导出公式使用
+	Seed                   int64              // 随机种子，未配置时使用当前时间
+	CheckpointFile         string             // 训练断点文件，为空表示不保存断点
+	Objectives             []config.Objective // 多目标训练的目标，为空表示单目标训练
+	ExpectFitness          float64            // 满意预期，最优得分达到即终止训练
+	Context                context.Context    // 训练的上下文，取消时中断训练
 }
 
 type WithOption func(op *Op)
 
-// PerformanceFunc 优化函数，返回最优基因组，以及是否达到预期
-type PerformanceFunc func(int, []*genome.Genome) (g *genome.Genome, accomplished bool)
-type PerformanceFunc2 func(int, []*genomeset.GenomeSet) (g *genomeset.GenomeSet, accomplished bool)
+// PerformanceFunc 评估单个基因组，返回得分和各训练目标的值，训练时由工作池并发调用
+type PerformanceFunc func(*genome.Genome) (score float64, objectives []float64)
+type PerformanceFunc2 func(*genomeset.GenomeSet) (score float64, objectives []float64)
 
 type GenomeFunc func(*genome.Genome)
 type GenomeSetFunc func(*genomeset.GenomeSet)
@@ -106,6 +127,20 @@ func WithGenomeSet(f GenomeSetFunc) WithOption {
 	}
 }
 
+// WithCheckpoint 训练断点文件，文件存在时从断点继续训练，并按配置的间隔保存断点
+func WithCheckpoint(file string) WithOption {
+	return func(op *Op) {
+		op.CheckpointFile = file
+	}
+}
+
+// WithObjectives 多目标训练的目标，配置后按非支配排序和拥挤距离选择，并输出帕累托前沿
+func WithObjectives(objectives []config.Objective) WithOption {
+	return func(op *Op) {
+		op.Objectives = objectives
+	}
+}
+
 func WithIndicator2FormulaIndex(m map[string]int) WithOption {
 	return func(op *Op) {
 		op.Indicator2FormulaIndex = m
@@ -114,21 +149,38 @@ func WithIndicator2FormulaIndex(m map[string]int) WithOption {
 
 func WithKarvaExpressionFile(f string) WithOption {
 	return func(op *Op) {
-		rec := Record{
-			Gep: GepRecord{
-				KES: [][]string{},
-			},
-		}
-		content, err := os.ReadFile(f)
+		rec, err := ReadRecord(f)
 		if err != nil {
-			config.ErrorF("读取karva表达式文件失败: %v", err)
+			config.ErrorF(err.Error())
 		}
 
-		err = yaml.Unmarshal(content, &rec)
-		if err != nil {
-			config.ErrorF("解析karva表达式文件失败: %v", err)
-		}
+		op.Record = rec
+	}
+}
+
+// ReadRecord 读取训练输出的karva表达式文件
+func ReadRecord(f string) (Record, error) {
+	rec := Record{
+		Gep: GepRecord{
+			KES: [][]string{},
+		},
+	}
+	content, err := os.ReadFile(f)
+	if err != nil {
+		return rec, fmt.Errorf("读取karva表达式文件失败: %w", err)
+	}
 
+	err = yaml.Unmarshal(content, &rec)
+	if err != nil {
+		return rec, fmt.Errorf("解析karva表达式文件失败: %w", err)
+	}
+
+	return rec, nil
+}
+
+// WithRecord 直接使用已有的模型记录，用于滚动训练中将上一段训练结果用于样本外验证
+func WithRecord(rec Record) WithOption {
+	return func(op *Op) {
 		op.Record = rec
 	}
 }
@@ -145,6 +197,27 @@ func WithPerformanceSet(perf PerformanceFunc2) WithOption {
 	}
 }
 
+// WithExpectFitness 满意预期，最优得分达到即终止训练，未设置时训练到最大迭代次数
+func WithExpectFitness(fitness float64) WithOption {
+	return func(op *Op) {
+		op.ExpectFitness = fitness
+	}
+}
+
+// WithContext 训练的上下文，取消时等待正在评估的个体完成后中断训练
+func WithContext(ctx context.Context) WithOption {
+	return func(op *Op) {
+		op.Context = ctx
+	}
+}
+
+// WithInputs 训练使用的指标，顺序与表达式中的 d0, d1, ... 一致
+func WithInputs(inputs []string) WithOption {
+	return func(op *Op) {
+		op.Inputs = inputs
+	}
+}
+
 func WithNumTerminal(num int) WithOption {
 	return func(op *Op) {
 		op.NumTerminal = num
@@ -159,7 +232,9 @@ func WithFuncType(funcType functions2.FuncType) WithOption {
 
 func NewOp(option ...WithOption) *Op {
 	op := &Op{
-		FuncType: functions2.Float64,
+		FuncType:      functions2.Float64,
+		ExpectFitness: math.Inf(1),
+		Context:       context.Background(),
 	}
 
 	for _, opt := range option {
@@ -178,6 +253,11 @@ func NewOp(option ...WithOption) *Op {
 		config.ErrorF("未指定模型参数数量，此参数由指标数量决定")
 	}
 
+	op.Seed = op.Conf.Seed
+	if op.Seed == 0 {
+		op.Seed = time.Now().UnixNano()
+	}
+
 	return op
 }
 
@@ -25,9 +25,12 @@ type Generation struct {
 	Genomes     []*genome.Genome
 	Funcs       []gene.FuncWeight
 	ScoringFunc genome.ScoringFunc
+
+	rng *rand.Rand
 }
 
 // New creates a new random generation of the model.
+// rng is the random source used for the whole run; a seeded source makes the run reproducible.
 // fs is a slice of function weights.
 // funcType is the underlying function type (no generics).
 // numGenomes is the number of genomes to use to populate this generation of the model.
@@ -38,20 +41,21 @@ type Generation struct {
 // linkFunc is the linking function used to combine the genes within a genome.
 // sf is the scoring (or fitness) function.
 func New(
-	fs []gene.FuncWeight, funcType functions.FuncType,
+	rng *rand.Rand, fs []gene.FuncWeight, funcType functions.FuncType,
 	numGenomes, headSize, numGenesPerGenome, numTerminals, numConstants int, linkFunc string, sf genome.ScoringFunc,
 ) *Generation {
 	r := &Generation{
 		Genomes:     make([]*genome.Genome, numGenomes),
 		Funcs:       fs,
 		ScoringFunc: sf,
+		rng:         rng,
 	}
 	n := maxArity(fs, funcType)
 	tailSize := headSize*(n-1) + 1
 	for i := range r.Genomes {
 		genes := make([]*gene.Gene, numGenesPerGenome)
 		for j := range genes {
-			genes[j] = gene.RandomNew(headSize, tailSize, numTerminals, numConstants, fs, funcType)
+			genes[j] = gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, fs, funcType)
 		}
 		r.Genomes[i] = genome.New(genes, linkFunc)
 	}
@@ -132,10 +136,10 @@ func (g *Generation) replication() {
 
 	maxWeight = maxWeight - minWeight + 1.0
 	result := make([]*genome.Genome, 0, len(g.Genomes))
-	index := rand.Intn(len(g.Genomes))
+	index := g.rng.Intn(len(g.Genomes))
 	beta := 0.0
 	for i := 0; i < len(g.Genomes); i++ {
-		beta += rand.Float64() * 2.0 * maxWeight
+		beta += g.rng.Float64() * 2.0 * maxWeight
 		for beta > g.Genomes[index].Score-minWeight+1.0 {
 			beta -= g.Genomes[index].Score - minWeight + 1.0
 			index = (index + 1) % len(g.Genomes)
@@ -151,13 +155,13 @@ func (g *Generation) mutation(pm float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pm))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Determine the total number of mutations to perform within the genome
 		// Do not change this part, respect the init coder
-		numMutations := 1 + rand.Intn(2)
+		numMutations := 1 + g.rng.Intn(2)
 		// fmt.Printf("\nMutating genome #%v %v times, before:\n%v\n", genomeNum, numMutations, genome)
-		gen.Mutate(numMutations)
+		gen.Mutate(g.rng, numMutations)
 		// fmt.Printf("after:\n%v\n", genome)
 	}
 }
@@ -167,10 +171,10 @@ func (g *Generation) isTransposition(pis float64, gl int) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pis))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the isTransposition within the genome
-		gen.IsTransposition(gl)
+		gen.IsTransposition(g.rng, gl)
 
 	}
 }
@@ -180,10 +184,10 @@ func (g *Generation) risTransposition(pris float64, gl int) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pris))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the risTransposition within the genome
-		gen.RisTransposition(gl)
+		gen.RisTransposition(g.rng, gl)
 	}
 }
 
@@ -192,10 +196,10 @@ func (g *Generation) geneTransposition(pgene float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pgene))
 	for i := 0; i < numGenomes; i++ {
 		// Pick a random genome
-		genomeNum := rand.Intn(len(g.Genomes))
+		genomeNum := g.rng.Intn(len(g.Genomes))
 		gen := g.Genomes[genomeNum]
 		// Perform the geneTransposition within the genome
-		gen.GeneTransposition()
+		gen.GeneTransposition(g.rng)
 	}
 }
 
@@ -204,10 +208,10 @@ func (g *Generation) onePointRecombination(p1p float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * p1p))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -215,7 +219,7 @@ func (g *Generation) onePointRecombination(p1p float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the onePointRecombination within the genome
-		gen1.OnePointRecombination(gen2)
+		gen1.OnePointRecombination(g.rng, gen2)
 	}
 }
 
@@ -224,10 +228,10 @@ func (g *Generation) twoPointRecombination(p2p float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * p2p))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -235,7 +239,7 @@ func (g *Generation) twoPointRecombination(p2p float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the twoPointRecombination within the genome
-		gen1.TwoPointRecombination(gen2)
+		gen1.TwoPointRecombination(g.rng, gen2)
 	}
 }
 
@@ -244,10 +248,10 @@ func (g *Generation) geneRecombination(pr float64) {
 	numGenomes := int(math.Ceil(float64(len(g.Genomes)) * pr))
 	for i := 0; i < numGenomes; i++ {
 		// Pick two different random genomes
-		genomeNum1 := rand.Intn(len(g.Genomes))
+		genomeNum1 := g.rng.Intn(len(g.Genomes))
 		var genomeNum2 int
 		for {
-			genomeNum2 = rand.Intn(len(g.Genomes))
+			genomeNum2 = g.rng.Intn(len(g.Genomes))
 			if genomeNum1 != genomeNum2 {
 				break
 			}
@@ -255,7 +259,7 @@ func (g *Generation) geneRecombination(pr float64) {
 		gen1 := g.Genomes[genomeNum1]
 		gen2 := g.Genomes[genomeNum2]
 		// Perform the geneRecombination within the genome
-		gen1.GeneRecombination(gen2)
+		gen1.GeneRecombination(g.rng, gen2)
 	}
 }
 
@@ -309,10 +313,13 @@ type GenerationGS struct {
 	GenomeSets  []*genomeset.GenomeSet
 	Funcs       []gene.FuncWeight
 	ScoringFunc genomeset.ScoringFunc
+
+	rng *rand.Rand
 }
 
 // New creates a new random generation of the model.
 // fs is a slice of function weights.
+// rng is the random source used for the whole run; a seeded source makes the run reproducible.
 // funcType is the underlying function type (no generics).
 // num
 // numGenomeSets is the number of genomeset to use to populate this generation of the modelgs.
@@ -324,7 +331,7 @@ type GenerationGS struct {
 // linkFunc is the linking function used to combine the genes within a genome.
 // sf is the scoring (or fitness) function.
 func NewGS(
-	fs []gene.FuncWeight, funcType functions.FuncType,
+	rng *rand.Rand, fs []gene.FuncWeight, funcType functions.FuncType,
 	numGenomeSets, headSize, numGenomesPerGenomeSet, numGenesPerGenome, numTerminals, numConstants int, linkFunc string,
 	sf genomeset.ScoringFunc,
 ) *GenerationGS {
@@ -332,6 +339,7 @@ func NewGS(
 		GenomeSets:  make([]*genomeset.GenomeSet, numGenomeSets),
 		Funcs:       fs,
 		ScoringFunc: sf,
+		rng:         rng,
 	}
 	n := maxArity(fs, funcType)
 	tailSize := headSize*(n-1) + 1
@@ -340,7 +348,7 @@ func NewGS(
 		for j := range r.GenomeSets[i].Genomes {
 			genes := make([]*gene.Gene, numGenesPerGenome)
 			for k := range genes {
-				genes[k] = gene.RandomNew(headSize, tailSize, numTerminals, numConstants, fs, funcType)
+				genes[k] = gene.RandomNew(rng, headSize, tailSize, numTerminals, numConstants, fs, funcType)
 			}
 			r.GenomeSets[i].Genomes[j] = genome.New(genes, linkFunc)
 		}
@@ -421,10 +429,10 @@ func (gs *GenerationGS) replication() {
 
 	maxWeight = maxWeight - minWeight + 1.0
 	result := make([]*genomeset.GenomeSet, 0, len(gs.GenomeSets))
-	index := rand.Intn(len(gs.GenomeSets))
+	index := gs.rng.Intn(len(gs.GenomeSets))
 	beta := 0.0
 	for i := 0; i < len(gs.GenomeSets); i++ {
-		beta += rand.Float64() * 2.0 * maxWeight
+		beta += gs.rng.Float64() * 2.0 * maxWeight
 		for beta > gs.GenomeSets[index].Score-minWeight+1.0 {
 			beta -= gs.GenomeSets[index].Score - minWeight + 1.0
 			index = (index + 1) % len(gs.GenomeSets)
@@ -440,12 +448,12 @@ func (gs *GenerationGS) mutation(pm float64) {
 
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Determine the total number of mutations to perform within the genome
-		numMutations := 1 + rand.Intn(2)
+		numMutations := 1 + gs.rng.Intn(2)
 		// fmt.Printf("\nMutating genome #%v %v times, before:\n%v\n", genomeNum, numMutations, genome)
-		genSet.Mutate(numMutations)
+		genSet.Mutate(gs.rng, numMutations)
 		// fmt.Printf("after:\n%v\n", genome)
 	}
 }
@@ -455,10 +463,10 @@ func (gs *GenerationGS) isTransposition(pis float64, gl int) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pis))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the isTransposition within the genomeset
-		genSet.IsTransposition(gl)
+		genSet.IsTransposition(gs.rng, gl)
 	}
 }
 
@@ -467,10 +475,10 @@ func (gs *GenerationGS) risTransposition(pris float64, gl int) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pris))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the risTransposition within the genomeset
-		genSet.RisTransposition(gl)
+		genSet.RisTransposition(gs.rng, gl)
 	}
 }
 
@@ -479,10 +487,10 @@ func (gs *GenerationGS) geneTransposition(pgene float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pgene))
 	for i := 0; i < numGenomesets; i++ {
 		// Pick a random genomeSet
-		genomeSetNum := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum := gs.rng.Intn(len(gs.GenomeSets))
 		genSet := gs.GenomeSets[genomeSetNum]
 		// Perform the geneTransposition within the genomeset
-		genSet.GeneTransposition()
+		genSet.GeneTransposition(gs.rng)
 	}
 }
 
@@ -491,10 +499,10 @@ func (gs *GenerationGS) onePointRecombination(p1p float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * p1p))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -502,7 +510,7 @@ func (gs *GenerationGS) onePointRecombination(p1p float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the onePointRecombination within the genomeset
-		genSet1.OnePointRecombination(genSet2)
+		genSet1.OnePointRecombination(gs.rng, genSet2)
 	}
 }
 
@@ -511,10 +519,10 @@ func (gs *GenerationGS) twoPointRecombination(p2p float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * p2p))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -522,7 +530,7 @@ func (gs *GenerationGS) twoPointRecombination(p2p float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the twoPointRecombination within the genomeset
-		genSet1.TwoPointRecombination(genSet2)
+		genSet1.TwoPointRecombination(gs.rng, genSet2)
 	}
 }
 
@@ -531,10 +539,10 @@ func (gs *GenerationGS) geneRecombination(pr float64) {
 	numGenomesets := int(math.Ceil(float64(len(gs.GenomeSets)) * pr))
 	for i := 0; i < numGenomesets; i++ {
 		// pick two different random genomeSets
-		genomeSetNum1 := rand.Intn(len(gs.GenomeSets))
+		genomeSetNum1 := gs.rng.Intn(len(gs.GenomeSets))
 		var genomeSetNum2 int
 		for {
-			genomeSetNum2 = rand.Intn(len(gs.GenomeSets))
+			genomeSetNum2 = gs.rng.Intn(len(gs.GenomeSets))
 			if genomeSetNum1 != genomeSetNum2 {
 				break
 			}
@@ -542,7 +550,7 @@ func (gs *GenerationGS) geneRecombination(pr float64) {
 		genSet1 := gs.GenomeSets[genomeSetNum1]
 		genSet2 := gs.GenomeSets[genomeSetNum2]
 		// Perform the geneRecombination within the genomeset
-		genSet1.GeneRecombination(genSet2)
+		genSet1.GeneRecombination(gs.rng, genSet2)
 	}
 }
 
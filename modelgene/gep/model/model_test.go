@@ -5,6 +5,7 @@
 package model
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/wonderstone/QuantKit/modelgene/gep/functions"
@@ -36,7 +37,7 @@ func BenchmarkReplication(b *testing.B) {
 		{Symbol: "-", Weight: 1},
 		{Symbol: "*", Weight: 1},
 	}
-	e := New(funcs, functions.Float64, 30, 8, 4, 1, 0, "+", nil)
+	e := New(rand.New(rand.NewSource(1)), funcs, functions.Float64, 30, 8, 4, 1, 0, "+", nil)
 	for i := 0; i < b.N; i++ {
 		e.replication()
 	}
@@ -48,7 +49,7 @@ func BenchmarkMutation(b *testing.B) {
 		{Symbol: "-", Weight: 1},
 		{Symbol: "*", Weight: 1},
 	}
-	e := New(funcs, functions.Float64, 30, 8, 4, 1, 0, "+", nil)
+	e := New(rand.New(rand.NewSource(1)), funcs, functions.Float64, 30, 8, 4, 1, 0, "+", nil)
 	for i := 0; i < b.N; i++ {
 		e.mutation(0.5)
 	}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/wonderstone/QuantKit/config"
+)
+
+// + 多目标训练使用 NSGA-II 选择:
+// + 1. 父代通过二元锦标赛(等级低者优先，等级相同拥挤距离大者优先)复制出子代，再经过变异、转座和重组
+// + 2. 子代评估后与父代合并，按非支配排序逐个前沿选入下一代，最后一个前沿按拥挤距离截断
+// + 目标值统一转换为越大越优的适应度后再比较，未评估或无效的目标值视为最差
+
+// multiObjective 是否为多目标训练
+func (op *Op) multiObjective() bool {
+	return len(op.Objectives) > 0
+}
+
+// objectiveNames 训练目标的指标类型
+func (op *Op) objectiveNames() []string {
+	names := make([]string, len(op.Objectives))
+	for i, o := range op.Objectives {
+		names[i] = o.Type
+	}
+
+	return names
+}
+
+// fitness 将原始目标值按优化方向转换为越大越优的适应度
+func fitness(values []float64, objectives []config.Objective) []float64 {
+	fits := make([]float64, len(objectives))
+	for i, o := range objectives {
+		if i >= len(values) || math.IsNaN(values[i]) {
+			fits[i] = math.Inf(-1)
+			continue
+		}
+
+		fits[i] = values[i]
+		if o.Direction == config.ObjectiveMinimize {
+			fits[i] = -values[i]
+		}
+	}
+
+	return fits
+}
+
+// dominates a 是否支配 b: 所有目标不差于 b，且至少一个目标优于 b
+func dominates(a, b []float64) bool {
+	better := false
+	for i := range a {
+		if a[i] < b[i] {
+			return false
+		}
+		if a[i] > b[i] {
+			better = true
+		}
+	}
+
+	return better
+}
+
+// nonDominatedSort 快速非支配排序，返回各前沿包含的序号，前沿内保持原有顺序
+func nonDominatedSort(fits [][]float64) [][]int {
+	n := len(fits)
+	dominated := make([][]int, n) // 被 i 支配的个体
+	count := make([]int, n)       // 支配 i 的个体数量
+
+	var front []int
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+
+			if dominates(fits[i], fits[j]) {
+				dominated[i] = append(dominated[i], j)
+			} else if dominates(fits[j], fits[i]) {
+				count[i]++
+			}
+		}
+
+		if count[i] == 0 {
+			front = append(front, i)
+		}
+	}
+
+	var fronts [][]int
+	for len(front) > 0 {
+		fronts = append(fronts, front)
+
+		var next []int
+		for _, i := range front {
+			for _, j := range dominated[i] {
+				count[j]--
+				if count[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+
+		sort.Ints(next)
+		front = next
+	}
+
+	return fronts
+}
+
+// crowdingDistance 计算前沿内各个体的拥挤距离，边界个体为正无穷
+func crowdingDistance(fits [][]float64, front []int) []float64 {
+	distance := make([]float64, len(front))
+	if len(front) <= 2 {
+		for i := range distance {
+			distance[i] = math.Inf(1)
+		}
+		return distance
+	}
+
+	order := make([]int, len(front))
+	for m := range fits[front[0]] {
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return fits[front[order[i]]][m] < fits[front[order[j]]][m] })
+
+		lo, hi := fits[front[order[0]]][m], fits[front[order[len(order)-1]]][m]
+		distance[order[0]] = math.Inf(1)
+		distance[order[len(order)-1]] = math.Inf(1)
+		if !(hi-lo > 0) || math.IsInf(hi-lo, 0) {
+			continue
+		}
+
+		for i := 1; i < len(order)-1; i++ {
+			distance[order[i]] += (fits[front[order[i+1]]][m] - fits[front[order[i-1]]][m]) / (hi - lo)
+		}
+	}
+
+	return distance
+}
+
+// rankPopulation 计算种群中各个体的前沿等级和拥挤距离
+func rankPopulation(fits [][]float64) (rank []int, crowding []float64) {
+	rank = make([]int, len(fits))
+	crowding = make([]float64, len(fits))
+	for r, front := range nonDominatedSort(fits) {
+		for i, d := range crowdingDistance(fits, front) {
+			rank[front[i]] = r
+			crowding[front[i]] = d
+		}
+	}
+
+	return
+}
+
+// nsgaSelect 从合并后的种群中按前沿顺序选出 n 个个体的序号，最后一个前沿按拥挤距离从大到小截断
+func nsgaSelect(fits [][]float64, n int) []int {
+	selected := make([]int, 0, n)
+	for _, front := range nonDominatedSort(fits) {
+		if len(selected)+len(front) <= n {
+			selected = append(selected, front...)
+			continue
+		}
+
+		distance := crowdingDistance(fits, front)
+		order := make([]int, len(front))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return distance[order[i]] > distance[order[j]] })
+
+		for _, i := range order[:n-len(selected)] {
+			selected = append(selected, front[i])
+		}
+		break
+	}
+
+	return selected
+}
+
+// tournament 二元锦标赛，返回胜出个体的序号
+func tournament(rng *rand.Rand, rank []int, crowding []float64) int {
+	a, b := rng.Intn(len(rank)), rng.Intn(len(rank))
+	if rank[a] != rank[b] {
+		if rank[a] < rank[b] {
+			return a
+		}
+		return b
+	}
+
+	if crowding[b] > crowding[a] {
+		return b
+	}
+	return a
+}
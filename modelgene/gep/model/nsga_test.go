@@ -0,0 +1,83 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
+)
+
+func TestNonDominatedSort(t *testing.T) {
+	objectives := []config.Objective{
+		{Type: "annualized-return", Direction: config.ObjectiveMaximize},
+		{Type: "max-drawdown", Direction: config.ObjectiveMinimize},
+	}
+
+	values := [][]float64{
+		{0.3, 0.4}, // 0: 高收益高回撤
+		{0.1, 0.1}, // 1: 低收益低回撤
+		{0.2, 0.2}, // 2: 折中
+		{0.1, 0.3}, // 3: 被 1、2 支配
+		{0.0, 0.5}, // 4: 被所有个体支配
+	}
+	fits := make([][]float64, len(values))
+	for i, v := range values {
+		fits[i] = fitness(v, objectives)
+	}
+
+	fronts := nonDominatedSort(fits)
+	if want := [][]int{{0, 1, 2}, {3}, {4}}; !reflect.DeepEqual(fronts, want) {
+		t.Errorf("非支配排序错误: %v != %v", fronts, want)
+	}
+
+	// 折中个体位于前沿内部，拥挤距离有限，边界个体为正无穷
+	distance := crowdingDistance(fits, fronts[0])
+	if !math.IsInf(distance[0], 1) || !math.IsInf(distance[1], 1) || math.IsInf(distance[2], 1) {
+		t.Errorf("拥挤距离错误: %v", distance)
+	}
+
+	if selected := nsgaSelect(fits, 2); !reflect.DeepEqual(selected, []int{0, 1}) {
+		t.Errorf("按拥挤距离截断错误: %v", selected)
+	}
+
+	// 未评估的个体视为最差
+	if dominates(fitness(nil, objectives), fits[4]) || !dominates(fits[4], fitness(nil, objectives)) {
+		t.Errorf("未评估个体的适应度错误")
+	}
+}
+
+func TestEvolveFront(t *testing.T) {
+	objectives := []config.Objective{
+		{Type: "a", Direction: config.ObjectiveMaximize},
+		{Type: "b", Direction: config.ObjectiveMinimize},
+	}
+
+	perf := func(g *genome.Genome) (float64, []float64) {
+		score, _ := testPerf(g)
+		a, b := g.EvalMath([]float64{1.5, 2.5}), g.EvalMath([]float64{-1, 3})
+		return score, []float64{a, math.Abs(b)}
+	}
+
+	rec := evolve(10, WithObjectives(objectives), WithPerformance(perf))
+	if len(rec.Gep.Front) == 0 {
+		t.Fatalf("没有输出帕累托前沿")
+	}
+
+	if !reflect.DeepEqual(rec.Gep.Objectives, []string{"a", "b"}) {
+		t.Errorf("目标名称错误: %v", rec.Gep.Objectives)
+	}
+
+	fits := make([][]float64, len(rec.Gep.Front))
+	for i, f := range rec.Gep.Front {
+		fits[i] = fitness(f.Objectives, objectives)
+	}
+	for i := range fits {
+		for j := range fits {
+			if dominates(fits[i], fits[j]) {
+				t.Errorf("前沿中的个体 %d 支配了个体 %d", i, j)
+			}
+		}
+	}
+}
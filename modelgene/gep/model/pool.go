@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genome"
+	"github.com/wonderstone/QuantKit/modelgene/gep/genomeset"
+)
+
+// evaluate 使用有界的工作池评估种群，Workers 个协程从任务通道依次领取个体
+// 得分按序号写入，与评估完成的先后无关，保证同一种群的评估结果确定
+// 中断时不再派发新的个体，等待已领取的个体评估完成后返回false
+func (op *Op) evaluate(n int, score func(index int)) bool {
+	ctx, stop := signal.NotifyContext(op.Context, os.Interrupt, os.Kill)
+	defer stop()
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < min(max(op.Conf.Workers, 1), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				score(index)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err() == nil
+}
+
+// progress 输出训练进度
+func (op *Op) progress(iterate int) {
+	config.StatusLog(
+		config.RunningEvent, 95*float64(iterate)/float64(max(op.Conf.Iteration, 1)),
+		map[string]any{"msg": fmt.Sprintf("迭代: %d / %d", iterate, op.Conf.Iteration)},
+	)
+}
+
+// evaluateGenomes 评估全部基因组，返回最优基因组，以及评估是否完成(false表示被中断)
+func (op *Op) evaluateGenomes(iterate int, gs []*genome.Genome) (*genome.Genome, bool) {
+	op.progress(iterate)
+	if !op.evaluate(len(gs), func(i int) { gs[i].Score, gs[i].Objectives = op.Perf(gs[i]) }) {
+		config.InfoF("中断，当前最优基因组序号: %d, 得分: %f, Exp: %s", 1, gs[0].Score, gs[0].StringSlice())
+		return gs[0], false
+	}
+
+	best := 0
+	for i := range gs {
+		if gs[i].Score > gs[best].Score {
+			best = i
+		}
+	}
+
+	config.InfoF("第%d代最优基因组序号: %d, 得分: %f, Exp: %s", iterate, best+1, gs[best].Score, gs[best].StringSlice())
+	return gs[best], true
+}
+
+// evaluateGenomeSets 评估全部基因组集合，返回最优基因组集合，以及评估是否完成(false表示被中断)
+func (op *Op) evaluateGenomeSets(iterate int, gs []*genomeset.GenomeSet) (*genomeset.GenomeSet, bool) {
+	op.progress(iterate)
+	if !op.evaluate(len(gs), func(i int) { gs[i].Score, gs[i].Objectives = op.Perf2(gs[i]) }) {
+		config.InfoF("中断，当前最优基因组序号: %d, 得分: %f, Exp: %s", 1, gs[0].Score, gs[0].StringSlice())
+		return gs[0], false
+	}
+
+	best := 0
+	for i := range gs {
+		if gs[i].Score > gs[best].Score {
+			best = i
+		}
+	}
+
+	config.InfoF("第%d代最优基因组序号: %d, 得分: %f, Exp: %s", iterate, best+1, gs[best].Score, gs[best].StringSlice())
+	return gs[best], true
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+)
+
+// 并行评估的结果与串行评估一致
+func TestEvolveWorkers(t *testing.T) {
+	want := evolve(10)
+	got := evolve(10, func(op *Op) { op.Conf.Workers = 4 })
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("并行评估的训练结果不一致: %v != %v", want.Gep, got.Gep)
+	}
+}
+
+// 中断后不再派发新的个体，已领取的个体评估完成后返回
+func TestEvaluateInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &Op{Conf: &config.GepModel{Workers: 2}, Context: ctx}
+
+	var evaluated, running atomic.Int64
+	ok := op.evaluate(
+		100, func(index int) {
+			running.Add(1)
+			defer running.Add(-1)
+			if evaluated.Add(1) == 10 {
+				cancel()
+			}
+		},
+	)
+
+	require.False(t, ok)
+	require.Zero(t, running.Load())
+	require.Less(t, evaluated.Load(), int64(100))
+}
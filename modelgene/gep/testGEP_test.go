@@ -80,7 +80,7 @@ func TestGEPSymbol(t *testing.T) {
 		{Symbol: "/", Weight: 1},
 	}
 	numIn := len(srTests1[0].in)
-	e := model.New(funcs, functions.Float64, 50, 4, 2, numIn, 0, "+", validateFunc)
+	e := model.New(rand.New(rand.NewSource(time.Now().UnixNano())), funcs, functions.Float64, 50, 4, 2, numIn, 0, "+", validateFunc)
 	s := e.Evolve(1000, 50000, 0.8, 0.5, 3, 0.5, 3, 0.5, 0.01, 0.01, 0.01)
 
 	// Write out the Go source code for the solution.
@@ -380,9 +380,8 @@ func (s *VSS) OnTick(
 		tmp := orderedmap.New[string, dataframe.StreamingRecord]()
 		for pIndicate := indicators.Oldest(); pIndicate != nil; pIndicate = pIndicate.Next() {
 
-			tmpSR := dataframe.StreamingRecord{}
-			// copy the data to tmpSR.Data
-			tmpSR.Data = append(tmpSR.Data, pIndicate.Value.Data...)
+			// copy the values and data to tmpSR
+			tmpSR := pIndicate.Value.Clone()
 			// iter pIndicate.Value.Headers Map and copy the data to tmpSR.Headers
 
 			tmpSR.Headers = make(map[string]int)
@@ -28,13 +28,31 @@ func CalcCommStock(fee config.StockFee, qty float64, price float64, direction co
 	return commission
 }
 
-// CalcCommFuture 计算期货手续费，方向为买卖方向
-func CalcCommFuture(fee config.FutureFee, qty float64, price float64, direction config.OrderDirection) float64 {
-	commission := 0.0
+// CalcCommFuture 计算期货手续费，按开平标志区分开仓、平今、平昨
+// 手续费 = 手数 * (按手数费用 + 券商按手数费用) + 成交额 * (费率 + 券商费率)
+func CalcCommFuture(
+	fee config.FutureFee, contractSize float64, qty float64, price float64, offset config.TransactionType,
+) float64 {
+	if qty == 0 {
+		return 0
+	}
 
-	// TODO 期货手续费计算
+	amt := qty * price * contractSize
+	commission := qty*fee.Broker + amt*fee.BrokerRate
 
-	return commission
+	switch offset {
+	case config.OffsetOpen:
+		commission += qty*fee.Open + amt*fee.OpenRate
+	case config.OffsetClose:
+		commission += qty*fee.CloseToday + amt*fee.CloseTodayRate
+	case config.OffsetCloseHis:
+		commission += qty*fee.ClosePrevious + amt*fee.ClosePreviousRate
+	default:
+		panic(fmt.Sprintf("未知的开平标志: %s", offset))
+	}
+
+	// 保留两位小数
+	return math2.Round(commission, 2)
 }
 
 // CalcMarketValueStock 计算股票市值，方向为多空方向
@@ -61,7 +79,7 @@ func CalcMarginFuture(
 		return qty * price * contractSize * (marginRate.Short + marginRate.Broker)
 	}
 
-	panic(fmt.Sprintf("未知的合约方向: %d", direction))
+	panic(fmt.Sprintf("未知的合约方向: %s", direction))
 }
 
 // CalcSlipPrice 计算滑点，方向为买卖方向
@@ -72,5 +90,5 @@ func CalcSlipPrice(basic config.ContractBasic, price float64, slip float64, dire
 		return price - basic.TickSize*slip
 	}
 
-	panic(fmt.Sprintf("未知的合约买卖方向: %d", direction))
+	panic(fmt.Sprintf("未知的合约买卖方向: %s", direction))
 }
@@ -3,7 +3,6 @@ package dataframe
 import (
 	"encoding/csv"
 	"errors"
-	"fmt"
 	"io"
 	"math"
 	"os"
@@ -26,17 +25,16 @@ type DataFrame struct {
 	Header        []string
 }
 
-type StreamingRecord struct {
-	Data    []string
-	Headers map[string]int
-}
-
 type RecordFunc interface {
 	Val(fieldName string, header ...map[string]int) string
 	Update(fieldName, value string, header ...map[string]int)
 }
 
 func ConvertToFloat(recordFunc RecordFunc, fieldName string, header ...map[string]int) float64 {
+	if x, ok := recordFunc.(StreamingRecord); ok {
+		return x.ConvertToFloat(fieldName)
+	}
+
 	value, err := strconv.ParseFloat(recordFunc.Val(fieldName, header...), 64)
 	if err != nil {
 		config.ErrorF("不能转换为 float64: %v", err)
@@ -45,6 +43,10 @@ func ConvertToFloat(recordFunc RecordFunc, fieldName string, header ...map[strin
 }
 
 func TryConvertToFloat(recordFunc RecordFunc, fieldName string, header ...map[string]int) (float64, error) {
+	if x, ok := recordFunc.(StreamingRecord); ok {
+		return x.TryConvertToFloat(fieldName)
+	}
+
 	value, err := strconv.ParseFloat(recordFunc.Val(fieldName, header...), 64)
 	if err != nil {
 		return 0, err
@@ -68,49 +70,6 @@ func TryConvertToInt(recordFunc RecordFunc, fieldName string, header ...map[stri
 	return value, nil
 }
 
-func (x StreamingRecord) Update(fieldName, value string, header ...map[string]int) {
-	if _, ok := x.Headers[fieldName]; !ok {
-		config.ErrorF("提供的字段 %s 不是数据帧中的有效字段。", fieldName)
-	}
-
-	x.Data[x.Headers[fieldName]] = value
-}
-
-// Return the value of the specified field.
-func (x StreamingRecord) Val(fieldName string, header ...map[string]int) string {
-	if _, ok := x.Headers[fieldName]; !ok {
-		panic(fmt.Errorf("提供的指标 %s 不存在", fieldName))
-	}
-	return x.Data[x.Headers[fieldName]]
-}
-
-// Converts the value from a string to float64
-func (x StreamingRecord) ConvertToFloat(fieldName string) float64 {
-	value, err := strconv.ParseFloat(x.Val(fieldName), 64)
-	if err != nil {
-		config.ErrorF("不能转换为 float64: %v", err)
-	}
-	return value
-}
-
-// Converts the value from a string to float64
-func (x StreamingRecord) TryConvertToFloat(fieldName string) (float64, error) {
-	value, err := strconv.ParseFloat(x.Val(fieldName), 64)
-	if err != nil {
-		return 0, err
-	}
-	return value, nil
-}
-
-// Converts the value from a string to int64
-func (x StreamingRecord) ConvertToInt(fieldName string) int64 {
-	value, err := strconv.ParseInt(x.Val(fieldName), 0, 64)
-	if err != nil {
-		config.ErrorF("不能转换为 int64: %v", err)
-	}
-	return value
-}
-
 // Generate a new empty DataFrame.
 func CreateNewDataFrame(headers []string) DataFrame {
 	var myRecords []Record
@@ -240,14 +199,7 @@ func Stream(dir, fileName string, c chan StreamingRecord) {
 		} else if err != nil {
 			config.ErrorF("读取文件失败: %v", err)
 		}
-		// Create new Record
-		x := StreamingRecord{Headers: headers}
-
-		// Loop over records and add to Data field of Record struct
-		for _, r := range record {
-			x.Data = append(x.Data, r)
-		}
-		c <- x
+		c <- NewStreamingRecord(record, headers)
 	}
 
 	return
@@ -65,6 +65,58 @@ func TestStreamConvertToFloat(t *testing.T) {
 	}
 }
 
+func TestStreamingRecordTyped(t *testing.T) {
+	headers := map[string]int{"Date": 0, "Close": 1, "MA": 2}
+	row := NewStreamingRecord([]string{"2023.01.03", "10.5", ""}, headers)
+
+	if row.Float("Close") != 10.5 {
+		t.Error("Close did not match.")
+	}
+	if _, ok := row.Get("MA"); ok {
+		t.Error("Empty value should be missing.")
+	}
+	if _, ok := row.Get("Volume"); ok {
+		t.Error("Unknown field should be missing.")
+	}
+	if row.Val("Date") != "2023.01.03" {
+		t.Error("Date text did not match.")
+	}
+
+	// 复制后修改不影响原记录
+	c := row.Clone()
+	c.Set("Close", 11)
+	c.Update("MA", "10.75")
+	if row.Float("Close") != 10.5 || !math.IsNaN(row.Float("MA")) {
+		t.Error("Clone shares values with the original record.")
+	}
+	if c.Val("Close") != "11" || c.Float("MA") != 10.75 {
+		t.Error("Set or Update did not change the cloned record.")
+	}
+
+	// 只有文本的记录仍可按数值读取
+	legacy := StreamingRecord{Data: []string{"2023.01.03", "9.5", "1"}, Headers: headers}
+	if legacy.Float("Close") != 9.5 || ConvertToFloat(legacy, "MA") != 1 {
+		t.Error("Text only record did not convert.")
+	}
+
+	// Set 写入缺失值后按字符串读取为空
+	c.Set("Close", math.NaN())
+	if _, ok := c.Get("Close"); ok || c.Val("Close") != "" {
+		t.Error("Set NaN did not clear the value.")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() { _ = row.Float("Close") })
+	if allocs != 0 {
+		t.Errorf("Float allocated %v times.", allocs)
+	}
+
+	// 写入数值不做字符串转换
+	allocs = testing.AllocsPerRun(100, func() { c.Set("MA", 10.25) })
+	if allocs != 0 {
+		t.Errorf("Set allocated %v times.", allocs)
+	}
+}
+
 func TestDynamicMetrics(t *testing.T) {
 	// Create DataFrame
 	columns := []string{"Value"}
@@ -0,0 +1,209 @@
+package dataframe
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/wonderstone/QuantKit/config"
+)
+
+// StreamingRecord 一行行情或指标数据
+// Values 按列保存解析后的数值，在构建时解析一次，空值和无法解析的文本(如日期、时间)记为NaN
+// Data 保留原始文本，用于日期等文本列以及按字符串访问的旧代码；Values 为空时数值访问退回到解析 Data
+// Set 写入的数值只保存在 Values 中，Data 对应位置清空，按字符串读取时再由数值生成
+type StreamingRecord struct {
+	Values  []float64
+	Data    []string
+	Headers map[string]int
+}
+
+// NewStreamingRecord 由一行文本构建记录，数值只在此处解析一次
+func NewStreamingRecord(data []string, headers map[string]int) StreamingRecord {
+	x := StreamingRecord{
+		Values:  make([]float64, len(data)),
+		Data:    data,
+		Headers: headers,
+	}
+
+	for i, v := range data {
+		x.Values[i] = parseFloat(v)
+	}
+
+	return x
+}
+
+// NewEmptyStreamingRecord 新建指定列的空记录，所有数值为NaN
+func NewEmptyStreamingRecord(headers map[string]int) StreamingRecord {
+	x := StreamingRecord{
+		Values:  make([]float64, len(headers)),
+		Data:    make([]string, len(headers)),
+		Headers: headers,
+	}
+
+	for i := range x.Values {
+		x.Values[i] = math.NaN()
+	}
+
+	return x
+}
+
+func parseFloat(s string) float64 {
+	if s == "" {
+		return math.NaN()
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+
+	return v
+}
+
+func (x StreamingRecord) index(fieldName string) int {
+	i, ok := x.Headers[fieldName]
+	if !ok {
+		panic(fmt.Errorf("提供的指标 %s 不存在", fieldName))
+	}
+
+	return i
+}
+
+// At 按列序号获取数值
+func (x StreamingRecord) At(i int) float64 {
+	if x.Values == nil {
+		return parseFloat(x.Data[i])
+	}
+
+	return x.Values[i]
+}
+
+// Float 获取数值，缺失值为NaN，字段不存在时panic；不解析字符串也不分配内存
+func (x StreamingRecord) Float(fieldName string) float64 {
+	return x.At(x.index(fieldName))
+}
+
+// Get 获取数值，字段不存在或值缺失时返回false
+func (x StreamingRecord) Get(fieldName string) (float64, bool) {
+	i, ok := x.Headers[fieldName]
+	if !ok {
+		return 0, false
+	}
+
+	v := x.At(i)
+	return v, !math.IsNaN(v)
+}
+
+// Suspended 是否为停牌时补齐的K线
+func (x StreamingRecord) Suspended() bool {
+	v, ok := x.Get(config.SuspendedField)
+	return ok && v != 0
+}
+
+// Set 设置数值，不做字符串转换，原始文本在按字符串读取时由数值生成
+func (x StreamingRecord) Set(fieldName string, value float64) {
+	i, ok := x.Headers[fieldName]
+	if !ok {
+		config.ErrorF("提供的字段 %s 不是数据帧中的有效字段。", fieldName)
+	}
+
+	if x.Values == nil {
+		x.Data[i] = formatFloat(value)
+		return
+	}
+
+	x.Values[i] = value
+	if x.Data != nil {
+		x.Data[i] = ""
+	}
+}
+
+// Copy 从另一条记录复制同名字段的数值和文本，不经过字符串转换
+func (x StreamingRecord) Copy(fieldName string, src StreamingRecord) {
+	i, j := x.index(fieldName), src.index(fieldName)
+	if x.Values != nil {
+		x.Values[i] = src.At(j)
+	}
+
+	if x.Data != nil && src.Data != nil {
+		x.Data[i] = src.Data[j]
+	}
+}
+
+// Clone 深拷贝数值和文本，表头共享
+func (x StreamingRecord) Clone() StreamingRecord {
+	c := StreamingRecord{Headers: x.Headers}
+	if x.Values != nil {
+		c.Values = append([]float64(nil), x.Values...)
+	}
+
+	if x.Data != nil {
+		c.Data = append([]string(nil), x.Data...)
+	}
+
+	return c
+}
+
+func (x StreamingRecord) Update(fieldName, value string, header ...map[string]int) {
+	if _, ok := x.Headers[fieldName]; !ok {
+		config.ErrorF("提供的字段 %s 不是数据帧中的有效字段。", fieldName)
+	}
+
+	i := x.Headers[fieldName]
+	if x.Values != nil {
+		x.Values[i] = parseFloat(value)
+	}
+
+	if x.Data != nil {
+		x.Data[i] = value
+	}
+}
+
+// Return the value of the specified field.
+// 原始文本为空时由数值生成(Set 写入的数值)
+func (x StreamingRecord) Val(fieldName string, header ...map[string]int) string {
+	i := x.index(fieldName)
+	if x.Values == nil || (x.Data != nil && x.Data[i] != "") {
+		return x.Data[i]
+	}
+
+	return formatFloat(x.Values[i])
+}
+
+func formatFloat(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Converts the value from a string to float64
+func (x StreamingRecord) ConvertToFloat(fieldName string) float64 {
+	value, err := x.TryConvertToFloat(fieldName)
+	if err != nil {
+		config.ErrorF("不能转换为 float64: %v", err)
+	}
+	return value
+}
+
+// Converts the value from a string to float64
+// 只有数值为NaN时才解析原始文本，以区分缺失值和文本"NaN"
+func (x StreamingRecord) TryConvertToFloat(fieldName string) (float64, error) {
+	v := x.Float(fieldName)
+	if !math.IsNaN(v) {
+		return v, nil
+	}
+
+	return strconv.ParseFloat(x.Val(fieldName), 64)
+}
+
+// Converts the value from a string to int64
+func (x StreamingRecord) ConvertToInt(fieldName string) int64 {
+	value, err := strconv.ParseInt(x.Val(fieldName), 0, 64)
+	if err != nil {
+		config.ErrorF("不能转换为 int64: %v", err)
+	}
+	return value
+}
@@ -23,4 +23,31 @@ const (
 	R2               IndicateType = "r2"                // R2
 	AlphaBetaRatio   IndicateType = "alpha-beta-ratio"  // alpha-beta比率
 	GainLossRatio    IndicateType = "gain-loss-ratio"   // 盈亏比
+	Turnover         IndicateType = "turnover"          // 年化换手率
+	AvgHoldingDays   IndicateType = "avg-holding-days"  // 平均持仓交易日数
 )
+
+// IndicateTypes 全部性能指标
+var IndicateTypes = []IndicateType{
+	TotalReturn, AnnualizedReturn, MaxDrawdown, SharpeRatio, SortinoRatio, CalmarRatio, WinRate, ProfitFactor,
+	Alpha, Beta, Volatility, InformationRatio, TrackingError, TreynorRatio, SterlingRatio, DownsideRisk,
+	UpsidePotential, R2, AlphaBetaRatio, GainLossRatio, Turnover, AvgHoldingDays,
+}
+
+// NeedBenchmark 是否为相对基准的指标
+func (t IndicateType) NeedBenchmark() bool {
+	switch t {
+	case Alpha, Beta, InformationRatio, TrackingError, TreynorRatio, R2, AlphaBetaRatio:
+		return true
+	}
+	return false
+}
+
+// NeedOrders 是否为基于交易记录的指标
+func (t IndicateType) NeedOrders() bool {
+	switch t {
+	case WinRate, ProfitFactor, GainLossRatio, Turnover, AvgHoldingDays:
+		return true
+	}
+	return false
+}
@@ -42,3 +42,66 @@ type ErrInvalidRunMode struct{ Mode config.Mode }
 func (e ErrInvalidRunMode) Error() string {
 	return fmt.Sprintf("无效的运行模式: %v", e.Mode)
 }
+
+type ErrPriceLimit struct {
+	Price float64
+	Lower float64
+	Upper float64
+}
+
+func (e ErrPriceLimit) Error() string {
+	return fmt.Sprintf("委托价格 %f 超出涨跌停范围 [%f, %f]", e.Price, e.Lower, e.Upper)
+}
+
+type ErrInsufficientMargin struct {
+	Need float64
+	Have float64
+}
+
+func (e ErrInsufficientMargin) Error() string {
+	return fmt.Sprintf("保证金可用余额不足, 需要 %f, 可用 %f", e.Need, e.Have)
+}
+
+type ErrNotBorrowable struct{ InstID string }
+
+func (e ErrNotBorrowable) Error() string {
+	return fmt.Sprintf("标的 %s 不在可融券名单中", e.InstID)
+}
+
+type ErrRiskLimit struct {
+	Rule  string
+	Value float64
+	Limit float64
+}
+
+func (e ErrRiskLimit) Error() string {
+	return fmt.Sprintf("风控检查未通过: %s %f 超过上限 %f", e.Rule, e.Value, e.Limit)
+}
+
+type ErrRestricted struct{ InstID string }
+
+func (e ErrRestricted) Error() string {
+	return fmt.Sprintf("风控检查未通过: 标的 %s 在禁止交易名单中", e.InstID)
+}
+
+type ErrHalted struct{ Reason string }
+
+func (e ErrHalted) Error() string {
+	return fmt.Sprintf("风控检查未通过: 策略已熔断停止交易, %s", e.Reason)
+}
+
+type ErrOrderQty struct {
+	Qty       float64
+	Min       float64
+	Increment float64
+}
+
+func (e ErrOrderQty) Error() string {
+	return fmt.Sprintf("委托数量 %f 不符合交易规则, 最小下单量 %f, 递增单位 %f", e.Qty, e.Min, e.Increment)
+}
+
+type ErrSuspended struct{ InstID string }
+
+func (e ErrSuspended) Error() string {
+	return fmt.Sprintf("标的 %s 停牌, 无法交易", e.InstID)
+}
@@ -1,6 +1,10 @@
 package recorder
 
-import "github.com/wonderstone/QuantKit/config"
+import (
+	"fmt"
+
+	"github.com/wonderstone/QuantKit/config"
+)
 
 type MemoryRecorder[Struct any] struct {
 	sliceData []Struct
@@ -13,7 +17,12 @@ func (m *MemoryRecorder[Struct]) QueryRecord(query ...WithQuery) []any {
 }
 
 func (m *MemoryRecorder[Struct]) Read(data any) error {
-	data = m.sliceData
+	records, ok := data.(*[]Struct)
+	if !ok {
+		return fmt.Errorf("MemoryRecorder读取类型不匹配: %T", data)
+	}
+
+	*records = append((*records)[:0], m.sliceData...)
 	return nil
 }
 
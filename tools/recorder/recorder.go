@@ -48,6 +48,47 @@ type OrderRecord struct {
 	Commission        float64            `csv:"commission"`                               // 手续费
 	Status            config.OrderStatus `csv:"status"`                                   // 订单状态
 	RejectReason      string             `csv:"RejectReason"`                             // 拒单原因
+	OrderType         string             `csv:"order-type"`                               // 委托类型, 条件单为原始类型
+	StopPrice         float64            `csv:"stop-price"`                               // 条件单触发价
+	TriggerState      string             `csv:"trigger-state"`                            // 条件单触发状态
+	TriggerTime       string             `csv:"trigger-time"`                             // 条件单触发时间
+}
+
+// + TradeRecord 成交记录，订单的每笔成交一条
+type TradeRecord struct {
+	TradeDate         string  `csv:"date" gorm:"primaryKey"`
+	Account           string  `csv:"account" gorm:"primaryKey"`                // 账户id
+	TradeId           int64   `csv:"id" gorm:"primaryKey;autoIncrement:false"` // 成交id
+	OrderId           int64   `csv:"order-id"`                                 // 订单id
+	TradeTime         string  `csv:"time"`                                     // 成交时间
+	InstId            string  `csv:"inst-id"`                                  // 合约
+	OrderDirection    string  `csv:"side"`                                     // 订单方向 买卖
+	PositionDirection string  `csv:"pos-side"`                                 // 仓位方向 多空
+	TransactionType   string  `csv:"trans-type"`                               // 交易类型 开平
+	Price             float64 `csv:"price"`                                    // 成交价格
+	Qty               float64 `csv:"qty"`                                      // 成交数量
+	Commission        float64 `csv:"commission"`                               // 本笔手续费
+	Liquidity         string  `csv:"liquidity"`                                // 流动性标志 maker/taker
+}
+
+// + RoundTripRecord 完整交易记录，持仓由空仓开仓到全部平仓为一笔
+type RoundTripRecord struct {
+	ExitDate    string  `csv:"exit-date" gorm:"primaryKey"`
+	Account     string  `csv:"account" gorm:"primaryKey"`                // 账户id
+	TripId      int64   `csv:"id" gorm:"primaryKey;autoIncrement:false"` // 交易id，取最后一笔平仓成交id
+	InstId      string  `csv:"inst-id"`                                  // 合约
+	Direction   string  `csv:"direction"`                                // 持仓方向 多空
+	EntryDate   string  `csv:"entry-date"`                               // 首笔开仓日期
+	EntryTime   string  `csv:"entry-time"`                               // 首笔开仓时间
+	ExitTime    string  `csv:"exit-time"`                                // 最后一笔平仓时间
+	EntryPrice  float64 `csv:"entry-price"`                              // 开仓均价(除权除息调整后)
+	ExitPrice   float64 `csv:"exit-price"`                               // 平仓均价
+	Qty         float64 `csv:"qty"`                                      // 累计开仓数量(含送转股)
+	HoldingDays int     `csv:"holding-days"`                             // 持仓交易日数，日内平仓为0
+	Commission  float64 `csv:"commission"`                               // 开平手续费及红利税
+	PnL         float64 `csv:"pnl" gorm:"column:pnl"`                    // 净盈亏(已扣除手续费)
+	MAE         float64 `csv:"mae" gorm:"column:mae"`                    // 最大不利波动，相对开仓均价的收益率
+	MFE         float64 `csv:"mfe" gorm:"column:mfe"`                    // 最大有利波动，相对开仓均价的收益率
 }
 
 // + PositionRecord 持仓记录
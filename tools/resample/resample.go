@@ -0,0 +1,284 @@
+package resample
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+
+// + K线合成:
+// + 由小周期K线合成大周期K线，K线按结束时间标记，与下载的行情一致
+// + 分钟K线在交易时段组内按交易分钟数切分，午休、小节休息不计入，标记为该段最后一个交易分钟的时间
+// + 日线按交易日合成，夜盘归属下一交易日；周线按自然周、月线按自然月合成，标记为最后一个交易日的收盘时间
+// + 开盘取首根、收盘取末根、最高取最大、最低取最小，成交量和成交额累加，其他列取末根的值
+
+// Sources 可以合成目标频率的源频率，按优先级(周期由大到小)排列
+func Sources(to config.Frequency) []config.Frequency {
+	var sources []config.Frequency
+	for i := len(config.Frequencies) - 1; i >= 0; i-- {
+		from := config.Frequencies[i]
+		if from != to && CanResample(from, to) {
+			sources = append(sources, from)
+		}
+	}
+
+	return sources
+}
+
+// CanResample 源频率能否合成目标频率，分钟频率需整除，周线不能合成月线
+func CanResample(from, to config.Frequency) bool {
+	if !from.Valid() || !to.Valid() {
+		return false
+	}
+
+	if from == to {
+		return true
+	}
+
+	n, m := from.Minutes(), to.Minutes()
+	switch {
+	case m > 0:
+		return n > 0 && m%n == 0
+	case to == config.Frequency1Day:
+		return n > 0
+	default:
+		return n > 0 || from == config.Frequency1Day
+	}
+}
+
+// Locate 查找行情目录，目标频率的目录存在时直接使用，否则使用可以合成目标频率的最大周期目录
+func Locate(download string, to config.Frequency) (dir string, from config.Frequency, err error) {
+	for _, freq := range append([]config.Frequency{to}, Sources(to)...) {
+		dir = filepath.Join(download, string(freq))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, freq, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("行情目录 %s 不存在，也没有可以合成该频率的行情", filepath.Join(download, string(to)))
+}
+
+// Resampler 按行情文件的列逐根合成K线
+type Resampler struct {
+	to       config.Frequency
+	schedule Schedule
+	headers  map[string]int
+
+	key   bucket
+	label time.Time
+	bar   []string // 合成中的K线，nil表示没有
+
+	high, low, volume, amount float64
+}
+
+// bucket 合成K线的区间
+type bucket struct {
+	day   int64 // 交易日或时段组起始日
+	group int
+	n     int
+}
+
+// New 创建合成器，headers为行情文件的列
+func New(to config.Frequency, instID string, headers map[string]int) (*Resampler, error) {
+	if !to.Valid() {
+		return nil, fmt.Errorf("频率[%s]不支持", to)
+	}
+
+	if _, ok := headers["Time"]; !ok {
+		return nil, fmt.Errorf("行情缺少 Time 列")
+	}
+
+	schedule, err := ScheduleOf(instID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resampler{to: to, schedule: schedule, headers: headers}, nil
+}
+
+// Add 加入一根源K线，源K线进入新的区间时返回上一根合成完成的K线
+func (r *Resampler) Add(record []string) ([]string, error) {
+	tm, err := time.ParseInLocation(config.TimeFormatDefault, r.field(record, "Time"), time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("时间格式错误: %w", err)
+	}
+
+	key, label, ok := r.bucket(tm)
+	if !ok && r.bar != nil {
+		// 不在交易时段内的分钟K线并入当前K线
+		key, label = r.key, r.label
+	}
+
+	var done []string
+	if r.bar != nil && key != r.key {
+		done = r.Flush()
+	}
+
+	if err := r.merge(record); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.field(record, "Time"), err)
+	}
+
+	r.key, r.label = key, label
+
+	return done, nil
+}
+
+// Flush 返回合成中的K线，没有时返回nil
+func (r *Resampler) Flush() []string {
+	if r.bar == nil {
+		return nil
+	}
+
+	bar := r.bar
+	r.bar = nil
+
+	r.set(bar, "High", r.high)
+	r.set(bar, "Low", r.low)
+	r.set(bar, "Volume", r.volume)
+	r.set(bar, "Amount", r.amount)
+	if i, ok := r.headers["Time"]; ok {
+		bar[i] = r.label.Format(config.TimeFormatDefault)
+	}
+	if i, ok := r.headers["Date"]; ok {
+		bar[i] = r.label.Format(config.TimeFormatDate)
+	}
+
+	return bar
+}
+
+// Label 源K线所属的合成K线的时间
+func (r *Resampler) Label(tm time.Time) time.Time {
+	_, label, _ := r.bucket(tm)
+	return label
+}
+
+// bucket K线所属的区间及合成K线的时间
+func (r *Resampler) bucket(tm time.Time) (bucket, time.Time, bool) {
+	if n := r.to.Minutes(); n > 0 {
+		p, ok := r.schedule.locate(tm)
+		if !ok {
+			return bucket{day: tm.Unix(), group: -1}, tm, false
+		}
+
+		// 开盘时刻的K线归入第一段
+		k := max((p.elapsed+n-1)/n, 1)
+		end := min(k*n, r.schedule.minutes(p.group))
+
+		return bucket{day: p.day.Unix(), group: p.group, n: k}, r.schedule.at(p.day, p.group, end), true
+	}
+
+	day := r.schedule.tradingDay(tm)
+	label := day.Add(r.schedule.close())
+	switch r.to {
+	case config.Frequency1Week:
+		year, week := day.ISOWeek()
+		return bucket{n: year*100 + week}, label, true
+	case config.Frequency1Month:
+		return bucket{n: day.Year()*100 + int(day.Month())}, label, true
+	default:
+		return bucket{day: day.Unix()}, label, true
+	}
+}
+
+// merge 将源K线合并到合成中的K线
+func (r *Resampler) merge(record []string) error {
+	high, err := r.float(record, "High")
+	if err != nil {
+		return err
+	}
+
+	low, err := r.float(record, "Low")
+	if err != nil {
+		return err
+	}
+
+	volume, err := r.float(record, "Volume")
+	if err != nil {
+		return err
+	}
+
+	amount, err := r.float(record, "Amount")
+	if err != nil {
+		return err
+	}
+
+	if r.bar == nil {
+		r.bar = append(make([]string, 0, len(record)), record...)
+		r.high, r.low, r.volume, r.amount = high, low, volume, amount
+		return nil
+	}
+
+	// 开盘价保留首根，其他列取末根
+	open := r.field(r.bar, "Open")
+	copy(r.bar, record)
+	if i, ok := r.headers["Open"]; ok {
+		r.bar[i] = open
+	}
+
+	r.high = max(r.high, high)
+	r.low = min(r.low, low)
+	r.volume += volume
+	r.amount += amount
+
+	return nil
+}
+
+func (r *Resampler) field(record []string, name string) string {
+	if i, ok := r.headers[name]; ok && i < len(record) {
+		return record[i]
+	}
+
+	return ""
+}
+
+// float 读取数值列，行情没有该列时为0
+func (r *Resampler) float(record []string, name string) (float64, error) {
+	v := r.field(record, name)
+	if v == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s 列数值错误: %w", name, err)
+	}
+
+	return f, nil
+}
+
+func (r *Resampler) set(record []string, name string, v float64) {
+	if i, ok := r.headers[name]; ok && i < len(record) {
+		record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+}
+
+// Frame 合成整个行情表
+func Frame(df dataframe.DataFrame, to config.Frequency, instID string) (dataframe.DataFrame, error) {
+	r, err := New(to, instID, df.HeaderToIndex)
+	if err != nil {
+		return dataframe.DataFrame{}, err
+	}
+
+	out := dataframe.DataFrame{HeaderToIndex: df.HeaderToIndex, Header: df.Header}
+	for _, record := range df.FrameRecords {
+		bar, err := r.Add(record.Data)
+		if err != nil {
+			return dataframe.DataFrame{}, err
+		}
+
+		if bar != nil {
+			out.FrameRecords = append(out.FrameRecords, dataframe.Record{Data: bar})
+		}
+	}
+
+	if bar := r.Flush(); bar != nil {
+		out.FrameRecords = append(out.FrameRecords, dataframe.Record{Data: bar})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package resample
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wonderstone/QuantKit/config"
+	"github.com/wonderstone/QuantKit/tools/dataframe"
+)
+
+// bars 按时间生成K线，第i根: 开i 收i+0.5 高i+1 低i-1 量1 额10
+func bars(times ...time.Time) dataframe.DataFrame {
+	df := dataframe.CreateNewDataFrame([]string{"Date", "Time", "Open", "Close", "High", "Low", "Volume", "Amount"})
+	for i, tm := range times {
+		v := float64(i + 1)
+		df.FrameRecords = append(
+			df.FrameRecords, dataframe.Record{
+				Data: []string{
+					tm.Format(config.TimeFormatDate), tm.Format(config.TimeFormatDefault),
+					strconv.FormatFloat(v, 'f', -1, 64), strconv.FormatFloat(v+0.5, 'f', -1, 64),
+					strconv.FormatFloat(v+1, 'f', -1, 64), strconv.FormatFloat(v-1, 'f', -1, 64),
+					"1", "10",
+				},
+			},
+		)
+	}
+
+	return df
+}
+
+// every 按间隔生成[begin, end]内的K线时间
+func every(begin, end time.Time, step time.Duration) []time.Time {
+	var times []time.Time
+	for tm := begin; !tm.After(end); tm = tm.Add(step) {
+		times = append(times, tm)
+	}
+
+	return times
+}
+
+func at(day time.Time, hour, minute int) time.Time {
+	return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+func labels(df dataframe.DataFrame) []string {
+	var out []string
+	for _, record := range df.FrameRecords {
+		out = append(out, record.Val("Time", df.HeaderToIndex))
+	}
+
+	return out
+}
+
+// 测试股票分钟线合成60分钟线: 午休不计入，上午两根、下午两根
+func TestStockMinute(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+	times := append(every(at(day, 9, 31), at(day, 11, 30), time.Minute), every(at(day, 13, 1), at(day, 15, 0), time.Minute)...)
+
+	out, err := Frame(bars(times...), config.Frequency60Min, "000001.XSHE.CS")
+	require.NoError(t, err)
+	require.Equal(
+		t, []string{
+			"2024.01.02T10:30:00.000", "2024.01.02T11:30:00.000",
+			"2024.01.02T14:00:00.000", "2024.01.02T15:00:00.000",
+		}, labels(out),
+	)
+
+	first := out.FrameRecords[0]
+	require.Equal(t, "1", first.Val("Open", out.HeaderToIndex))
+	require.Equal(t, "60.5", first.Val("Close", out.HeaderToIndex))
+	require.Equal(t, "61", first.Val("High", out.HeaderToIndex))
+	require.Equal(t, "0", first.Val("Low", out.HeaderToIndex))
+	require.Equal(t, "60", first.Val("Volume", out.HeaderToIndex))
+	require.Equal(t, "600", first.Val("Amount", out.HeaderToIndex))
+	require.Equal(t, "20240102", first.Val("Date", out.HeaderToIndex))
+
+	// 日线
+	out, err = Frame(bars(times...), config.Frequency1Day, "000001.XSHE.CS")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024.01.02T15:00:00.000"}, labels(out))
+	require.Equal(t, "240", out.FrameRecords[0].Val("Volume", out.HeaderToIndex))
+}
+
+// 测试期货合成: 夜盘跨零点，日盘小节休息不计入，夜盘归属下一交易日
+func TestFutureSession(t *testing.T) {
+	friday := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	monday := friday.AddDate(0, 0, 3)
+	times := every(at(friday, 21, 15), at(friday, 26, 30), 15*time.Minute)
+	times = append(times, every(at(monday, 9, 15), at(monday, 10, 15), 15*time.Minute)...)
+	times = append(times, every(at(monday, 10, 45), at(monday, 11, 30), 15*time.Minute)...)
+	times = append(times, every(at(monday, 13, 45), at(monday, 15, 0), 15*time.Minute)...)
+
+	out, err := Frame(bars(times...), config.Frequency60Min, "rb2405.XSGE.CF")
+	require.NoError(t, err)
+	require.Equal(
+		t, []string{
+			"2024.01.05T22:00:00.000", "2024.01.05T23:00:00.000", "2024.01.06T00:00:00.000",
+			"2024.01.06T01:00:00.000", "2024.01.06T02:00:00.000", "2024.01.06T02:30:00.000",
+			"2024.01.08T10:00:00.000", "2024.01.08T11:15:00.000", "2024.01.08T14:15:00.000",
+			"2024.01.08T15:00:00.000",
+		}, labels(out),
+	)
+
+	out, err = Frame(bars(times...), config.Frequency1Day, "rb2405.XSGE.CF")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024.01.08T15:00:00.000"}, labels(out))
+	require.Equal(t, "1", out.FrameRecords[0].Val("Open", out.HeaderToIndex))
+	require.Equal(t, strconv.Itoa(len(times)), out.FrameRecords[0].Val("Volume", out.HeaderToIndex))
+}
+
+// 测试日线合成周线、月线: 标记为最后一个交易日的收盘时间
+func TestWeekMonth(t *testing.T) {
+	var times []time.Time
+	for day := time.Date(2024, 1, 24, 0, 0, 0, 0, time.Local); day.Month() < 3; day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			times = append(times, at(day, 15, 0))
+		}
+	}
+
+	out, err := Frame(bars(times...), config.Frequency1Week, "000001.XSHE.CS")
+	require.NoError(t, err)
+	require.Equal(t, "2024.01.26T15:00:00.000", labels(out)[0])
+	require.Equal(t, "2024.02.02T15:00:00.000", labels(out)[1])
+	require.Equal(t, "2024.02.29T15:00:00.000", labels(out)[len(out.FrameRecords)-1])
+	require.Equal(t, "3", out.FrameRecords[0].Val("Volume", out.HeaderToIndex))
+
+	out, err = Frame(bars(times...), config.Frequency1Month, "000001.XSHE.CS")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024.01.31T15:00:00.000", "2024.02.29T15:00:00.000"}, labels(out))
+	require.Equal(t, "7", out.FrameRecords[1].Val("Open", out.HeaderToIndex))
+}
+
+// 测试按合约类型选择交易时段，未知的合约类型报错
+func TestScheduleOf(t *testing.T) {
+	schedule, err := ScheduleOf("000001.XSHE.CS")
+	require.NoError(t, err)
+	require.Equal(t, StockSchedule, schedule)
+
+	schedule, err = ScheduleOf("rb2405.XSGE.CF")
+	require.NoError(t, err)
+	require.Equal(t, FutureSchedule, schedule)
+
+	_, err = ScheduleOf("000300.XSHG.INDX")
+	require.Error(t, err)
+
+	_, err = ScheduleOf("000001")
+	require.Error(t, err)
+
+	_, err = Frame(bars(time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)), config.Frequency1Day, "000300.XSHG.INDX")
+	require.Error(t, err)
+}
+
+func TestLocate(t *testing.T) {
+	require.True(t, CanResample(config.Frequency15Min, config.Frequency60Min))
+	require.False(t, CanResample(config.Frequency15Min, config.Frequency5Min))
+	require.False(t, CanResample(config.Frequency1Week, config.Frequency1Month))
+	require.Equal(t, []config.Frequency{config.Frequency1Day, config.Frequency120Min, config.Frequency60Min, config.Frequency30Min,
+		config.Frequency15Min, config.Frequency5Min, config.Frequency1Min}, Sources(config.Frequency1Week))
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "5min"), 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "15min"), 0755))
+
+	path, from, err := Locate(dir, config.Frequency30Min)
+	require.NoError(t, err)
+	require.Equal(t, config.Frequency15Min, from)
+	require.Equal(t, filepath.Join(dir, "15min"), path)
+
+	_, _, err = Locate(dir, config.Frequency1Min)
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package resample
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session 交易时段，为相对时段组起始日零点的偏移，夜盘跨零点时结束时间超过24小时
+type Session struct {
+	Begin time.Duration
+	End   time.Duration
+}
+
+// Schedule 交易时段组，按交易日内的先后排列(夜盘在前)
+// + 同一组内的时段连续累计交易分钟数，午休、小节休息不计入
+// + 分钟K线不跨组合成，夜盘和日盘分别计算
+type Schedule [][]Session
+
+var (
+	// StockSchedule 股票: 上午、下午两个时段
+	StockSchedule = Schedule{
+		{
+			{Begin: 9*time.Hour + 30*time.Minute, End: 11*time.Hour + 30*time.Minute},
+			{Begin: 13 * time.Hour, End: 15 * time.Hour},
+		},
+	}
+
+	// FutureSchedule 期货: 夜盘归属下一交易日，日盘上午有小节休息
+	FutureSchedule = Schedule{
+		{
+			{Begin: 21 * time.Hour, End: 26*time.Hour + 30*time.Minute},
+		},
+		{
+			{Begin: 9 * time.Hour, End: 10*time.Hour + 15*time.Minute},
+			{Begin: 10*time.Hour + 30*time.Minute, End: 11*time.Hour + 30*time.Minute},
+			{Begin: 13*time.Hour + 30*time.Minute, End: 15 * time.Hour},
+		},
+	}
+)
+
+// ScheduleOf 按合约类型选择交易时段，合约代码格式为 合约代码.交易所Mic码.合约类型，CS为股票，CF为期货
+func ScheduleOf(instID string) (Schedule, error) {
+	instIdSlice := strings.Split(instID, ".")
+	if len(instIdSlice) != 3 {
+		return nil, fmt.Errorf("合约代码格式错误，应该为: 合约代码.交易所Mic码.合约类型，例如：600000.XSHG.CS: %s", instID)
+	}
+
+	switch instIdSlice[2] {
+	case "CS":
+		return StockSchedule, nil
+	case "CF":
+		return FutureSchedule, nil
+	default:
+		return nil, fmt.Errorf("未知的合约类型 %s, 无法确定 %s 的交易时段", instIdSlice[2], instID)
+	}
+}
+
+// position K线在交易时段中的位置
+type position struct {
+	day     time.Time // 时段组起始日零点
+	group   int       // 时段组序号
+	elapsed int       // 时段组内已交易的分钟数
+}
+
+// locate 定位K线所在的时段组，K线按结束时间标记，时段的开始和结束时间都属于该时段
+func (s Schedule) locate(tm time.Time) (position, bool) {
+	midnight := time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, tm.Location())
+	offset := tm.Sub(midnight)
+
+	for g, sessions := range s {
+		var elapsed time.Duration
+		for _, session := range sessions {
+			// 夜盘零点之后的K线属于前一日开始的时段
+			for _, back := range []int{0, 1} {
+				at := offset + time.Duration(back)*24*time.Hour
+				if at >= session.Begin && at <= session.End {
+					return position{
+						day:     midnight.AddDate(0, 0, -back),
+						group:   g,
+						elapsed: int((elapsed + at - session.Begin) / time.Minute),
+					}, true
+				}
+			}
+
+			elapsed += session.End - session.Begin
+		}
+	}
+
+	return position{}, false
+}
+
+// minutes 时段组的交易分钟数
+func (s Schedule) minutes(group int) int {
+	var total time.Duration
+	for _, session := range s[group] {
+		total += session.End - session.Begin
+	}
+
+	return int(total / time.Minute)
+}
+
+// at 时段组内第elapsed个交易分钟结束的时间
+func (s Schedule) at(day time.Time, group, elapsed int) time.Time {
+	remain := time.Duration(elapsed) * time.Minute
+	sessions := s[group]
+	for _, session := range sessions {
+		if remain <= session.End-session.Begin {
+			return day.Add(session.Begin + remain)
+		}
+
+		remain -= session.End - session.Begin
+	}
+
+	return day.Add(sessions[len(sessions)-1].End)
+}
+
+// close 交易日收盘时间，即最后一个时段的结束时间
+func (s Schedule) close() time.Duration {
+	sessions := s[len(s)-1]
+	return sessions[len(sessions)-1].End
+}
+
+// tradingDay K线所属的交易日，夜盘归属下一个工作日，不在交易时段内的K线按自然日
+func (s Schedule) tradingDay(tm time.Time) time.Time {
+	p, ok := s.locate(tm)
+	if !ok {
+		return time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, tm.Location())
+	}
+
+	if p.group == len(s)-1 {
+		return p.day
+	}
+
+	day := p.day.AddDate(0, 0, 1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return day
+}
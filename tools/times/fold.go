@@ -0,0 +1,49 @@
+package times
+
+import "time"
+
+// Fold 滚动训练的一个分段: 训练段 + 紧随其后的验证段
+type Fold struct {
+	TrainBegin time.Time
+	TrainEnd   time.Time
+	TestBegin  time.Time
+	TestEnd    time.Time
+}
+
+// SplitFolds 按月切分训练段和验证段，验证段长度同时也是每次滚动的步长
+// anchored 为 true 时训练段起点固定为 begin，否则训练段长度固定
+// 最后一个验证段截止到 end，区间不足一个训练段时返回空
+func SplitFolds(begin, end time.Time, trainMonths, testMonths int, anchored bool) []Fold {
+	if trainMonths <= 0 || testMonths <= 0 {
+		return nil
+	}
+
+	var folds []Fold
+	for i := 0; ; i++ {
+		trainBegin := begin.AddDate(0, i*testMonths, 0)
+		if anchored {
+			trainBegin = begin
+		}
+
+		testBegin := begin.AddDate(0, trainMonths+i*testMonths, 0)
+		if testBegin.After(end) {
+			break
+		}
+
+		testEnd := testBegin.AddDate(0, testMonths, -1)
+		if testEnd.After(end) {
+			testEnd = end
+		}
+
+		folds = append(
+			folds, Fold{
+				TrainBegin: trainBegin,
+				TrainEnd:   testBegin.AddDate(0, 0, -1),
+				TestBegin:  testBegin,
+				TestEnd:    testEnd,
+			},
+		)
+	}
+
+	return folds
+}
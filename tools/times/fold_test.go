@@ -0,0 +1,34 @@
+package times
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFolds(t *testing.T) {
+	begin := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(2021, 2, 15, 0, 0, 0, 0, time.Local)
+	date := func(tm time.Time) string { return tm.Format(time.DateOnly) }
+
+	folds := SplitFolds(begin, end, 6, 3, false)
+	require.Len(t, folds, 3)
+	require.Equal(t, "2020-01-01", date(folds[0].TrainBegin))
+	require.Equal(t, "2020-06-30", date(folds[0].TrainEnd))
+	require.Equal(t, "2020-07-01", date(folds[0].TestBegin))
+	require.Equal(t, "2020-09-30", date(folds[0].TestEnd))
+	require.Equal(t, "2020-04-01", date(folds[1].TrainBegin))
+	require.Equal(t, "2021-01-01", date(folds[2].TestBegin))
+	require.Equal(t, "2021-02-15", date(folds[2].TestEnd))
+
+	anchored := SplitFolds(begin, end, 6, 3, true)
+	require.Len(t, anchored, 3)
+	for _, f := range anchored {
+		require.Equal(t, "2020-01-01", date(f.TrainBegin))
+	}
+	require.Equal(t, "2020-12-31", date(anchored[2].TrainEnd))
+
+	require.Empty(t, SplitFolds(begin, begin.AddDate(0, 5, 0), 6, 3, false))
+	require.Empty(t, SplitFolds(begin, end, 0, 3, false))
+}